@@ -2,18 +2,35 @@ package main
 
 import (
 	"dotdot/internal/cli"
+	"dotdot/internal/config"
 	"dotdot/internal/storage"
 	"dotdot/internal/tui"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strconv"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea/v2"
 )
 
+// version is the application version, set at build time with
+// -ldflags "-X main.version=v1.2.3"
+var version = "dev"
+
 func main() {
-	cmd, err := cli.ParseArgs()
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v, using default settings\n", err)
+	}
+
+	cmd, err := cli.ParseArgs(cfg)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
@@ -21,24 +38,127 @@ func main() {
 
 	switch cmd.Action {
 	case "open":
-		runTUI(cmd.FilePath)
+		runTUI(cmd, cfg)
 	case "list":
 		listTasks(cmd)
 	case "delete":
-		deleteTasks(cmd)
+		deleteTasks(cmd, cfg)
+	case "rename":
+		renameTasks(cmd)
+	case "estimate":
+		printEstimate(cmd)
+	case "merge":
+		mergeTasks(cmd)
+	case "normalize":
+		normalizeTasks(cmd)
+	case "migrate":
+		migrateTasks(cmd)
+	case "validate":
+		validateTasks(cmd)
+	case "restore":
+		restoreTasks(cmd)
+	case "clear-done":
+		clearDoneTasks(cmd)
+	case "export":
+		exportTasks(cmd)
+	case "import":
+		importTasks(cmd)
+	case "add":
+		addTask(cmd)
+	case "done":
+		markTaskDone(cmd)
+	case "tree":
+		printTree(cmd)
+	case "stats":
+		printStats(cmd)
+	case "path":
+		printPath(cmd)
+	case "version":
+		printVersion(cmd)
+	case "completion":
+		printCompletion(cmd)
+	case cli.CompleteAction:
+		completeNames(cmd)
 	default:
 		fmt.Fprintf(os.Stderr, "Unknown action: %s\n", cmd.Action)
 		os.Exit(1)
 	}
 }
 
-func runTUI(filePath string) {
-	model := tui.NewModelWithFile(filePath)
+func runTUI(cmd *cli.Command, cfg config.Config) {
+	// Invalid bullet overrides fall back to the defaults
+	_, warnings := cfg.BulletOverrides()
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", warning)
+	}
+
+	theme, err := config.SelectTheme(cmd.Theme)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v, using the default theme\n", err)
+	}
+	tui.ApplyTheme(theme)
 
-	program := tea.NewProgram(model, tea.WithAltScreen())
-	if _, err := program.Run(); err != nil {
+	model := tui.NewModelWithConfig(cmd.FilePath, cfg)
+	keys, err := config.LoadKeys()
+	if err == nil {
+		err = model.OverrideKeys(keys)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: keybindings: %v\n", err)
+		os.Exit(1)
+	}
+	if cmd.History != nil {
+		model.SetMaxHistory(*cmd.History)
+	}
+	model.SetASCII(cmd.ASCII || !config.UTF8Locale())
+	model.SetConfirmDelete(cmd.ConfirmDelete)
+	model.SetReadOnly(cmd.ReadOnly)
+
+	// Resume where the list was left; the state is a convenience, so problems
+	// with it never stop the list from opening
+	statePath, err := config.StatePath()
+	var state config.State
+	if err == nil {
+		state, err = config.LoadStateFile(statePath)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	model.RestoreCursor(state.LastCursor(cmd.FilePath))
+
+	// Inline mode leaves the final view in the terminal's scrollback
+	var opts []tea.ProgramOption
+	if cmd.Inline {
+		model.SetInline(true)
+	} else {
+		opts = append(opts, tea.WithAltScreen())
+	}
+
+	// Another dotdot with the list open would otherwise silently overwrite its
+	// changes. Browsing read-only never writes, so it needs no lock.
+	var lock *storage.FileLock
+	if !cmd.ReadOnly {
+		lock, err = storage.LockFile(cmd.FilePath, cmd.Force)
+		if errors.Is(err, storage.ErrLocked) {
+			model.SetLockedOut(err)
+		} else if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+
+	program := tea.NewProgram(model, opts...)
+	final, err := program.Run()
+	lock.Release()
+	if err != nil {
 		log.Fatal(err)
 	}
+
+	if final, ok := final.(tui.Model); ok && state.Cursors != nil {
+		state.SetLastCursor(cmd.FilePath, final.CursorID())
+		if err := config.SaveStateFile(statePath, state); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
 }
 
 func listTasks(cmd *cli.Command) {
@@ -67,7 +187,7 @@ func listTasks(cmd *cli.Command) {
 		fmt.Printf("%s task lists:\n", location)
 		for _, name := range taskLists {
 			if cmd.Local {
-				fmt.Printf("  %s.dot\n", name)
+				fmt.Printf("  %s\n", storage.TaskFileName(name))
 			} else {
 				fmt.Printf("  %s\n", name)
 			}
@@ -75,7 +195,7 @@ func listTasks(cmd *cli.Command) {
 	}
 }
 
-func deleteTasks(cmd *cli.Command) {
+func deleteTasks(cmd *cli.Command, cfg config.Config) {
 	if !storage.FileExists(cmd.FilePath) {
 		fmt.Fprintf(os.Stderr, "Task list file does not exist: %s\n", cmd.FilePath)
 		os.Exit(1)
@@ -83,8 +203,14 @@ func deleteTasks(cmd *cli.Command) {
 
 	// Confirm deletion
 	fmt.Printf("Are you sure you want to delete '%s'? (y/N): ", cmd.FilePath)
-	var response string
-	if _, err := fmt.Scanln(&response); err != nil || (response != "y" && response != "Y" && response != "yes" && response != "Yes") {
+	timeout := time.Duration(cfg.DeleteConfirmTimeout) * time.Second
+	response, ok := readResponse(timeout)
+	if !ok {
+		fmt.Println()
+		fmt.Println("No response, deletion cancelled")
+		return
+	}
+	if response != "y" && response != "Y" && response != "yes" && response != "Yes" {
 		fmt.Println("Deletion cancelled")
 		return
 	}
@@ -96,3 +222,555 @@ func deleteTasks(cmd *cli.Command) {
 
 	fmt.Printf("Successfully deleted task list: %s\n", cmd.FilePath)
 }
+
+// readResponse reads a single answer from stdin. With a non-zero timeout it
+// gives up after that long and returns false, which callers treat as "no".
+func readResponse(timeout time.Duration) (string, bool) {
+	responses := make(chan string, 1)
+	go func() {
+		var response string
+		fmt.Scanln(&response) // An empty or unreadable line counts as "no"
+		responses <- response
+	}()
+
+	if timeout <= 0 {
+		return <-responses, true
+	}
+
+	select {
+	case response := <-responses:
+		return response, true
+	case <-time.After(timeout):
+		return "", false
+	}
+}
+
+// restoreTasks replaces a task list with a backup, given with --backup or
+// picked from the list's backups, after confirming unless --force is set
+func restoreTasks(cmd *cli.Command) {
+	backup := cmd.Backup
+	if backup == "" {
+		backups, err := storage.ListBackups(cmd.FilePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error listing backups: %v\n", err)
+			os.Exit(1)
+		}
+		if len(backups) == 0 {
+			fmt.Fprintf(os.Stderr, "No backups found for %s\n", cmd.FilePath)
+			os.Exit(1)
+		}
+		if backup = chooseBackup(backups); backup == "" {
+			fmt.Println("Restore cancelled")
+			return
+		}
+	} else if !storage.FileExists(backup) {
+		fmt.Fprintf(os.Stderr, "Backup file does not exist: %s\n", backup)
+		os.Exit(1)
+	}
+
+	if storage.FileExists(cmd.FilePath) && !cmd.Force {
+		fmt.Printf("Replace '%s' with '%s'? (y/N): ", cmd.FilePath, backup)
+		response, _ := readResponse(0)
+		if response != "y" && response != "Y" && response != "yes" && response != "Yes" {
+			fmt.Println("Restore cancelled")
+			return
+		}
+	}
+
+	if err := storage.RestoreBackup(cmd.FilePath, backup); err != nil {
+		fmt.Fprintf(os.Stderr, "Error restoring task list: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Restored %s from %s\n", cmd.FilePath, backup)
+}
+
+// chooseBackup returns the only backup, or asks which one to restore and
+// returns "" if none was picked
+func chooseBackup(backups []string) string {
+	if len(backups) == 1 {
+		return backups[0]
+	}
+
+	fmt.Println("Backups, newest first:")
+	for i, backup := range backups {
+		details := ""
+		if info, err := storage.GetFileInfo(backup); err == nil {
+			details = " (saved " + info.Modified.Format(time.DateTime) + ")"
+		}
+		fmt.Printf("  %d) %s%s\n", i+1, backup, details)
+	}
+	fmt.Printf("Restore which backup? (1-%d): ", len(backups))
+	response, _ := readResponse(0)
+	n, err := strconv.Atoi(response)
+	if err != nil || n < 1 || n > len(backups) {
+		return ""
+	}
+	return backups[n-1]
+}
+
+func renameTasks(cmd *cli.Command) {
+	err := storage.RenameTaskList(cmd.FilePath, cmd.NewFilePath, cmd.Force)
+	if errors.Is(err, storage.ErrTargetExists) {
+		fmt.Fprintf(os.Stderr, "Task list already exists: %s (use --force to overwrite)\n", cmd.NewFilePath)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error renaming task list: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Successfully renamed task list: %s -> %s\n", cmd.FilePath, cmd.NewFilePath)
+}
+
+func printEstimate(cmd *cli.Command) {
+	tasks, err := storage.LoadTasks(cmd.FilePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading task list: %v\n", err)
+		os.Exit(1)
+	}
+
+	total := storage.TotalEstimate(tasks)
+	if total == 0 {
+		fmt.Println("No estimates set")
+		return
+	}
+	fmt.Printf("Total estimate: %s\n", storage.FormatEstimate(total))
+}
+
+// listStats is the machine-readable form of the stats command's output
+type listStats struct {
+	Path    string    `json:"path"`
+	Version string    `json:"version,omitempty"`
+	Created time.Time `json:"created,omitzero"`
+	Updated time.Time `json:"updated,omitzero"`
+	storage.ListStats
+	PercentDone int `json:"percent_done"`
+}
+
+// printStats prints a task list's file details and how many of its tasks,
+// at any depth, are in each status
+func printStats(cmd *cli.Command) {
+	if !storage.FileExists(cmd.FilePath) {
+		fmt.Fprintf(os.Stderr, "Task list file does not exist: %s\n", cmd.FilePath)
+		os.Exit(1)
+	}
+
+	info, err := storage.GetFileInfo(cmd.FilePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading task list: %v\n", err)
+		os.Exit(1)
+	}
+	tasks, err := storage.LoadTasks(cmd.FilePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading task list: %v\n", err)
+		os.Exit(1)
+	}
+
+	path, err := filepath.Abs(cmd.FilePath)
+	if err != nil {
+		path = cmd.FilePath
+	}
+	counts := storage.CountTasks(tasks)
+	stats := listStats{
+		Path:        path,
+		Version:     info.Version,
+		Created:     info.Created,
+		Updated:     info.Updated,
+		ListStats:   counts,
+		PercentDone: counts.PercentDone(),
+	}
+
+	if cmd.JSON {
+		data, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding stats: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	fmt.Printf("Path: %s\n", stats.Path)
+	if stats.Version != "" {
+		fmt.Printf("Version: %s\n", stats.Version)
+	}
+	if !stats.Created.IsZero() {
+		fmt.Printf("Created: %s\n", stats.Created.Local().Format(time.DateTime))
+	}
+	if !stats.Updated.IsZero() {
+		fmt.Printf("Updated: %s\n", stats.Updated.Local().Format(time.DateTime))
+	}
+	fmt.Printf("Tasks: %d (%d todo, %d active, %d done)\n", counts.Total, counts.Todo, counts.Active, counts.Done)
+	fmt.Printf("Complete: %d%%\n", stats.PercentDone)
+}
+
+// printPath prints the absolute path of the task list without creating it
+func printPath(cmd *cli.Command) {
+	path, err := filepath.Abs(cmd.FilePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error resolving path: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(path)
+}
+
+// versionInfo describes the running binary for bug reports
+type versionInfo struct {
+	Version       string `json:"version"`
+	FormatVersion string `json:"format_version"`
+	GoVersion     string `json:"go_version"`
+	Platform      string `json:"platform"`
+	Revision      string `json:"revision,omitempty"`
+	BuildTime     string `json:"build_time,omitempty"`
+	Modified      bool   `json:"modified,omitempty"`
+}
+
+// buildVersionInfo collects version details from the binary's embedded build info
+func buildVersionInfo() versionInfo {
+	info := versionInfo{
+		Version:       version,
+		FormatVersion: storage.CurrentVersion,
+		GoVersion:     runtime.Version(),
+		Platform:      runtime.GOOS + "/" + runtime.GOARCH,
+	}
+
+	buildInfo, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	if info.Version == "dev" && buildInfo.Main.Version != "" && buildInfo.Main.Version != "(devel)" {
+		info.Version = buildInfo.Main.Version // Installed with go install
+	}
+	for _, setting := range buildInfo.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			info.Revision = setting.Value
+		case "vcs.time":
+			info.BuildTime = setting.Value
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+	return info
+}
+
+func printVersion(cmd *cli.Command) {
+	info := buildVersionInfo()
+
+	if cmd.JSON {
+		data, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding version info: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	fmt.Printf("dotdot %s\n", info.Version)
+	fmt.Printf("Task file format: %s\n", info.FormatVersion)
+	fmt.Printf("Go: %s (%s)\n", info.GoVersion, info.Platform)
+	if info.Revision != "" {
+		revision := info.Revision
+		if info.Modified {
+			revision += " (modified)"
+		}
+		fmt.Printf("Revision: %s\n", revision)
+	}
+	if info.BuildTime != "" {
+		fmt.Printf("Built: %s\n", info.BuildTime)
+	}
+}
+
+func mergeTasks(cmd *cli.Command) {
+	leftPath, rightPath := cmd.Args[0], cmd.Args[1]
+	for _, path := range cmd.Args {
+		if !storage.FileExists(path) {
+			fmt.Fprintf(os.Stderr, "Task list file does not exist: %s\n", path)
+			os.Exit(1)
+		}
+	}
+
+	if storage.FileExists(cmd.Output) && !cmd.Force {
+		fmt.Fprintf(os.Stderr, "Output file already exists: %s (use --force to overwrite)\n", cmd.Output)
+		os.Exit(1)
+	}
+
+	left, err := storage.LoadTasks(leftPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading %s: %v\n", leftPath, err)
+		os.Exit(1)
+	}
+	right, err := storage.LoadTasks(rightPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading %s: %v\n", rightPath, err)
+		os.Exit(1)
+	}
+
+	merged, conflicts := storage.MergeTasks(left, right)
+	if err := storage.SaveTasks(cmd.Output, merged); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving merged task list: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Merged %s and %s into %s\n", leftPath, rightPath, cmd.Output)
+	if len(conflicts) > 0 {
+		fmt.Fprintf(os.Stderr, "%d conflict(s) kept the version from %s:\n", len(conflicts), leftPath)
+		for _, conflict := range conflicts {
+			fmt.Fprintf(os.Stderr, "  %s\n", conflict)
+		}
+		os.Exit(1)
+	}
+}
+
+func normalizeTasks(cmd *cli.Command) {
+	if !storage.FileExists(cmd.FilePath) {
+		fmt.Fprintf(os.Stderr, "Task list file does not exist: %s\n", cmd.FilePath)
+		os.Exit(1)
+	}
+
+	fixes, err := storage.NormalizeFile(cmd.FilePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error normalizing task list: %v\n", err)
+		os.Exit(1)
+	}
+	if len(fixes) == 0 {
+		fmt.Println("Nothing to normalize")
+		return
+	}
+
+	fmt.Printf("Normalized %s (%d fix(es)):\n", cmd.FilePath, len(fixes))
+	for _, fix := range fixes {
+		fmt.Printf("  %s\n", fix)
+	}
+}
+
+// migrateTasks rewrites a task file in the current format
+func migrateTasks(cmd *cli.Command) {
+	if !storage.FileExists(cmd.FilePath) {
+		fmt.Fprintf(os.Stderr, "Task list file does not exist: %s\n", cmd.FilePath)
+		os.Exit(1)
+	}
+
+	changes, err := storage.MigrateFile(cmd.FilePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error migrating task list: %v\n", err)
+		os.Exit(1)
+	}
+	if len(changes) == 0 {
+		fmt.Printf("%s is already in the current format (version %s)\n", cmd.FilePath, storage.CurrentVersion)
+		return
+	}
+
+	fmt.Printf("Migrated %s to version %s:\n", cmd.FilePath, storage.CurrentVersion)
+	for _, change := range changes {
+		fmt.Printf("  %s\n", change)
+	}
+}
+
+// validateTasks reports the problems in a task file and exits non-zero if
+// there are any, for use in scripts and pre-commit hooks
+func validateTasks(cmd *cli.Command) {
+	if !storage.FileExists(cmd.FilePath) {
+		fmt.Fprintf(os.Stderr, "Task list file does not exist: %s\n", cmd.FilePath)
+		os.Exit(1)
+	}
+
+	problems, err := storage.ValidateFile(cmd.FilePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(problems) == 0 {
+		fmt.Printf("%s is valid\n", cmd.FilePath)
+		return
+	}
+
+	fmt.Printf("%s has %d problem(s):\n", cmd.FilePath, len(problems))
+	for _, problem := range problems {
+		fmt.Printf("  %v\n", problem)
+	}
+	os.Exit(1)
+}
+
+// clearDoneTasks removes the finished tasks from a task list
+func clearDoneTasks(cmd *cli.Command) {
+	if !storage.FileExists(cmd.FilePath) {
+		fmt.Fprintf(os.Stderr, "Task list file does not exist: %s\n", cmd.FilePath)
+		os.Exit(1)
+	}
+
+	tasks, err := storage.LoadTasks(cmd.FilePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading task list: %v\n", err)
+		os.Exit(1)
+	}
+
+	cleared, removed := storage.ClearDone(tasks)
+	if removed == 0 {
+		fmt.Println("No Done tasks to clear")
+		return
+	}
+	if err := storage.SaveTasks(cmd.FilePath, cleared); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving task list: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Removed %d Done task(s) from %s\n", removed, cmd.FilePath)
+}
+
+// exportTasks writes the task list as a Markdown checklist to stdout, or to the -o file
+func exportTasks(cmd *cli.Command) {
+	if !storage.FileExists(cmd.FilePath) {
+		fmt.Fprintf(os.Stderr, "Task list file does not exist: %s\n", cmd.FilePath)
+		os.Exit(1)
+	}
+
+	tasks, err := storage.LoadTasks(cmd.FilePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading task list: %v\n", err)
+		os.Exit(1)
+	}
+	markdown := storage.ExportMarkdown(tasks)
+
+	if cmd.Output == "" {
+		fmt.Print(markdown)
+		return
+	}
+	if storage.FileExists(cmd.Output) && !cmd.Force {
+		fmt.Fprintf(os.Stderr, "Output file already exists: %s (use --force to overwrite)\n", cmd.Output)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(cmd.Output, []byte(markdown), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", cmd.Output, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Exported %s to %s\n", cmd.FilePath, cmd.Output)
+}
+
+// importTasks creates a task list from a Markdown checklist read from stdin
+func importTasks(cmd *cli.Command) {
+	if storage.FileExists(cmd.FilePath) && !cmd.Force {
+		fmt.Fprintf(os.Stderr, "Task list already exists: %s (use --force to overwrite)\n", cmd.FilePath)
+		os.Exit(1)
+	}
+
+	tasks, err := storage.ImportMarkdown(os.Stdin)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading checklist: %v\n", err)
+		os.Exit(1)
+	}
+	if len(tasks) == 0 {
+		fmt.Fprintln(os.Stderr, "No checklist items found")
+		os.Exit(1)
+	}
+
+	if err := storage.SaveTasks(cmd.FilePath, tasks); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving task list: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Imported %d task(s) into %s\n", countTasks(tasks), cmd.FilePath)
+}
+
+// addTask appends a task to the end of the task list and prints its ID
+func addTask(cmd *cli.Command) {
+	title := strings.TrimSpace(cmd.Args[0])
+	if title == "" {
+		fmt.Fprintln(os.Stderr, "Task title cannot be empty")
+		os.Exit(1)
+	}
+
+	tasks, err := storage.LoadTasks(cmd.FilePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading task list: %v\n", err)
+		os.Exit(1)
+	}
+
+	tasks, task := storage.AppendTask(tasks, title)
+	if err := storage.SaveTasks(cmd.FilePath, tasks); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving task list: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(task.ID)
+}
+
+// markTaskDone marks the task with the given number or ID Done
+func markTaskDone(cmd *cli.Command) {
+	if !storage.FileExists(cmd.FilePath) {
+		fmt.Fprintf(os.Stderr, "Task list file does not exist: %s\n", cmd.FilePath)
+		os.Exit(1)
+	}
+
+	tasks, err := storage.LoadTasks(cmd.FilePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading task list: %v\n", err)
+		os.Exit(1)
+	}
+
+	task, err := storage.FindTask(tasks, cmd.Args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if task.Status == storage.StatusDone {
+		fmt.Printf("Already done: %s\n", task.Title)
+		return
+	}
+	task.Status = storage.StatusDone
+	task.UpdatedAt = time.Now()
+
+	if err := storage.SaveTasks(cmd.FilePath, tasks); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving task list: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Done: %s\n", task.Title)
+}
+
+// printTree prints the task list as a numbered plain-text tree
+func printTree(cmd *cli.Command) {
+	if !storage.FileExists(cmd.FilePath) {
+		fmt.Fprintf(os.Stderr, "Task list file does not exist: %s\n", cmd.FilePath)
+		os.Exit(1)
+	}
+
+	tasks, err := storage.LoadTasks(cmd.FilePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading task list: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(storage.FormatTree(tasks, cmd.Status))
+}
+
+// countTasks returns the number of tasks in a tree, subtasks included
+func countTasks(tasks []storage.TaskData) int {
+	count := len(tasks)
+	for _, task := range tasks {
+		count += countTasks(task.Subtasks)
+	}
+	return count
+}
+
+// printCompletion prints the completion script for the shell named in the arguments
+func printCompletion(cmd *cli.Command) {
+	script, err := cli.CompletionScript(cmd.Args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(script)
+}
+
+// completeNames prints matching task list names, one per line, for shell completion
+func completeNames(cmd *cli.Command) {
+	prefix := ""
+	if len(cmd.Args) > 0 {
+		prefix = cmd.Args[0]
+	}
+
+	names, err := cmd.CompleteNames(prefix)
+	if err != nil {
+		os.Exit(1) // Print nothing; the shell simply offers no completions
+	}
+	for _, name := range names {
+		fmt.Println(name)
+	}
+}
@@ -1,94 +1,274 @@
 package cli
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
-	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 
+	"dotdot/internal/config"
 	"dotdot/internal/storage"
 )
 
 // Command represents the parsed command and its arguments
 type Command struct {
-	Action   string // "open", "list", "delete"
-	Name     string // task list name for global lists
-	Local    bool   // --local flag
-	File     string // --file flag value
-	FilePath string // resolved file path to use
+	Action        string   // "open", "list", "delete", "rename", "estimate", "normalize", "migrate", "validate", "restore", "clear-done", "export", "import", "add", "done", "tree", "stats", "path", "version", "completion"
+	Name          string   // task list name for global lists
+	NewName       string   // target task list name for rename
+	Local         bool     // --local flag
+	File          string   // --file flag value
+	Force         bool     // --force flag
+	Inline        bool     // --inline flag
+	ReadOnly      bool     // --read-only flag
+	ASCII         bool     // --ascii flag
+	ConfirmDelete bool     // --confirm-delete flag, on unless turned off with --confirm-delete=false
+	JSON          bool     // --json flag
+	Output        string   // --output/-o flag value
+	Backup        string   // --backup flag value
+	History       *int     // --history flag value, nil when not given
+	Status        int      // --status flag value, storage.AllStatuses when not given
+	Theme         string   // --theme flag value
+	Args          []string // extra positional arguments (e.g. files to merge)
+	FilePath      string   // resolved file path to use
+	NewFilePath   string   // resolved target file path for rename
+}
+
+// actionSpec describes the positional arguments an action accepts after its name
+type actionSpec struct {
+	minArgs int
+	maxArgs int
+	usage   string
+}
+
+// actions lists the supported commands and their arguments
+var actions = map[string]actionSpec{
+	"open":       {0, 1, "open [name]"},
+	"list":       {0, 0, "list"},
+	"delete":     {1, 1, "delete <name>"},
+	"rename":     {2, 2, "rename <old> <new>"},
+	"estimate":   {0, 1, "estimate [name]"},
+	"merge":      {2, 2, "merge <left.dot> <right.dot> -o <out.dot>"},
+	"normalize":  {0, 1, "normalize [name]"},
+	"migrate":    {0, 1, "migrate [name]"},
+	"validate":   {0, 1, "validate [name]"},
+	"restore":    {0, 1, "restore [name] [--backup file]"},
+	"clear-done": {0, 1, "clear-done [name]"},
+	"export":     {0, 1, "export [name] [-o out.md]"},
+	"import":     {0, 1, "import [name] < checklist.md"},
+	"add":        {1, 2, "add <title> [name]"},
+	"done":       {1, 2, "done <number|id> [name]"},
+	"tree":       {0, 1, "tree [name] [--status todo|active|done]"},
+	"show":       {0, 1, "show [name] [--status todo|active|done]"},
+	"stats":      {0, 1, "stats [name] [--json]"},
+	"info":       {0, 1, "info [name] [--json]"},
+	"path":       {0, 1, "path [name]"},
+	"version":    {0, 0, "version [--json]"},
+	"completion": {1, 1, "completion bash|zsh|fish"},
+
+	// Hidden: prints list names matching a prefix for shell completion
+	CompleteAction: {0, 1, CompleteAction + " [prefix]"},
 }
 
 // ParseArgs parses command line arguments and returns a Command
-func ParseArgs() (*Command, error) {
+func ParseArgs(cfg config.Config) (*Command, error) {
+	return parseArgs(os.Args[1:], cfg)
+}
+
+// parseArgs parses the given arguments (excluding the program name)
+func parseArgs(argv []string, cfg config.Config) (*Command, error) {
+	fs := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+
 	// Define flags
 	var (
-		local = flag.Bool("local", false, "Use local task list in current directory")
-		file  = flag.String("file", "", "Use specific file path")
-		help  = flag.Bool("help", false, "Show help information")
+		local         = fs.Bool("local", false, "Use local task list in current directory")
+		file          = fs.String("file", "", "Use specific file path")
+		force         = fs.Bool("force", false, "Overwrite existing files without refusing, or open a list another dotdot has locked")
+		inline        = fs.Bool("inline", false, "Run the TUI inline instead of in the alternate screen")
+		readOnly      = fs.Bool("read-only", false, "Open the TUI for browsing without saving or changing anything")
+		confirmDelete = fs.Bool("confirm-delete", true, "Ask before deleting a task that has subtasks")
+		ascii         = fs.Bool("ascii", false, "Draw with plain ASCII symbols (default when the locale isn't UTF-8)")
+		theme         = fs.String("theme", "", "Color theme: "+strings.Join(config.ThemeNames(), ", ")+" (default: theme.json if present)")
+		help          = fs.Bool("help", false, "Show help information")
+		asJSON        = fs.Bool("json", false, "Print machine-readable output (stats, version)")
+		showVersion   = fs.Bool("version", false, "Print version information and exit")
 	)
+	var output string
+	fs.StringVar(&output, "output", "", "Output file path (merge, export)")
+	fs.StringVar(&output, "o", "", "Shorthand for --output")
+	backup := fs.String("backup", "", "Backup file to restore instead of choosing one (restore)")
+	status := storage.AllStatuses
+	fs.Func("status", "Only list tasks with this status: todo, active or done (tree)", func(value string) error {
+		var err error
+		status, err = storage.ParseStatus(value)
+		return err
+	})
+	var history *int
+	fs.Func("history", "Maximum number of undo steps to keep, 0 for unlimited (default 50)", func(value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("expected a whole number of undo steps")
+		}
+		history = &n
+		return nil
+	})
 
 	// Custom usage function
-	flag.Usage = func() {
+	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: %s [flags] [command] [name]\n\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "Commands:\n")
-		fmt.Fprintf(os.Stderr, "  open [name]    Open a task list (default command)\n")
-		fmt.Fprintf(os.Stderr, "  list           List available task lists\n")
-		fmt.Fprintf(os.Stderr, "  delete [name]  Delete a task list\n")
+		fmt.Fprintf(os.Stderr, "  open [name]        Open a task list (default command)\n")
+		fmt.Fprintf(os.Stderr, "  list               List available task lists\n")
+		fmt.Fprintf(os.Stderr, "  delete [name]      Delete a task list\n")
+		fmt.Fprintf(os.Stderr, "  rename [old] [new] Rename a task list\n")
+		fmt.Fprintf(os.Stderr, "  estimate [name]    Print the total time estimate of a task list\n")
+		fmt.Fprintf(os.Stderr, "  merge [a] [b]      Merge two copies of a task file into -o output\n")
+		fmt.Fprintf(os.Stderr, "  normalize [name]   Repair missing or duplicate IDs, invalid fields and over-deep nesting\n")
+		fmt.Fprintf(os.Stderr, "  migrate [name]     Upgrade a task file from an older format to the current one\n")
+		fmt.Fprintf(os.Stderr, "  validate [name]    Report problems in a task file, exiting non-zero if there are any\n")
+		fmt.Fprintf(os.Stderr, "  restore [name]     Replace a task list with one of its backups\n")
+		fmt.Fprintf(os.Stderr, "  clear-done [name]  Remove Done tasks, keeping Done parents of unfinished subtasks\n")
+		fmt.Fprintf(os.Stderr, "  export [name]      Print a task list as a Markdown checklist (or -o file)\n")
+		fmt.Fprintf(os.Stderr, "  import [name]      Create a task list from a Markdown checklist on stdin\n")
+		fmt.Fprintf(os.Stderr, "  add [title] [name] Add a task to the end of a task list (the default list without a name)\n")
+		fmt.Fprintf(os.Stderr, "  done [n] [name]    Mark a task Done by its number in the list, or by ID\n")
+		fmt.Fprintf(os.Stderr, "  tree [name]        Print a task list as a numbered plain-text tree (alias: show)\n")
+		fmt.Fprintf(os.Stderr, "  stats [name]       Print task counts by status and file details (alias: info)\n")
+		fmt.Fprintf(os.Stderr, "  path [name]        Print the file path a task list is stored at\n")
+		fmt.Fprintf(os.Stderr, "  version            Print version and build information\n")
+		fmt.Fprintf(os.Stderr, "  completion [shell] Print a completion script for bash, zsh or fish\n")
 		fmt.Fprintf(os.Stderr, "\nFlags:\n")
-		flag.PrintDefaults()
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nEnvironment:\n")
+		fmt.Fprintf(os.Stderr, "  %s  List opened with no arguments (a name, or a path ending in .dot)\n", DefaultListEnv)
 		fmt.Fprintf(os.Stderr, "\nExamples:\n")
-		fmt.Fprintf(os.Stderr, "  %s                        # Open default tasks.dot in current directory\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s                        # Open default tasks list (local tasks.dot unless configured)\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s open work              # Open global 'work' task list\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s open alice/work        # Open 'work' in the global 'alice' namespace\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s --local open mytasks   # Open mytasks.dot in current directory\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s --file ~/tasks.dot open # Open specific file\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s --inline               # Edit without clearing the terminal\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s --ascii                # Draw [x] bullets for terminals without Unicode\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s --confirm-delete=false # Delete parent tasks without asking\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s --history 0            # Keep the whole session's undo history\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s list                   # List global task lists\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s --local list           # List local .dot files\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s delete work            # Delete global 'work' task list\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s rename work job        # Rename global 'work' to 'job'\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s merge a.dot b.dot -o out.dot # Merge two conflicted copies\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s path work              # Print where the global 'work' list is stored\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s export work > work.md  # Share 'work' as a Markdown checklist\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s import plan < plan.md  # Create the global 'plan' list from a checklist\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s add \"Buy milk\"         # Add a task to the default list\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s clear-done work        # Remove the finished tasks from 'work'\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s done 3                 # Mark the third task in the default list Done\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s tree work --status todo # Print the unfinished tasks in 'work'\n", os.Args[0])
 	}
 
-	flag.Parse()
+	args, err := parseInterspersed(fs, argv)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0) // Usage has already been printed
+	}
+	if err != nil {
+		return nil, err
+	}
 
 	if *help {
-		flag.Usage()
+		fs.Usage()
 		os.Exit(0)
 	}
 
-	args := flag.Args()
-
 	cmd := &Command{
-		Local: *local,
-		File:  *file,
+		Local:         *local,
+		File:          *file,
+		Force:         *force,
+		Inline:        *inline,
+		ReadOnly:      *readOnly,
+		ASCII:         *ascii,
+		ConfirmDelete: *confirmDelete,
+		JSON:          *asJSON,
+		Output:        output,
+		Backup:        *backup,
+		History:       history,
+		Status:        status,
+		Theme:         *theme,
+	}
+	if _, ok := config.Themes[cmd.Theme]; cmd.Theme != "" && !ok {
+		return nil, fmt.Errorf("unknown theme %q (expected one of %s)", cmd.Theme, strings.Join(config.ThemeNames(), ", "))
+	}
+
+	// --version behaves like the version command
+	if *showVersion && len(args) == 0 {
+		args = []string{"version"}
 	}
 
 	// Parse command and name from remaining args
-	switch len(args) {
-	case 0:
-		// No arguments: default to opening local tasks.dot
+	if len(args) == 0 {
+		// No arguments: open the default tasks list, local unless configured otherwise
 		cmd.Action = "open"
-		cmd.Name = "tasks"
-		cmd.Local = true
-	case 1:
-		// One argument: could be a command or a name
-		if args[0] == "list" {
-			cmd.Action = "list"
-		} else if args[0] == "delete" {
-			return nil, fmt.Errorf("delete command requires a name")
-		} else {
-			// Assume it's a task list name
-			cmd.Action = "open"
-			cmd.Name = strings.TrimSuffix(args[0], ".dot")
+		if err := cmd.useDefaultList(cfg, *local); err != nil {
+			return nil, err
 		}
-	case 2:
-		// Two arguments: command and name
+	} else if spec, ok := actions[args[0]]; ok {
 		cmd.Action = args[0]
-		cmd.Name = strings.TrimSuffix(args[1], ".dot")
-
-		if cmd.Action != "open" && cmd.Action != "delete" {
-			return nil, fmt.Errorf("invalid command: %s", cmd.Action)
+		switch cmd.Action {
+		case "show":
+			cmd.Action = "tree"
+		case "info":
+			cmd.Action = "stats"
+		}
+		rest := args[1:]
+		if len(rest) < spec.minArgs || len(rest) > spec.maxArgs {
+			return nil, fmt.Errorf("usage: %s %s", os.Args[0], spec.usage)
+		}
+		if cmd.Action == "merge" {
+			// Merge operates on explicit file paths rather than list names
+			if cmd.Output == "" {
+				return nil, fmt.Errorf("merge command requires an output file (-o)")
+			}
+			cmd.Args = rest
+			return cmd, nil
+		}
+		if cmd.Action == "version" {
+			// Nothing to resolve
+			return cmd, nil
+		}
+		if cmd.Action == "completion" {
+			if !slices.Contains(CompletionShells, rest[0]) {
+				return nil, fmt.Errorf("unsupported shell %q (expected one of %s)", rest[0], strings.Join(CompletionShells, ", "))
+			}
+			cmd.Args = rest
+			return cmd, nil
+		}
+		if cmd.Action == CompleteAction {
+			// The argument is a partial name, not a list to resolve
+			cmd.Args = rest
+			return cmd, nil
 		}
-	default:
-		return nil, fmt.Errorf("too many arguments")
+		if cmd.Action == "add" || cmd.Action == "done" {
+			// The title or task comes first; without a list name the default list is used
+			cmd.Args = rest[:1]
+			if len(rest) == 1 {
+				if err := cmd.useDefaultList(cfg, *local); err != nil {
+					return nil, err
+				}
+			}
+			rest = rest[1:]
+		}
+		if len(rest) > 0 {
+			cmd.Name = strings.TrimSuffix(rest[0], ".dot")
+		}
+		if cmd.Action == "rename" {
+			cmd.NewName = strings.TrimSuffix(rest[1], ".dot")
+		}
+	} else if len(args) == 1 {
+		// A single unknown word is a task list name to open
+		cmd.Action = "open"
+		cmd.Name = strings.TrimSuffix(args[0], ".dot")
+	} else {
+		return nil, fmt.Errorf("invalid command: %s", args[0])
 	}
 
 	// Validate flag combinations
@@ -96,46 +276,111 @@ func ParseArgs() (*Command, error) {
 		return nil, fmt.Errorf("cannot use both --local and --file flags")
 	}
 
-	if cmd.Action == "list" && cmd.Name != "" {
-		return nil, fmt.Errorf("list command does not accept a name argument")
+	if cmd.Action == "rename" && cmd.File != "" {
+		return nil, fmt.Errorf("rename command does not support --file; use names with --local or global lists")
 	}
 
 	// Resolve file path
-	var err error
 	cmd.FilePath, err = cmd.resolveFilePath()
 	if err != nil {
 		return nil, err
 	}
 
+	if cmd.Action == "rename" {
+		cmd.NewFilePath, err = cmd.resolveNamePath(cmd.NewName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return cmd, nil
 }
 
+// useDefaultList selects the list used when none is named: "tasks", local unless
+// configured otherwise, or the one in DefaultListEnv unless a scope flag was given
+func (c *Command) useDefaultList(cfg config.Config, local bool) error {
+	c.Name = "tasks"
+	if cfg.DefaultNoArgScope != config.ScopeGlobal {
+		c.Local = true
+	}
+	if !local && c.File == "" {
+		return c.applyDefaultListEnv()
+	}
+	return nil
+}
+
+// DefaultListEnv names the environment variable that overrides the list opened without arguments
+const DefaultListEnv = "DOTDOT_DEFAULT_LIST"
+
+// applyDefaultListEnv replaces the default list with the one named in DefaultListEnv.
+// A value ending in .dot is a file path; anything else is a list name in the default scope.
+func (c *Command) applyDefaultListEnv() error {
+	value := strings.TrimSpace(os.Getenv(DefaultListEnv))
+	if value == "" {
+		return nil
+	}
+
+	if strings.HasSuffix(value, ".dot") {
+		if info, err := os.Stat(value); err == nil && info.IsDir() {
+			return fmt.Errorf("invalid %s %q: is a directory", DefaultListEnv, value)
+		}
+		c.File = value
+		c.Local = false
+		return nil
+	}
+
+	if strings.Contains(value, `\`) || strings.HasPrefix(value, "/") {
+		return fmt.Errorf("invalid %s %q: expected a list name or a path ending in .dot", DefaultListEnv, value)
+	}
+	if c.Local && strings.Contains(value, "/") {
+		return fmt.Errorf("invalid %s %q: local list names cannot contain '/'", DefaultListEnv, value)
+	}
+	if _, err := c.resolveNamePath(value); err != nil {
+		return fmt.Errorf("invalid %s %q: %w", DefaultListEnv, value, err)
+	}
+	c.Name = value
+	return nil
+}
+
+// parseInterspersed parses flags that may appear before, between, or after
+// positional arguments and returns the positional arguments in order
+func parseInterspersed(fs *flag.FlagSet, argv []string) ([]string, error) {
+	var args []string
+	for {
+		if err := fs.Parse(argv); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return args, nil
+		}
+		args = append(args, rest[0])
+		argv = rest[1:]
+	}
+}
+
 // resolveFilePath determines the actual file path to use based on the command flags
 func (c *Command) resolveFilePath() (string, error) {
-	switch {
-	case c.File != "":
+	if c.File != "" {
 		// Explicit file path
 		return c.File, nil
-	case c.Local:
-		// Local file in current directory
-		if c.Name == "" {
-			c.Name = "tasks"
-		}
-		return c.Name + ".dot", nil
-	default:
-		// Global task list
-		if c.Name == "" {
-			c.Name = "tasks"
-		}
+	}
 
-		configDir, err := storage.GetConfigDir()
-		if err != nil {
-			return "", fmt.Errorf("failed to get config directory: %w", err)
-		}
+	if c.Name == "" {
+		c.Name = "tasks"
+	}
+	return c.resolveNamePath(c.Name)
+}
 
-		tasksDir := filepath.Join(configDir, "dotdot", "tasks")
-		return filepath.Join(tasksDir, c.Name+".dot"), nil
+// resolveNamePath maps a task list name to a local or global file path
+func (c *Command) resolveNamePath(name string) (string, error) {
+	if c.Local {
+		// Local file in current directory
+		return storage.TaskFileName(name), nil
 	}
+
+	// Global task list, possibly namespaced into a subdirectory
+	return storage.GlobalTaskPath(name)
 }
 
 // IsGlobal returns true if this command operates on global task lists
@@ -0,0 +1,428 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"dotdot/internal/config"
+	"dotdot/internal/storage"
+)
+
+func TestParseArgsNoArgsDefaultScope(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configDir)
+	globalTasks := filepath.Join(configDir, "dotdot", "tasks", "tasks.dot")
+
+	tests := []struct {
+		name     string
+		argv     []string
+		scope    string
+		wantPath string
+	}{
+		{"LocalByDefault", nil, config.ScopeLocal, "tasks.dot"},
+		{"GlobalWhenConfigured", nil, config.ScopeGlobal, globalTasks},
+		{"LocalFlagOverridesGlobal", []string{"--local"}, config.ScopeGlobal, "tasks.dot"},
+		{"FileFlagOverridesGlobal", []string{"--file", "mine.dot"}, config.ScopeGlobal, "mine.dot"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Default()
+			cfg.DefaultNoArgScope = tt.scope
+
+			cmd, err := parseArgs(tt.argv, cfg)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if cmd.Action != "open" {
+				t.Errorf("Expected open action, got %s", cmd.Action)
+			}
+			if cmd.FilePath != tt.wantPath {
+				t.Errorf("Expected file path %s, got %s", tt.wantPath, cmd.FilePath)
+			}
+		})
+	}
+}
+
+func TestParseArgsRename(t *testing.T) {
+	cmd, err := parseArgs([]string{"--local", "rename", "old", "new.dot", "--force"}, config.Default())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cmd.Action != "rename" || cmd.FilePath != "old.dot" || cmd.NewFilePath != "new.dot" {
+		t.Errorf("Unexpected rename command: %+v", cmd)
+	}
+	if !cmd.Force {
+		t.Error("Expected --force after positional arguments to be parsed")
+	}
+
+	if _, err := parseArgs([]string{"rename", "old"}, config.Default()); err == nil {
+		t.Error("Expected error when rename is missing the new name")
+	}
+}
+
+func TestParseArgsMerge(t *testing.T) {
+	cmd, err := parseArgs([]string{"merge", "a.dot", "b (conflict).dot", "-o", "out.dot"}, config.Default())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cmd.Action != "merge" || cmd.Output != "out.dot" || len(cmd.Args) != 2 || cmd.Args[1] != "b (conflict).dot" {
+		t.Errorf("Unexpected merge command: %+v", cmd)
+	}
+
+	if _, err := parseArgs([]string{"merge", "a.dot", "b.dot"}, config.Default()); err == nil {
+		t.Error("Expected error when merge has no output file")
+	}
+}
+
+func TestParseArgsComplete(t *testing.T) {
+	cmd, err := parseArgs([]string{"--local", CompleteAction, "wo"}, config.Default())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cmd.Action != CompleteAction || !cmd.Local {
+		t.Errorf("Unexpected completion command: %+v", cmd)
+	}
+	if len(cmd.Args) != 1 || cmd.Args[0] != "wo" {
+		t.Errorf("Expected prefix argument to be kept as-is, got %v", cmd.Args)
+	}
+}
+
+func TestParseArgsCompletion(t *testing.T) {
+	cmd, err := parseArgs([]string{"completion", "zsh"}, config.Default())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cmd.Action != "completion" || cmd.Args[0] != "zsh" {
+		t.Errorf("Unexpected completion command: %+v", cmd)
+	}
+
+	if _, err := parseArgs([]string{"completion", "powershell"}, config.Default()); err == nil {
+		t.Error("Expected an unsupported shell to be rejected")
+	}
+}
+
+func TestCompletionScript(t *testing.T) {
+	for _, shell := range CompletionShells {
+		script, err := CompletionScript(shell)
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %v", shell, err)
+		}
+		for _, want := range []string{"rename", "stats", CompleteAction} {
+			if !strings.Contains(script, want) {
+				t.Errorf("Expected the %s script to mention %q", shell, want)
+			}
+		}
+	}
+
+	data := completionCommands()
+	if slices.Contains(data.Commands, CompleteAction) {
+		t.Error("Expected the hidden completion command not to be offered")
+	}
+	if !slices.Contains(data.NameCommands, "open") || slices.Contains(data.NameCommands, "add") {
+		t.Errorf("Expected list names after open but not add, got %v", data.NameCommands)
+	}
+}
+
+func TestCompleteNamesFiltersByPrefix(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"work.dot", "home.dot", "workshop.dot", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("[]"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(dir)
+
+	cmd := &Command{Local: true}
+	names, err := cmd.CompleteNames("wo")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(names) != 2 || names[0] != "work" || names[1] != "workshop" {
+		t.Errorf("Expected [work workshop], got %v", names)
+	}
+}
+
+func TestParseArgsNamespacedGlobalList(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configDir)
+
+	cmd, err := parseArgs([]string{"open", "alice/work"}, config.Default())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if want := filepath.Join(configDir, "dotdot", "tasks", "alice", "work.dot"); cmd.FilePath != want {
+		t.Errorf("Expected file path %s, got %s", want, cmd.FilePath)
+	}
+
+	if _, err := parseArgs([]string{"open", "../outside"}, config.Default()); err == nil {
+		t.Error("Expected a name escaping the tasks directory to be rejected")
+	}
+}
+
+func TestParseArgsDefaultListEnv(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configDir)
+
+	tests := []struct {
+		name     string
+		env      string
+		argv     []string
+		scope    string
+		wantPath string
+	}{
+		{"LocalName", "sprint", nil, config.ScopeLocal, "sprint.dot"},
+		{"GlobalName", "alice/work", nil, config.ScopeGlobal, filepath.Join(configDir, "dotdot", "tasks", "alice", "work.dot")},
+		{"FilePath", "projects/todo.dot", nil, config.ScopeLocal, "projects/todo.dot"},
+		{"ExplicitNameWins", "sprint", []string{"other"}, config.ScopeGlobal, filepath.Join(configDir, "dotdot", "tasks", "other.dot")},
+		{"LocalFlagWins", "sprint", []string{"--local"}, config.ScopeGlobal, "tasks.dot"},
+		{"FileFlagWins", "sprint", []string{"--file", "mine.dot"}, config.ScopeLocal, "mine.dot"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(DefaultListEnv, tt.env)
+			cfg := config.Default()
+			cfg.DefaultNoArgScope = tt.scope
+
+			cmd, err := parseArgs(tt.argv, cfg)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if cmd.FilePath != tt.wantPath {
+				t.Errorf("Expected file path %s, got %s", tt.wantPath, cmd.FilePath)
+			}
+		})
+	}
+}
+
+func TestParseArgsDefaultListEnvMalformed(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	for _, value := range []string{"/abs/name", "../outside", "a/b", `a\b`} {
+		t.Setenv(DefaultListEnv, value)
+		if _, err := parseArgs(nil, config.Default()); err == nil {
+			t.Errorf("Expected %s=%q to be rejected", DefaultListEnv, value)
+		}
+	}
+}
+
+func TestParseArgsInline(t *testing.T) {
+	cmd, err := parseArgs([]string{"--local", "open", "mine", "--inline"}, config.Default())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !cmd.Inline {
+		t.Error("Expected --inline to be set")
+	}
+	if cmd.FilePath != "mine.dot" {
+		t.Errorf("Expected file path mine.dot, got %s", cmd.FilePath)
+	}
+}
+
+func TestParseArgsASCII(t *testing.T) {
+	cmd, err := parseArgs([]string{"--local", "open", "mine"}, config.Default())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cmd.ASCII {
+		t.Error("Expected --ascii to be off by default")
+	}
+
+	cmd, err = parseArgs([]string{"--ascii", "--local", "open", "mine"}, config.Default())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !cmd.ASCII {
+		t.Error("Expected --ascii to be set")
+	}
+}
+
+func TestParseArgsConfirmDelete(t *testing.T) {
+	cmd, err := parseArgs([]string{"--local", "open", "mine"}, config.Default())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !cmd.ConfirmDelete {
+		t.Error("Expected --confirm-delete to be on by default")
+	}
+
+	cmd, err = parseArgs([]string{"--confirm-delete=false", "--local", "open", "mine"}, config.Default())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cmd.ConfirmDelete {
+		t.Error("Expected --confirm-delete=false to turn confirmation off")
+	}
+}
+
+func TestParseArgsHistory(t *testing.T) {
+	cmd, err := parseArgs([]string{"--local", "open", "mine"}, config.Default())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cmd.History != nil {
+		t.Errorf("Expected no history limit without --history, got %d", *cmd.History)
+	}
+
+	cmd, err = parseArgs([]string{"--local", "open", "mine", "--history", "0"}, config.Default())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cmd.History == nil || *cmd.History != 0 {
+		t.Error("Expected --history 0 to be kept")
+	}
+
+	_, err = parseArgs([]string{"--history", "lots"}, config.Default())
+	if err == nil || !strings.Contains(err.Error(), "history") {
+		t.Errorf("Expected a non-numeric --history to be rejected, got %v", err)
+	}
+}
+
+func TestParseArgsPath(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configDir)
+
+	cmd, err := parseArgs([]string{"path", "work"}, config.Default())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if want := filepath.Join(configDir, "dotdot", "tasks", "work.dot"); cmd.Action != "path" || cmd.FilePath != want {
+		t.Errorf("Expected path action for %s, got %s for %s", want, cmd.Action, cmd.FilePath)
+	}
+	if _, err := os.Stat(cmd.FilePath); !os.IsNotExist(err) {
+		t.Error("Expected resolving the path not to create the file")
+	}
+
+	cmd, err = parseArgs([]string{"--local", "path", "mine"}, config.Default())
+	if err != nil || cmd.FilePath != "mine.dot" {
+		t.Errorf("Expected local path mine.dot, got %v (err %v)", cmd, err)
+	}
+
+	if _, err := parseArgs([]string{"--local", "--file", "x.dot", "path"}, config.Default()); err == nil {
+		t.Error("Expected --local with --file to be rejected")
+	}
+}
+
+func TestParseArgsExport(t *testing.T) {
+	cmd, err := parseArgs([]string{"--local", "export", "work", "-o", "work.md"}, config.Default())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cmd.Action != "export" || cmd.FilePath != "work.dot" || cmd.Output != "work.md" {
+		t.Errorf("Expected export of work.dot to work.md, got %+v", cmd)
+	}
+
+	if _, err := parseArgs([]string{"export", "a", "b"}, config.Default()); err == nil {
+		t.Error("Expected export with two names to be rejected")
+	}
+}
+
+func TestParseArgsImport(t *testing.T) {
+	cmd, err := parseArgs([]string{"import", "--file", "plan.dot"}, config.Default())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cmd.Action != "import" || cmd.FilePath != "plan.dot" {
+		t.Errorf("Expected import into plan.dot, got %+v", cmd)
+	}
+}
+
+func TestParseArgsAdd(t *testing.T) {
+	t.Setenv(DefaultListEnv, "")
+	cmd, err := parseArgs([]string{"add", "Buy milk"}, config.Default())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cmd.Action != "add" || len(cmd.Args) != 1 || cmd.Args[0] != "Buy milk" || cmd.FilePath != "tasks.dot" {
+		t.Errorf("Expected the title added to the default local list, got %+v", cmd)
+	}
+
+	cmd, err = parseArgs([]string{"--local", "add", "Buy milk", "shopping"}, config.Default())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cmd.Args[0] != "Buy milk" || cmd.FilePath != "shopping.dot" {
+		t.Errorf("Expected the title added to shopping.dot, got %+v", cmd)
+	}
+
+	if _, err := parseArgs([]string{"add"}, config.Default()); err == nil {
+		t.Error("Expected add without a title to be rejected")
+	}
+}
+
+func TestParseArgsDone(t *testing.T) {
+	cmd, err := parseArgs([]string{"done", "3", "work", "--local"}, config.Default())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cmd.Action != "done" || cmd.Args[0] != "3" || cmd.FilePath != "work.dot" {
+		t.Errorf("Expected task 3 of work.dot, got %+v", cmd)
+	}
+}
+
+func TestParseArgsTree(t *testing.T) {
+	cmd, err := parseArgs([]string{"--local", "show", "work", "--status", "Done"}, config.Default())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cmd.Action != "tree" || cmd.FilePath != "work.dot" || cmd.Status != 2 {
+		t.Errorf("Expected the Done tasks of work.dot, got %+v", cmd)
+	}
+
+	cmd, err = parseArgs([]string{"--local", "tree"}, config.Default())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cmd.Status != storage.AllStatuses {
+		t.Errorf("Expected every status without --status, got %d", cmd.Status)
+	}
+
+	if _, err := parseArgs([]string{"tree", "--status", "finished"}, config.Default()); err == nil {
+		t.Error("Expected an unknown status to be rejected")
+	}
+}
+
+func TestParseArgsStats(t *testing.T) {
+	cmd, err := parseArgs([]string{"--local", "info", "work", "--json"}, config.Default())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cmd.Action != "stats" || cmd.FilePath != "work.dot" || !cmd.JSON {
+		t.Errorf("Expected JSON stats of work.dot, got %+v", cmd)
+	}
+}
+
+func TestParseArgsTheme(t *testing.T) {
+	cmd, err := parseArgs([]string{"--local", "--theme", "mono"}, config.Default())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cmd.Theme != "mono" {
+		t.Errorf("Expected the mono theme, got %q", cmd.Theme)
+	}
+
+	if _, err := parseArgs([]string{"--theme", "neon"}, config.Default()); err == nil {
+		t.Error("Expected an unknown theme to be rejected")
+	}
+}
+
+func TestParseArgsVersion(t *testing.T) {
+	for _, argv := range [][]string{{"version"}, {"--version"}, {"version", "--json"}} {
+		cmd, err := parseArgs(argv, config.Default())
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", argv, err)
+		}
+		if cmd.Action != "version" || cmd.FilePath != "" {
+			t.Errorf("%v: expected an unresolved version command, got %+v", argv, cmd)
+		}
+	}
+
+	cmd, _ := parseArgs([]string{"version", "--json"}, config.Default())
+	if !cmd.JSON {
+		t.Error("Expected --json to be set")
+	}
+}
@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"sort"
+	"strings"
+
+	"dotdot/internal/storage"
+)
+
+// CompleteAction is the hidden command shells call to complete task list names
+const CompleteAction = "__complete"
+
+// CompleteNames returns the task list names in the command's scope that start with prefix
+func (c *Command) CompleteNames(prefix string) ([]string, error) {
+	var names []string
+	var err error
+	if c.Local {
+		names, err = storage.ListLocalTasks()
+	} else {
+		names, err = storage.ListGlobalTasks()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return filterByPrefix(names, prefix), nil
+}
+
+// filterByPrefix returns the sorted names beginning with prefix
+func filterByPrefix(names []string, prefix string) []string {
+	var matches []string
+	for _, name := range names {
+		if strings.HasPrefix(name, prefix) {
+			matches = append(matches, name)
+		}
+	}
+	sort.Strings(matches)
+	return matches
+}
@@ -0,0 +1,180 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// CompletionShells lists the shells the completion command writes scripts for
+var CompletionShells = []string{"bash", "zsh", "fish"}
+
+// Flags that take a value, so the scripts can skip the value when looking for
+// the command. Keep these in sync with the flags defined in parseArgs.
+var (
+	fileFlags  = []string{"file", "output", "o", "backup"}
+	valueFlags = []string{"theme", "status", "history"}
+)
+
+// completionData is what the script templates fill in
+type completionData struct {
+	Commands     []string // Every command except the hidden ones
+	NameCommands []string // Commands whose first argument is an existing list name
+	FileFlags    []string // Flags taking a file path
+	ValueFlags   []string // Other flags taking a value
+}
+
+var completionFuncs = template.FuncMap{
+	"words": func(words []string) string { return strings.Join(words, " ") },
+	// flags turns flag names into a case pattern matching both - and -- forms
+	"flags": func(names []string) string {
+		var patterns []string
+		for _, name := range names {
+			patterns = append(patterns, "-"+name, "--"+name)
+		}
+		return strings.Join(patterns, "|")
+	},
+	"alts": func(words []string) string { return strings.Join(words, "|") },
+}
+
+var completionTemplates = map[string]*template.Template{
+	"bash": template.Must(template.New("bash").Funcs(completionFuncs).Parse(bashCompletion)),
+	"zsh":  template.Must(template.New("zsh").Funcs(completionFuncs).Parse(zshCompletion)),
+	"fish": template.Must(template.New("fish").Funcs(completionFuncs).Parse(fishCompletion)),
+}
+
+// CompletionScript returns a script completing the commands and task list
+// names for the given shell. List names come from the hidden __complete command,
+// so the script keeps up with lists created after it was installed.
+func CompletionScript(shell string) (string, error) {
+	tmpl, ok := completionTemplates[shell]
+	if !ok {
+		return "", fmt.Errorf("unsupported shell %q (expected one of %s)", shell, strings.Join(CompletionShells, ", "))
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, completionCommands()); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// completionCommands collects the commands to complete from the actions table
+func completionCommands() completionData {
+	data := completionData{FileFlags: fileFlags, ValueFlags: valueFlags}
+	for name, spec := range actions {
+		if strings.HasPrefix(name, "__") {
+			continue
+		}
+		data.Commands = append(data.Commands, name)
+		// The usage names the first argument, e.g. "open [name]" or "rename <old> <new>"
+		if fields := strings.Fields(spec.usage); len(fields) > 1 {
+			switch strings.Trim(fields[1], "[]<>") {
+			case "name", "old":
+				data.NameCommands = append(data.NameCommands, name)
+			}
+		}
+	}
+	slices.Sort(data.Commands)
+	slices.Sort(data.NameCommands)
+	return data
+}
+
+const bashCompletion = `# bash completion for dotdot
+# Load it with: source <(dotdot completion bash)
+
+_dotdot() {
+    local cur=${COMP_WORDS[COMP_CWORD]} prev=${COMP_WORDS[COMP_CWORD-1]}
+    local command="" scope="" args=0 word i
+
+    case $prev in
+        {{flags .FileFlags}}) COMPREPLY=($(compgen -f -- "$cur")); return ;;
+        {{flags .ValueFlags}}) return ;;
+    esac
+
+    for ((i = 1; i < COMP_CWORD; i++)); do
+        word=${COMP_WORDS[i]}
+        case $word in
+            -local|--local) scope=--local ;;
+            {{flags .FileFlags}}|{{flags .ValueFlags}}) ((i++)) ;;
+            -*) ;;
+            *) if [[ -z $command ]]; then command=$word; else ((args++)); fi ;;
+        esac
+    done
+
+    if [[ $cur == -* ]]; then
+        return
+    elif [[ -z $command ]]; then
+        COMPREPLY=($(compgen -W "{{words .Commands}}" -- "$cur"))
+    elif [[ $args -eq 0 ]]; then
+        case $command in
+            completion) COMPREPLY=($(compgen -W "bash zsh fish" -- "$cur")) ;;
+            {{alts .NameCommands}}) COMPREPLY=($(dotdot $scope __complete "$cur" 2>/dev/null)) ;;
+        esac
+    fi
+}
+
+complete -F _dotdot dotdot
+`
+
+const zshCompletion = `#compdef dotdot
+# zsh completion for dotdot
+# Install it with: dotdot completion zsh > ~/.zfunc/_dotdot
+# (with ~/.zfunc in your fpath before compinit runs)
+
+_dotdot() {
+    local command="" scope="" args=0 word i
+
+    case ${words[CURRENT-1]} in
+        {{flags .FileFlags}}) _files; return ;;
+        {{flags .ValueFlags}}) return ;;
+    esac
+
+    for ((i = 2; i < CURRENT; i++)); do
+        word=${words[i]}
+        case $word in
+            -local|--local) scope=--local ;;
+            {{flags .FileFlags}}|{{flags .ValueFlags}}) ((i++)) ;;
+            -*) ;;
+            *) if [[ -z $command ]]; then command=$word; else ((args++)); fi ;;
+        esac
+    done
+
+    if [[ ${words[CURRENT]} == -* ]]; then
+        return
+    elif [[ -z $command ]]; then
+        compadd -- {{words .Commands}}
+    elif (( args == 0 )); then
+        case $command in
+            completion) compadd -- bash zsh fish ;;
+            {{alts .NameCommands}}) compadd -- ${(f)"$(dotdot $scope __complete 2>/dev/null)"} ;;
+        esac
+    fi
+}
+
+_dotdot "$@"
+`
+
+const fishCompletion = `# fish completion for dotdot
+# Install it with: dotdot completion fish > ~/.config/fish/completions/dotdot.fish
+
+function __dotdot_names
+    set -l scope
+    if contains -- --local (commandline -opc); or contains -- -local (commandline -opc)
+        set scope --local
+    end
+    dotdot $scope __complete 2>/dev/null
+end
+
+complete -c dotdot -f
+{{- range .FileFlags}}
+complete -c dotdot {{if eq (len .) 1}}-s{{else}}-l{{end}} {{.}} -r -F
+{{- end}}
+{{- range .ValueFlags}}
+complete -c dotdot {{if eq (len .) 1}}-s{{else}}-l{{end}} {{.}} -x
+{{- end}}
+complete -c dotdot -n __fish_use_subcommand -a "{{words .Commands}}"
+complete -c dotdot -n "__fish_seen_subcommand_from completion" -a "bash zsh fish"
+complete -c dotdot -n "__fish_seen_subcommand_from {{words .NameCommands}}" -a "(__dotdot_names)"
+`
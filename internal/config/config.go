@@ -0,0 +1,202 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"dotdot/internal/storage"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Scopes for the default no-argument invocation
+const (
+	ScopeLocal  = "local"  // Open tasks.dot in the current directory
+	ScopeGlobal = "global" // Open the global tasks list
+)
+
+// Config holds user preferences loaded from the config file
+type Config struct {
+	// SmartNewTask creates new tasks below an Active parent with subtasks as
+	// its first subtask instead of as a sibling
+	SmartNewTask bool `json:"smart_new_task"`
+
+	// CompactJSON saves task files without indentation
+	CompactJSON bool `json:"compact_json"`
+
+	// SinkDoneTasks moves a task to the bottom of its sibling group when it is marked Done
+	SinkDoneTasks bool `json:"sink_done_tasks"`
+
+	// AutoAdvanceOnDone moves the cursor to the next incomplete task when a task is marked Done
+	AutoAdvanceOnDone bool `json:"auto_advance_on_done"`
+
+	// AutoCompleteParents marks a parent Done once all its subtasks are Done,
+	// and back to Active when one of them is reopened
+	AutoCompleteParents bool `json:"auto_complete_parents"`
+
+	// ConfirmRedoDiscard asks before a change that would discard undone changes from the redo history
+	ConfirmRedoDiscard bool `json:"confirm_redo_discard"`
+
+	// DefaultNoArgScope selects which tasks list `dotdot` opens without arguments
+	DefaultNoArgScope string `json:"default_no_arg_scope"`
+
+	// DeleteConfirmTimeout is how many seconds the CLI delete prompt waits for
+	// an answer before treating it as "no" (0 waits forever)
+	DeleteConfirmTimeout int `json:"delete_confirm_timeout"`
+
+	// BulletSymbols overrides the bullet shown for individual statuses,
+	// keyed by status name ("todo", "active" or "done")
+	BulletSymbols map[string]string `json:"bullet_symbols,omitempty"`
+}
+
+// StatusNames lists the status names accepted as bullet_symbols keys, in status order
+var StatusNames = []string{"todo", "active", "done"}
+
+// Default returns the built-in configuration used when no config file exists
+func Default() Config {
+	return Config{
+		SmartNewTask:         false,
+		CompactJSON:          false,
+		SinkDoneTasks:        false,
+		AutoAdvanceOnDone:    false,
+		AutoCompleteParents:  false,
+		ConfirmRedoDiscard:   false,
+		DefaultNoArgScope:    ScopeLocal,
+		DeleteConfirmTimeout: 0,
+	}
+}
+
+// Path returns the location of the config file
+func Path() (string, error) {
+	configDir, err := storage.GetConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config directory: %w", err)
+	}
+	return filepath.Join(configDir, "dotdot", "config.json"), nil
+}
+
+// KeysPath returns the location of the keybinding overrides file
+func KeysPath() (string, error) {
+	configDir, err := storage.GetConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config directory: %w", err)
+	}
+	return filepath.Join(configDir, "dotdot", "keys.json"), nil
+}
+
+// LoadKeys reads the keybinding overrides file: an object mapping binding names,
+// such as "delete_task", to the keys that trigger them. A missing file overrides nothing.
+func LoadKeys() (map[string][]string, error) {
+	path, err := KeysPath()
+	if err != nil {
+		return nil, err
+	}
+	return LoadKeysFile(path)
+}
+
+// LoadKeysFile reads keybinding overrides from a specific file path
+func LoadKeysFile(path string) (map[string][]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read keys file %s: %w", path, err)
+	}
+
+	var keys map[string][]string
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("failed to parse keys file %s: %w", path, err)
+	}
+	return keys, nil
+}
+
+// Load reads the config file, returning defaults for any missing keys
+func Load() (Config, error) {
+	path, err := Path()
+	if err != nil {
+		return Default(), err
+	}
+	return LoadFile(path)
+}
+
+// LoadFile reads configuration from a specific file path
+func LoadFile(path string) (Config, error) {
+	cfg := Default()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil // No config file, use defaults
+		}
+		return cfg, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return Default(), fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Default(), fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// BulletOverrides returns the usable bullet symbol overrides keyed by lowercase
+// status name. Entries with an unknown status or a glyph that isn't a single
+// display-width character are left out and reported as warnings, so the
+// default bullet is used for them instead.
+func (c Config) BulletOverrides() (map[string]string, []error) {
+	overrides := make(map[string]string)
+	var warnings []error
+
+	// Sort so warnings come out in a stable order
+	names := make([]string, 0, len(c.BulletSymbols))
+	for name := range c.BulletSymbols {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		glyph := c.BulletSymbols[name]
+		status := strings.ToLower(name)
+		switch {
+		case !isStatusName(status):
+			warnings = append(warnings, fmt.Errorf("bullet_symbols: unknown status %q (expected one of %s)", name, strings.Join(StatusNames, ", ")))
+		case utf8.RuneCountInString(glyph) != 1 || ansi.StringWidth(glyph) != 1:
+			warnings = append(warnings, fmt.Errorf("bullet_symbols: %q for %s must be a single-width character", glyph, name))
+		default:
+			overrides[status] = glyph
+		}
+	}
+	return overrides, warnings
+}
+
+// isStatusName reports whether name is one of StatusNames
+func isStatusName(name string) bool {
+	for _, status := range StatusNames {
+		if status == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks that all configured values are supported
+func (c Config) Validate() error {
+	switch c.DefaultNoArgScope {
+	case ScopeLocal, ScopeGlobal:
+	default:
+		return fmt.Errorf("default_no_arg_scope must be %q or %q, got %q", ScopeLocal, ScopeGlobal, c.DefaultNoArgScope)
+	}
+	if c.DeleteConfirmTimeout < 0 {
+		return fmt.Errorf("delete_confirm_timeout must not be negative, got %d", c.DeleteConfirmTimeout)
+	}
+	return nil
+}
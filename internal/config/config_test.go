@@ -0,0 +1,119 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadFileMissingUsesDefaults(t *testing.T) {
+	cfg, err := LoadFile(filepath.Join(t.TempDir(), "config.json"))
+	if err != nil {
+		t.Fatalf("Expected no error for missing config, got %v", err)
+	}
+	if !reflect.DeepEqual(cfg, Default()) {
+		t.Errorf("Expected default config, got %+v", cfg)
+	}
+}
+
+func TestLoadFileOverridesDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"smart_new_task": true}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !cfg.SmartNewTask {
+		t.Error("Expected smart_new_task to be enabled")
+	}
+}
+
+func TestLoadFileInvalidJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{not json`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadFile(path); err == nil {
+		t.Error("Expected an error for invalid JSON")
+	}
+}
+
+func TestLoadFileRejectsInvalidScope(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"default_no_arg_scope": "everywhere"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadFile(path)
+	if err == nil {
+		t.Error("Expected an error for an unknown scope")
+	}
+	if cfg.DefaultNoArgScope != ScopeLocal {
+		t.Errorf("Expected fallback to local scope, got %q", cfg.DefaultNoArgScope)
+	}
+}
+
+func TestLoadFileRejectsNegativeTimeout(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"delete_confirm_timeout": -5}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadFile(path)
+	if err == nil {
+		t.Error("Expected an error for a negative timeout")
+	}
+	if cfg.DeleteConfirmTimeout != 0 {
+		t.Errorf("Expected fallback to no timeout, got %d", cfg.DeleteConfirmTimeout)
+	}
+}
+
+func TestBulletOverrides(t *testing.T) {
+	cfg := Default()
+	cfg.BulletSymbols = map[string]string{
+		"Active":  "▶",
+		"done":    "✓",
+		"todo":    "[]",
+		"blocked": "x",
+		"Done":    "全",
+	}
+
+	overrides, warnings := cfg.BulletOverrides()
+	if len(overrides) != 2 || overrides["active"] != "▶" || overrides["done"] != "✓" {
+		t.Errorf("Expected active and done overrides, got %v", overrides)
+	}
+	if len(warnings) != 3 {
+		t.Errorf("Expected warnings for the multi-character, wide and unknown entries, got %v", warnings)
+	}
+}
+
+func TestLoadKeysFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	keys, err := LoadKeysFile(path)
+	if err != nil || keys != nil {
+		t.Errorf("Expected no overrides without a keys file, got %v (%v)", keys, err)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"delete_task": ["x"], "export_subtree": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	keys, err = LoadKeysFile(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !reflect.DeepEqual(keys, map[string][]string{"delete_task": {"x"}, "export_subtree": {}}) {
+		t.Errorf("Unexpected overrides: %v", keys)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"delete_task": "x"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadKeysFile(path); err == nil {
+		t.Error("Expected keys that aren't a list to be rejected")
+	}
+}
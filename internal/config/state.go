@@ -0,0 +1,93 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"dotdot/internal/storage"
+)
+
+// State is what dotdot remembers between sessions that isn't a preference:
+// the task the cursor was on when each list was last closed
+type State struct {
+	// Cursors maps the absolute path of a task list to the ID of the task the cursor was on
+	Cursors map[string]string `json:"cursors"`
+}
+
+// StatePath returns the location of the state file
+func StatePath() (string, error) {
+	configDir, err := storage.GetConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config directory: %w", err)
+	}
+	return filepath.Join(configDir, "dotdot", "state.json"), nil
+}
+
+// LoadStateFile reads the state from a specific file path. A missing file is an empty state.
+func LoadStateFile(path string) (State, error) {
+	state := State{Cursors: map[string]string{}}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return state, fmt.Errorf("failed to read state file %s: %w", path, err)
+	}
+
+	if err := json.Unmarshal(data, &state); err != nil {
+		return State{Cursors: map[string]string{}}, fmt.Errorf("failed to parse state file %s: %w", path, err)
+	}
+	if state.Cursors == nil {
+		state.Cursors = map[string]string{}
+	}
+	return state, nil
+}
+
+// SaveStateFile writes the state to a specific file path, leaving out lists
+// that no longer exist so the file doesn't grow forever
+func SaveStateFile(path string, state State) error {
+	for listPath := range state.Cursors {
+		if !storage.FileExists(listPath) {
+			delete(state.Cursors, listPath)
+		}
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write state file %s: %w", path, err)
+	}
+	return nil
+}
+
+// LastCursor returns the ID of the task the cursor was on when the list was
+// last closed, or "" if it isn't known
+func (s State) LastCursor(listPath string) string {
+	return s.Cursors[stateKey(listPath)]
+}
+
+// SetLastCursor remembers the task the cursor is on in a list
+func (s State) SetLastCursor(listPath, taskID string) {
+	if taskID == "" {
+		delete(s.Cursors, stateKey(listPath))
+		return
+	}
+	s.Cursors[stateKey(listPath)] = taskID
+}
+
+// stateKey returns the absolute form of a list path, so the same list opened
+// from different directories shares its state
+func stateKey(listPath string) string {
+	if abs, err := filepath.Abs(listPath); err == nil {
+		return abs
+	}
+	return listPath
+}
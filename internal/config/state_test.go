@@ -0,0 +1,57 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStateFile(t *testing.T) {
+	dir := t.TempDir()
+	statePath := filepath.Join(dir, "dotdot", "state.json")
+	listPath := filepath.Join(dir, "tasks.dot")
+	gonePath := filepath.Join(dir, "gone.dot")
+	if err := os.WriteFile(listPath, []byte(`{"tasks": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := LoadStateFile(statePath)
+	if err != nil || state.LastCursor(listPath) != "" {
+		t.Errorf("Expected an empty state without a state file, got %+v (%v)", state, err)
+	}
+
+	state.SetLastCursor(listPath, "task-1")
+	state.SetLastCursor(gonePath, "task-2")
+	if err := SaveStateFile(statePath, state); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err = LoadStateFile(statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := state.LastCursor(listPath); got != "task-1" {
+		t.Errorf("Expected the cursor to be remembered, got %q", got)
+	}
+	if got := state.LastCursor(gonePath); got != "" {
+		t.Errorf("Expected lists that no longer exist to be forgotten, got %q", got)
+	}
+
+	// Relative paths share the state of the absolute path
+	t.Chdir(dir)
+	if got := state.LastCursor("tasks.dot"); got != "task-1" {
+		t.Errorf("Expected a relative path to find the same list, got %q", got)
+	}
+
+	state.SetLastCursor(listPath, "")
+	if got := state.LastCursor(listPath); got != "" {
+		t.Errorf("Expected an empty ID to forget the cursor, got %q", got)
+	}
+
+	if err := os.WriteFile(statePath, []byte("not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if state, err = LoadStateFile(statePath); err == nil || state.Cursors == nil {
+		t.Errorf("Expected a parse error and a usable empty state, got %+v (%v)", state, err)
+	}
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"dotdot/internal/storage"
+)
+
+// Theme holds the colors the TUI is drawn with. Colors are ANSI color numbers
+// or "#rrggbb" values; an empty color uses the terminal's default.
+type Theme struct {
+	Cursor  string `json:"cursor"`           // Cursor and selection indicator
+	Active  string `json:"active"`           // Active tasks, prompts and help keys
+	Done    string `json:"done"`             // Done tasks
+	Dimmed  string `json:"dimmed"`           // Help text, metadata and other secondary text
+	Status  string `json:"status"`           // Status line in the footer
+	Error   string `json:"error"`            // Error text and overdue tasks
+	ErrorBg string `json:"error_background"` // Behind error messages
+	Warning string `json:"warning"`          // Tasks due today
+	Tag     string `json:"tag"`              // #tags within titles
+}
+
+// DefaultTheme returns the colors used unless another theme is chosen
+func DefaultTheme() Theme {
+	return Theme{
+		Cursor:  "1", // Red
+		Active:  "2", // Green
+		Done:    "8", // Gray
+		Dimmed:  "8", // Gray
+		Status:  "240",
+		Error:   "1", // Red
+		ErrorBg: "0", // Black
+		Warning: "3", // Yellow
+		Tag:     "6", // Cyan
+	}
+}
+
+// Themes are the built-in themes selectable with --theme
+var Themes = map[string]Theme{
+	"default": DefaultTheme(),
+	"mono":    {}, // No colors; statuses still differ by bullet and strikethrough
+}
+
+// ThemeNames returns the names of the built-in themes, sorted
+func ThemeNames() []string {
+	names := make([]string, 0, len(Themes))
+	for name := range Themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ThemePath returns the location of the custom theme file
+func ThemePath() (string, error) {
+	configDir, err := storage.GetConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config directory: %w", err)
+	}
+	return filepath.Join(configDir, "dotdot", "theme.json"), nil
+}
+
+// NoColor reports whether the NO_COLOR convention (https://no-color.org) asks for
+// output without colors: the variable is set to anything but an empty string
+func NoColor() bool {
+	return os.Getenv("NO_COLOR") != ""
+}
+
+// UTF8Locale reports whether the locale allows drawing Unicode symbols. The first
+// of LC_ALL, LC_CTYPE and LANG that is set decides; with none set UTF-8 is assumed.
+func UTF8Locale() bool {
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			value = strings.ToLower(value)
+			return strings.Contains(value, "utf-8") || strings.Contains(value, "utf8")
+		}
+	}
+	return true
+}
+
+// SelectTheme picks the theme to draw with: no colors when NO_COLOR is set, the
+// named built-in theme, or else theme.json from the config directory if there is one
+func SelectTheme(name string) (Theme, error) {
+	if NoColor() {
+		return Themes["mono"], nil
+	}
+	if name != "" {
+		theme, ok := Themes[name]
+		if !ok {
+			return DefaultTheme(), fmt.Errorf("unknown theme %q (expected one of %s)", name, strings.Join(ThemeNames(), ", "))
+		}
+		return theme, nil
+	}
+
+	path, err := ThemePath()
+	if err != nil {
+		return DefaultTheme(), err
+	}
+	return LoadThemeFile(path)
+}
+
+// LoadThemeFile reads a custom theme, keeping the default color for any missing keys
+func LoadThemeFile(path string) (Theme, error) {
+	theme := DefaultTheme()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return theme, nil
+		}
+		return theme, fmt.Errorf("failed to read theme file %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &theme); err != nil {
+		return DefaultTheme(), fmt.Errorf("failed to parse theme file %s: %w", path, err)
+	}
+	return theme, nil
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSelectTheme(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("NO_COLOR", "")
+
+	theme, err := SelectTheme("")
+	if err != nil || theme != DefaultTheme() {
+		t.Errorf("Expected the default theme without theme.json, got %+v (%v)", theme, err)
+	}
+
+	path, _ := ThemePath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(`{"cursor": "#ff8800"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	theme, err = SelectTheme("")
+	if err != nil || theme.Cursor != "#ff8800" || theme.Active != DefaultTheme().Active {
+		t.Errorf("Expected theme.json to override only the cursor color, got %+v (%v)", theme, err)
+	}
+
+	// A built-in theme chosen by name takes precedence over theme.json
+	if theme, _ = SelectTheme("default"); theme != DefaultTheme() {
+		t.Errorf("Expected the built-in default theme, got %+v", theme)
+	}
+	if _, err := SelectTheme("neon"); err == nil {
+		t.Error("Expected an unknown theme to be rejected")
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	if theme, _ = SelectTheme("default"); theme != Themes["mono"] {
+		t.Errorf("Expected NO_COLOR to select the colorless theme, got %+v", theme)
+	}
+}
+
+func TestUTF8Locale(t *testing.T) {
+	tests := []struct {
+		lcAll, lcCtype, lang string
+		want                 bool
+	}{
+		{"", "", "", true},
+		{"", "", "en_US.UTF-8", true},
+		{"", "", "en_US.utf8", true},
+		{"", "", "C", false},
+		{"", "en_GB.ISO-8859-1", "en_GB.UTF-8", false},
+		{"POSIX", "en_US.UTF-8", "en_US.UTF-8", false},
+		{"de_DE.UTF-8", "", "C", true},
+	}
+	for _, tt := range tests {
+		t.Setenv("LC_ALL", tt.lcAll)
+		t.Setenv("LC_CTYPE", tt.lcCtype)
+		t.Setenv("LANG", tt.lang)
+		if got := UTF8Locale(); got != tt.want {
+			t.Errorf("Expected UTF8Locale() = %v for LC_ALL=%q LC_CTYPE=%q LANG=%q, got %v", tt.want, tt.lcAll, tt.lcCtype, tt.lang, got)
+		}
+	}
+}
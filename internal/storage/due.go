@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DueDateLayout is the format due dates are shown in
+const DueDateLayout = "2006-01-02"
+
+// dueDateLayouts are the formats accepted for due dates, tried in order
+var dueDateLayouts = []string{
+	DueDateLayout,
+	"2006/01/02",
+	"Jan 2 2006",
+	"Jan 2, 2006",
+	"2 Jan 2006",
+	"January 2 2006",
+	"January 2, 2006",
+	"2 January 2006",
+}
+
+// dueDateLayoutsNoYear are formats without a year, which means this year
+var dueDateLayoutsNoYear = []string{"Jan 2", "2 Jan", "January 2", "2 January"}
+
+// ParseDueDate parses a due date such as "2024-03-01", "2024/03/01", "Mar 1 2024",
+// "1 Mar" (this year), "today" or "tomorrow", relative to now. The result is
+// midnight of that day in now's location. An empty string clears the due date
+// and returns nil.
+func ParseDueDate(s string, now time.Time) (*time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+
+	day := func(t time.Time) *time.Time {
+		d := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, now.Location())
+		return &d
+	}
+
+	switch strings.ToLower(s) {
+	case "today":
+		return day(now), nil
+	case "tomorrow":
+		return day(now.AddDate(0, 0, 1)), nil
+	}
+
+	for _, layout := range dueDateLayouts {
+		if t, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+			return day(t), nil
+		}
+	}
+	for _, layout := range dueDateLayoutsNoYear {
+		if t, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+			return day(t.AddDate(now.Year()-t.Year(), 0, 0)), nil
+		}
+	}
+
+	return nil, fmt.Errorf("invalid due date %q: use a date like 2024-03-01, Mar 1 or tomorrow", s)
+}
+
+// FormatDueDate formats a due date as YYYY-MM-DD, or "" when unset
+func FormatDueDate(due *time.Time) string {
+	if due == nil {
+		return ""
+	}
+	return due.Format(DueDateLayout)
+}
@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDueDate(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 15, 30, 0, 0, time.UTC)
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"2024-03-10", "2024-03-10", false},
+		{" 2024/12/31 ", "2024-12-31", false},
+		{"Apr 5 2025", "2025-04-05", false},
+		{"5 April 2025", "2025-04-05", false},
+		{"Jun 7", "2024-06-07", false},
+		{"Today", "2024-03-01", false},
+		{"tomorrow", "2024-03-02", false},
+		{"", "", false},
+		{"next week", "", true},
+		{"2024-13-01", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDueDate(tt.input, now)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseDueDate(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if s := FormatDueDate(got); s != tt.want {
+			t.Errorf("ParseDueDate(%q) = %q, want %q", tt.input, s, tt.want)
+		}
+		if got != nil && (got.Hour() != 0 || got.Minute() != 0) {
+			t.Errorf("ParseDueDate(%q) = %v, want midnight", tt.input, got)
+		}
+	}
+}
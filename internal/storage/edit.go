@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Changes to task lists other than the one open in the TUI, such as from the command line
+
+// Task statuses as stored in TaskData
+const (
+	StatusTodo   = 0
+	StatusActive = 1
+	StatusDone   = maxTaskStatus
+)
+
+// AppendTask adds a new Todo task with a fresh ID to the end of the top level
+// and returns the updated list along with the new task
+func AppendTask(tasks []TaskData, title string) ([]TaskData, TaskData) {
+	task := TaskData{
+		ID:        uuid.New().String(),
+		Title:     strings.TrimSpace(title),
+		CreatedAt: time.Now(),
+		Subtasks:  []TaskData{},
+	}
+	return append(tasks, task), task
+}
+
+// AppendSubtree adds a copy of a task and its subtasks, with fresh IDs, to the end
+// of the top level of the list at path, creating the list if it doesn't exist
+func AppendSubtree(path string, task TaskData, opts SaveOptions) error {
+	tasks, err := LoadTasks(path)
+	if err != nil {
+		return err
+	}
+	return SaveTasksWithOptions(path, append(tasks, withFreshIDs(task)), opts)
+}
+
+// ClearDone removes Done tasks whose subtasks are all Done too, along with those
+// subtasks, and returns the remaining tasks and the number of tasks removed. A Done
+// task with unfinished subtasks stays so they keep their place.
+func ClearDone(tasks []TaskData) ([]TaskData, int) {
+	kept := make([]TaskData, 0, len(tasks))
+	removed := 0
+	for _, task := range tasks {
+		subtasks, n := ClearDone(task.Subtasks)
+		removed += n
+		if task.Status == StatusDone && len(subtasks) == 0 {
+			removed++
+			continue
+		}
+		task.Subtasks = subtasks
+		kept = append(kept, task)
+	}
+	return kept, removed
+}
+
+// withFreshIDs returns a deep copy of a task and its subtasks with new IDs
+func withFreshIDs(task TaskData) TaskData {
+	task.ID = uuid.New().String()
+	subtasks := make([]TaskData, len(task.Subtasks))
+	for i, subtask := range task.Subtasks {
+		subtasks[i] = withFreshIDs(subtask)
+	}
+	task.Subtasks = subtasks
+	return task
+}
+
+// FlatTask is a task in the numbered, flattened order used by the command line
+type FlatTask struct {
+	Task  *TaskData
+	Depth int // 0 for top-level tasks
+}
+
+// FlattenTasks lists every task in the order the TUI shows them with nothing
+// folded away: each task followed by its subtasks. Task numbers on the command
+// line are positions in this list, starting at 1.
+func FlattenTasks(tasks []TaskData) []FlatTask {
+	var flat []FlatTask
+	var walk func(tasks []TaskData, depth int)
+	walk = func(tasks []TaskData, depth int) {
+		for i := range tasks {
+			flat = append(flat, FlatTask{Task: &tasks[i], Depth: depth})
+			walk(tasks[i].Subtasks, depth+1)
+		}
+	}
+	walk(tasks, 0)
+	return flat
+}
+
+// FindTask resolves a task number from FlattenTasks or a task ID to the task itself
+func FindTask(tasks []TaskData, ref string) (*TaskData, error) {
+	if n, err := strconv.Atoi(ref); err == nil {
+		flat := FlattenTasks(tasks)
+		if n < 1 || n > len(flat) {
+			return nil, fmt.Errorf("task number %d is out of range: the list has %d task(s)", n, len(flat))
+		}
+		return flat[n-1].Task, nil
+	}
+	if task := findTaskData(tasks, ref); task != nil {
+		return task, nil
+	}
+	return nil, fmt.Errorf("no task with ID %q", ref)
+}
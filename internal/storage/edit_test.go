@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAppendTask(t *testing.T) {
+	tasks := []TaskData{{ID: "a", Title: "First", Subtasks: []TaskData{}}}
+
+	tasks, added := AppendTask(tasks, "  Buy milk ")
+	tasks, second := AppendTask(tasks, "Buy eggs")
+
+	if len(tasks) != 3 || tasks[1].ID != added.ID || tasks[2].ID != second.ID {
+		t.Fatalf("Expected both tasks appended in order, got %+v", tasks)
+	}
+	if added.Title != "Buy milk" || added.Status != 0 || added.CreatedAt.IsZero() {
+		t.Errorf("Expected a trimmed Todo task with a creation time, got %+v", added)
+	}
+	if added.ID == "" || added.ID == second.ID {
+		t.Error("Expected each added task to get its own ID")
+	}
+}
+
+func TestFindTask(t *testing.T) {
+	tasks := []TaskData{
+		{ID: "a", Title: "A", Subtasks: []TaskData{
+			{ID: "a1", Title: "A1", Subtasks: []TaskData{{ID: "a1x", Title: "A1x"}}},
+		}},
+		{ID: "b", Title: "B"},
+	}
+
+	flat := FlattenTasks(tasks)
+	var titles []string
+	for _, entry := range flat {
+		titles = append(titles, strings.Repeat(">", entry.Depth)+entry.Task.Title)
+	}
+	if got := strings.Join(titles, " "); got != "A >A1 >>A1x B" {
+		t.Errorf("Expected parents before their subtasks, got %s", got)
+	}
+
+	for ref, want := range map[string]string{"1": "A", "3": "A1x", "4": "B", "a1": "A1"} {
+		task, err := FindTask(tasks, ref)
+		if err != nil || task.Title != want {
+			t.Errorf("%s: expected %s, got %v (%v)", ref, want, task, err)
+		}
+	}
+
+	// The returned task can be changed in place
+	task, _ := FindTask(tasks, "2")
+	task.Status = 2
+	if tasks[0].Subtasks[0].Status != 2 {
+		t.Error("Expected FindTask to return a pointer into the list")
+	}
+
+	for _, ref := range []string{"0", "5", "missing"} {
+		if _, err := FindTask(tasks, ref); err == nil {
+			t.Errorf("%s: expected an error", ref)
+		}
+	}
+}
+
+func TestAppendSubtree(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "projects.dot")
+	if err := SaveTasks(path, []TaskData{{ID: "p", Title: "Project", Subtasks: []TaskData{}}}); err != nil {
+		t.Fatalf("Failed to create list: %v", err)
+	}
+
+	task := TaskData{ID: "t", Title: "Triage", Status: 1, Subtasks: []TaskData{{ID: "s", Title: "Step", Subtasks: []TaskData{}}}}
+	if err := AppendSubtree(path, task, SaveOptions{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	loaded, err := LoadTasks(path)
+	if err != nil {
+		t.Fatalf("Failed to load list: %v", err)
+	}
+	if len(loaded) != 2 || loaded[1].Title != "Triage" || loaded[1].Status != 1 || len(loaded[1].Subtasks) != 1 {
+		t.Fatalf("Expected the subtree appended after the existing task, got %+v", loaded)
+	}
+	if loaded[1].ID == "t" || loaded[1].Subtasks[0].ID == "s" || loaded[1].Subtasks[0].ID == "" {
+		t.Error("Expected the appended subtree to get fresh IDs")
+	}
+}
+
+func TestClearDone(t *testing.T) {
+	tasks := []TaskData{
+		{ID: "done", Title: "Done leaf", Status: StatusDone},
+		{ID: "todo", Title: "Todo leaf"},
+		{ID: "all", Title: "Done with done subtasks", Status: StatusDone, Subtasks: []TaskData{
+			{ID: "all1", Title: "Done child", Status: StatusDone, Subtasks: []TaskData{
+				{ID: "all1a", Title: "Done grandchild", Status: StatusDone},
+			}},
+		}},
+		{ID: "mixed", Title: "Done with an open subtask", Status: StatusDone, Subtasks: []TaskData{
+			{ID: "mixed1", Title: "Done child", Status: StatusDone},
+			{ID: "mixed2", Title: "Open child", Status: 1, Subtasks: []TaskData{
+				{ID: "mixed2a", Title: "Done grandchild", Status: StatusDone},
+			}},
+		}},
+	}
+
+	cleared, removed := ClearDone(tasks)
+	if removed != 6 {
+		t.Errorf("Expected 6 tasks removed, got %d", removed)
+	}
+
+	var ids []string
+	for _, entry := range FlattenTasks(cleared) {
+		ids = append(ids, entry.Task.ID)
+	}
+	if got := strings.Join(ids, " "); got != "todo mixed mixed2" {
+		t.Errorf("Expected only the open tasks and the Done parent above them to stay, got %s", got)
+	}
+
+	// The input is left alone
+	if len(tasks) != 4 || len(tasks[3].Subtasks) != 2 {
+		t.Error("Expected ClearDone not to modify its input")
+	}
+}
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ParseEstimate parses a time estimate such as "2h", "30m" or "1h30m".
+// An empty string clears the estimate and returns zero.
+func ParseEstimate(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid estimate %q: use a duration like 2h, 30m or 1h30m", s)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid estimate %q: must be greater than zero", s)
+	}
+	if d%time.Minute != 0 {
+		return 0, fmt.Errorf("invalid estimate %q: must be whole minutes", s)
+	}
+
+	return d, nil
+}
+
+// FormatEstimate formats an estimate compactly, e.g. "2h", "45m" or "1h30m"
+func FormatEstimate(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+
+	hours := int(d / time.Hour)
+	minutes := int((d % time.Hour) / time.Minute)
+	switch {
+	case hours == 0:
+		return fmt.Sprintf("%dm", minutes)
+	case minutes == 0:
+		return fmt.Sprintf("%dh", hours)
+	default:
+		return fmt.Sprintf("%dh%dm", hours, minutes)
+	}
+}
+
+// TotalEstimate sums the estimates of all tasks in a tree, including nested subtasks.
+// Invalid estimate strings are ignored.
+func TotalEstimate(tasks []TaskData) time.Duration {
+	var total time.Duration
+	for _, task := range tasks {
+		if d, err := ParseEstimate(task.Estimate); err == nil {
+			total += d
+		}
+		total += TotalEstimate(task.Subtasks)
+	}
+	return total
+}
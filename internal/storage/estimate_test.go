@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseEstimate(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"2h", 2 * time.Hour, false},
+		{"30m", 30 * time.Minute, false},
+		{" 1h30m ", 90 * time.Minute, false},
+		{"", 0, false},
+		{"soon", 0, true},
+		{"-1h", 0, true},
+		{"90s", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseEstimate(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseEstimate(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseEstimate(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestFormatEstimateRoundTrip(t *testing.T) {
+	for _, s := range []string{"2h", "45m", "1h30m"} {
+		d, err := ParseEstimate(s)
+		if err != nil {
+			t.Fatalf("ParseEstimate(%q) failed: %v", s, err)
+		}
+		if got := FormatEstimate(d); got != s {
+			t.Errorf("FormatEstimate(%v) = %q, want %q", d, got, s)
+		}
+	}
+}
+
+func TestTotalEstimate(t *testing.T) {
+	tasks := []TaskData{
+		{Title: "A", Estimate: "1h", Subtasks: []TaskData{
+			{Title: "A1", Estimate: "30m"},
+			{Title: "A2", Estimate: "bogus"},
+		}},
+		{Title: "B", Estimate: "15m"},
+	}
+
+	if got := TotalEstimate(tasks); got != 105*time.Minute {
+		t.Errorf("Expected total of 1h45m, got %v", got)
+	}
+}
@@ -1,20 +1,34 @@
 package storage
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 )
 
 // TaskData represents the serializable task structure
 type TaskData struct {
-	ID       string     `json:"id"`
-	Title    string     `json:"title"`
-	Status   int        `json:"status"`
-	Subtasks []TaskData `json:"subtasks"`
+	ID        string     `json:"id"`
+	Title     string     `json:"title"`
+	Notes     string     `json:"notes,omitempty"`
+	Status    int        `json:"status"`
+	Estimate  string     `json:"estimate,omitempty"`
+	DueDate   *time.Time `json:"due_date,omitempty"`
+	Priority  int        `json:"priority,omitempty"`
+	Deferred  bool       `json:"deferred,omitempty"`
+	Pinned    bool       `json:"pinned,omitempty"`
+	Collapsed bool       `json:"collapsed,omitempty"`
+	CreatedAt time.Time  `json:"created_at,omitzero"`
+	UpdatedAt time.Time  `json:"updated_at,omitzero"`
+	Subtasks  []TaskData `json:"subtasks"`
 }
 
 // FileData represents the complete file structure with metadata
@@ -27,30 +41,59 @@ type FileData struct {
 
 const CurrentVersion = "1.0.0"
 
+// ErrTargetExists is returned when an operation would overwrite an existing file
+var ErrTargetExists = errors.New("target file already exists")
+
+// SaveOptions controls how task files are written
+type SaveOptions struct {
+	Compact bool // Write JSON without indentation to reduce file size
+
+	// CreatedAt is the creation time to record, as loaded with the list. When
+	// zero it is read from the existing file, which means reading the whole file.
+	CreatedAt time.Time
+}
+
 // SaveTasks saves task data to a JSON file
 func SaveTasks(filePath string, tasks []TaskData) error {
+	return SaveTasksWithOptions(filePath, tasks, SaveOptions{})
+}
+
+// SaveTasksWithOptions saves task data to a JSON file using the given options
+func SaveTasksWithOptions(filePath string, tasks []TaskData, opts SaveOptions) error {
 	// Ensure directory exists
 	dir := filepath.Dir(filePath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return fmt.Errorf("failed to create directory %s: %w", dir, err)
 	}
 
+	created := opts.CreatedAt
+	if created.IsZero() {
+		created = CreationTime(filePath)
+	}
+	return writeFileData(filePath, FileData{
+		Version:   CurrentVersion,
+		CreatedAt: created,
+		UpdatedAt: time.Now(),
+		Tasks:     tasks,
+	}, opts)
+}
+
+// writeFileData writes a complete task file, keeping a backup of the previous one
+func writeFileData(filePath string, fileData FileData, opts SaveOptions) error {
 	// Create backup of existing file
 	if err := createBackup(filePath); err != nil {
 		// Log error but don't fail the save operation
 		fmt.Fprintf(os.Stderr, "Warning: failed to create backup: %v\n", err)
 	}
 
-	// Prepare file data
-	fileData := FileData{
-		Version:   CurrentVersion,
-		CreatedAt: getCreationTime(filePath),
-		UpdatedAt: time.Now(),
-		Tasks:     tasks,
+	// Marshal to JSON, indented for readability unless compact output was requested
+	var data []byte
+	var err error
+	if opts.Compact {
+		data, err = json.Marshal(fileData)
+	} else {
+		data, err = json.MarshalIndent(fileData, "", "  ")
 	}
-
-	// Marshal to JSON with indentation for readability
-	data, err := json.MarshalIndent(fileData, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal tasks to JSON: %w", err)
 	}
@@ -70,37 +113,40 @@ func SaveTasks(filePath string, tasks []TaskData) error {
 	return nil
 }
 
-// LoadTasks loads task data from a JSON file
+// LoadTasks loads task data from a JSON file.
+// The file is decoded incrementally, one top-level task at a time, so a very
+// large list is never held in memory as raw bytes alongside the decoded tasks.
 func LoadTasks(filePath string) ([]TaskData, error) {
-	// Check if file exists
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		// Return empty task list for new files
-		return []TaskData{}, nil
-	}
+	fileData, err := LoadFile(filePath)
+	return fileData.Tasks, err
+}
 
-	// Read file
-	data, err := os.ReadFile(filePath)
+// LoadFile loads a task file like LoadTasks, along with its metadata. Files
+// that don't record a creation time get their best guess at one, so it can be
+// passed back in SaveOptions.
+func LoadFile(filePath string) (FileData, error) {
+	fileData, legacy, err := readFileData(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read file %s: %w", filePath, err)
+		return FileData{}, err
 	}
-
-	// Handle empty files
-	if len(data) == 0 {
-		return []TaskData{}, nil
+	if fileData.CreatedAt.IsZero() {
+		fileData.CreatedAt = guessCreationTime(filePath)
 	}
 
-	// Try to parse as new format with metadata
-	var fileData FileData
-	if err := json.Unmarshal(data, &fileData); err != nil {
-		// Fallback: try to parse as legacy format (just tasks array)
-		var tasks []TaskData
-		if legacyErr := json.Unmarshal(data, &tasks); legacyErr != nil {
-			return nil, fmt.Errorf("failed to parse JSON file %s: %w (legacy parse also failed: %v)", filePath, err, legacyErr)
-		}
+	// Very old or hand-written files may have tasks without IDs, which the
+	// TUI can't navigate to; the assigned IDs are kept on the next save
+	if assigned := assignMissingIDs(fileData.Tasks); assigned > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: assigned IDs to %d task(s) without one in %s\n", assigned, filePath)
+	}
+	// Shared IDs, e.g. from copy-pasting tasks by hand, would make edits land on
+	// the wrong task; the first task keeps the ID and the others get fresh ones
+	if replaced := replaceDuplicateIDs(fileData.Tasks); replaced > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: replaced %d duplicate task ID(s) in %s\n", replaced, filePath)
+	}
 
-		// Successfully parsed legacy format
+	if legacy {
 		fmt.Fprintf(os.Stderr, "Warning: loaded legacy format file %s, will be upgraded on next save\n", filePath)
-		return tasks, nil
+		return fileData, nil
 	}
 
 	// Validate version compatibility
@@ -109,18 +155,172 @@ func LoadTasks(filePath string) ([]TaskData, error) {
 			filePath, fileData.Version, CurrentVersion)
 	}
 
-	return fileData.Tasks, nil
+	return fileData, nil
+}
+
+// readFileData decodes a task file without checking or repairing its contents.
+// legacy is true for files holding just the tasks array, which have no metadata.
+// A missing or empty file reads as an empty list.
+func readFileData(filePath string) (fileData FileData, legacy bool, err error) {
+	file, err := os.Open(filePath)
+	if os.IsNotExist(err) {
+		// Return empty task list for new files
+		return FileData{Version: CurrentVersion, Tasks: []TaskData{}}, false, nil
+	}
+	if err != nil {
+		return FileData{}, false, fmt.Errorf("failed to read file %s: %w", filePath, err)
+	}
+	defer file.Close()
+
+	dec := json.NewDecoder(file)
+	tok, err := dec.Token()
+	if err == io.EOF {
+		// Handle empty files
+		return FileData{Version: CurrentVersion, Tasks: []TaskData{}}, false, nil
+	}
+	if err != nil {
+		return FileData{}, false, fmt.Errorf("failed to parse JSON file %s: %w", filePath, err)
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		// Current format with metadata
+		err = decodeFileData(dec, &fileData)
+	case json.Delim('['):
+		// Legacy format: just the tasks array
+		legacy = true
+		fileData.Tasks, err = decodeTaskElements(dec)
+	default:
+		err = fmt.Errorf("expected an object or array, got %v", tok)
+	}
+	if err == nil {
+		if _, trailing := dec.Token(); trailing != io.EOF {
+			err = errors.New("unexpected data after the task list")
+		}
+	}
+	if err != nil {
+		return FileData{}, false, fmt.Errorf("failed to parse JSON file %s: %w", filePath, err)
+	}
+	return fileData, legacy, nil
+}
+
+// decodeFileData decodes the fields of a file object whose opening brace has been read
+func decodeFileData(dec *json.Decoder, fileData *FileData) error {
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case "version":
+			err = dec.Decode(&fileData.Version)
+		case "created_at":
+			err = dec.Decode(&fileData.CreatedAt)
+		case "updated_at":
+			err = dec.Decode(&fileData.UpdatedAt)
+		case "tasks":
+			fileData.Tasks, err = decodeTaskArray(dec)
+		default:
+			var skipped json.RawMessage
+			err = dec.Decode(&skipped)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	_, err := dec.Token() // Closing brace
+	return err
+}
+
+// decodeTaskArray decodes a JSON array of tasks, or null, one element at a time
+func decodeTaskArray(dec *json.Decoder) ([]TaskData, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if tok == nil {
+		return nil, nil
+	}
+	if tok != json.Delim('[') {
+		return nil, fmt.Errorf("expected tasks array, got %v", tok)
+	}
+	return decodeTaskElements(dec)
+}
+
+// decodeTaskElements decodes the tasks of an array whose opening bracket has been read
+func decodeTaskElements(dec *json.Decoder) ([]TaskData, error) {
+	tasks := []TaskData{}
+	for dec.More() {
+		var task TaskData
+		if err := dec.Decode(&task); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	if _, err := dec.Token(); err != nil { // Closing bracket
+		return nil, err
+	}
+	return tasks, nil
 }
 
 // ListGlobalTasks returns a list of available global task list names
+// Lists in subdirectories are returned with a namespace, e.g. "alice/work"
 func ListGlobalTasks() ([]string, error) {
+	tasksDir, err := GlobalTasksDir()
+	if err != nil {
+		return nil, err
+	}
+
+	return listDotFiles(tasksDir, true)
+}
+
+// GlobalTasksDir returns the directory holding global task lists
+func GlobalTasksDir() (string, error) {
 	configDir, err := GetConfigDir()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get config directory: %w", err)
+		return "", fmt.Errorf("failed to get config directory: %w", err)
+	}
+	return filepath.Join(configDir, "dotdot", "tasks"), nil
+}
+
+// GlobalTaskPath maps a global task list name to its file path. Names may be
+// namespaced with "/" (e.g. "alice/work"), which maps to subdirectories of the
+// tasks directory; segments that would escape it are rejected.
+func GlobalTaskPath(name string) (string, error) {
+	for _, segment := range strings.Split(name, "/") {
+		if segment == "" || segment == "." || segment == ".." || strings.ContainsAny(segment, `\:`) {
+			return "", fmt.Errorf("invalid task list name %q", name)
+		}
+	}
+
+	tasksDir, err := GlobalTasksDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(tasksDir, filepath.FromSlash(TaskFileName(name))), nil
+}
+
+// TaskFileName returns the file name a task list name is stored under: the name
+// itself when it already ends in .json, otherwise the name with .dot appended
+func TaskFileName(name string) string {
+	if strings.HasSuffix(name, ".json") {
+		return name
 	}
+	return name + ".dot"
+}
 
-	tasksDir := filepath.Join(configDir, "dotdot", "tasks")
-	return listDotFiles(tasksDir)
+// GlobalTaskName returns the namespaced list name for a file inside the global
+// tasks directory, or false if the file is not a global task list
+func GlobalTaskName(filePath string) (string, bool) {
+	tasksDir, err := GlobalTasksDir()
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(tasksDir, filePath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return strings.TrimSuffix(filepath.ToSlash(rel), ".dot"), true
 }
 
 // ListLocalTasks returns a list of available local task files in the current directory
@@ -130,7 +330,7 @@ func ListLocalTasks() ([]string, error) {
 		return nil, fmt.Errorf("failed to get current directory: %w", err)
 	}
 
-	return listDotFiles(currentDir)
+	return listDotFiles(currentDir, false)
 }
 
 // DeleteTaskList deletes a task list file
@@ -153,9 +353,107 @@ func DeleteTaskList(filePath string) error {
 	return nil
 }
 
+// RenameTaskList moves a task list file to a new path.
+// An existing file at newPath is only replaced when overwrite is true.
+func RenameTaskList(oldPath, newPath string, overwrite bool) error {
+	if !FileExists(oldPath) {
+		return fmt.Errorf("task list file %s does not exist", oldPath)
+	}
+
+	if FileExists(newPath) {
+		if !overwrite {
+			return fmt.Errorf("%s: %w", newPath, ErrTargetExists)
+		}
+		// Keep a backup of the file being replaced
+		if err := createBackup(newPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to create backup before overwrite: %v\n", err)
+		}
+	}
+
+	// Ensure target directory exists
+	dir := filepath.Dir(newPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+
+	if err := os.Rename(oldPath, newPath); err != nil {
+		return fmt.Errorf("failed to rename %s to %s: %w", oldPath, newPath, err)
+	}
+
+	return nil
+}
+
+// ListBackups returns the backups of a task file, newest first: the .bak file
+// written before each save, and any copies of it kept under names starting
+// with the same path, such as "work.dot.bak.1"
+func ListBackups(filePath string) ([]string, error) {
+	dir, prefix := filepath.Dir(filePath), filepath.Base(filePath)+".bak"
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read directory %s: %w", dir, err)
+	}
+
+	var backups []string
+	modTimes := make(map[string]time.Time)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), prefix) {
+			continue
+		}
+		backup := filepath.Join(dir, entry.Name())
+		backups = append(backups, backup)
+		if info, err := entry.Info(); err == nil {
+			modTimes[backup] = info.ModTime()
+		}
+	}
+	slices.SortStableFunc(backups, func(a, b string) int {
+		return modTimes[b].Compare(modTimes[a])
+	})
+	return backups, nil
+}
+
+// RestoreBackup replaces a task file with one of its backups. The file being
+// replaced, if any, becomes the new .bak file, so a restore can itself be
+// undone by restoring again. The backup must hold a readable task list.
+func RestoreBackup(filePath, backupPath string) error {
+	if _, _, err := readFileData(backupPath); err != nil {
+		return fmt.Errorf("backup is not a valid task list: %w", err)
+	}
+	// Read it before createBackup can overwrite it
+	data, err := os.ReadFile(backupPath)
+	if err != nil {
+		return fmt.Errorf("failed to read backup %s: %w", backupPath, err)
+	}
+
+	dir := filepath.Dir(filePath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+	if err := createBackup(filePath); err != nil {
+		return fmt.Errorf("failed to back up %s before restoring: %w", filePath, err)
+	}
+
+	tempPath := filePath + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write temporary file %s: %w", tempPath, err)
+	}
+	if err := os.Rename(tempPath, filePath); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to rename temporary file to %s: %w", filePath, err)
+	}
+	return nil
+}
+
 // Helper functions
 
-func listDotFiles(dir string) ([]string, error) {
+// listDotFiles returns the names of the task list files in dir: .dot files
+// without the extension, and .json files holding a task list with it, so that
+// both map back to their file through TaskFileName. When recursive, files in
+// subdirectories are included as "sub/name".
+func listDotFiles(dir string, recursive bool) ([]string, error) {
+	if recursive {
+		return listDotFilesRecursive(dir)
+	}
+
 	entries, err := os.ReadDir(dir)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -166,9 +464,10 @@ func listDotFiles(dir string) ([]string, error) {
 
 	var dotFiles []string
 	for _, entry := range entries {
-		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".dot") {
-			// Remove .dot extension for display
-			name := strings.TrimSuffix(entry.Name(), ".dot")
+		if entry.IsDir() {
+			continue
+		}
+		if name, ok := taskListName(filepath.Join(dir, entry.Name())); ok {
 			dotFiles = append(dotFiles, name)
 		}
 	}
@@ -176,6 +475,72 @@ func listDotFiles(dir string) ([]string, error) {
 	return dotFiles, nil
 }
 
+func listDotFilesRecursive(dir string) ([]string, error) {
+	var dotFiles []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == dir {
+				return fs.SkipAll // Directory doesn't exist, return empty list
+			}
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		if _, ok := taskListName(path); !ok {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		dotFiles = append(dotFiles, strings.TrimSuffix(filepath.ToSlash(rel), ".dot"))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory %s: %w", dir, err)
+	}
+	if dotFiles == nil {
+		dotFiles = []string{}
+	}
+
+	return dotFiles, nil
+}
+
+// taskListName returns the list name of a task list file, or false if the file
+// isn't one. Other JSON files, such as a package.json next to a local list,
+// are told apart by their content.
+func taskListName(path string) (string, bool) {
+	name := filepath.Base(path)
+	switch {
+	case strings.HasSuffix(name, ".dot"):
+		return strings.TrimSuffix(name, ".dot"), true
+	case strings.HasSuffix(name, ".json") && isTaskListJSON(path):
+		return name, true
+	}
+	return "", false
+}
+
+// isTaskListJSON reports whether a JSON file holds a task list: an object with
+// a tasks array, or a legacy bare array
+func isTaskListJSON(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		return json.Valid(data)
+	}
+	var fileData struct {
+		Tasks json.RawMessage `json:"tasks"`
+	}
+	if err := json.Unmarshal(data, &fileData); err != nil {
+		return false
+	}
+	return bytes.HasPrefix(bytes.TrimSpace(fileData.Tasks), []byte("[")) || string(fileData.Tasks) == "null"
+}
+
 func createBackup(filePath string) error {
 	// Only create backup if the file exists
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
@@ -194,9 +559,30 @@ func createBackup(filePath string) error {
 	return os.WriteFile(backupPath, data, 0644)
 }
 
-func getCreationTime(filePath string) time.Time {
+// CreationTime returns the creation time recorded in an existing task file,
+// so that saving keeps it, or now for a brand-new file
+func CreationTime(filePath string) time.Time {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return time.Now()
+	}
+
+	// Only the timestamp is needed, so the tasks are skipped rather than decoded
+	var fileData struct {
+		CreatedAt time.Time `json:"created_at"`
+	}
+	if err := json.Unmarshal(data, &fileData); err == nil && !fileData.CreatedAt.IsZero() {
+		return fileData.CreatedAt
+	}
+	return guessCreationTime(filePath)
+}
+
+// guessCreationTime stands in for the creation time of a file that doesn't
+// record one: legacy files without metadata, or a file that doesn't exist yet
+func guessCreationTime(filePath string) time.Time {
+	// Use ModTime as approximation for creation time
 	if stat, err := os.Stat(filePath); err == nil {
-		return stat.ModTime() // Use ModTime as approximation for creation time
+		return stat.ModTime()
 	}
 	return time.Now()
 }
@@ -227,6 +613,7 @@ type FileInfo struct {
 	Modified  time.Time `json:"modified"`
 	Version   string    `json:"version,omitempty"`
 	Created   time.Time `json:"created,omitempty"`
+	Updated   time.Time `json:"updated,omitzero"`
 	TaskCount int       `json:"task_count,omitempty"`
 }
 
@@ -254,6 +641,7 @@ func GetFileInfo(filePath string) (*FileInfo, error) {
 		if err := json.Unmarshal(data, &fileData); err == nil {
 			info.Version = fileData.Version
 			info.Created = fileData.CreatedAt
+			info.Updated = fileData.UpdatedAt
 			info.TaskCount = len(fileData.Tasks)
 		}
 	}
@@ -0,0 +1,509 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenameTaskListRefusesOverwrite(t *testing.T) {
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "old.dot")
+	newPath := filepath.Join(dir, "new.dot")
+
+	if err := SaveTasks(oldPath, []TaskData{{ID: "a", Title: "Old task"}}); err != nil {
+		t.Fatalf("Failed to save old list: %v", err)
+	}
+	if err := SaveTasks(newPath, []TaskData{{ID: "b", Title: "Existing task"}}); err != nil {
+		t.Fatalf("Failed to save existing list: %v", err)
+	}
+
+	// Without overwrite the rename must fail and leave both files untouched
+	err := RenameTaskList(oldPath, newPath, false)
+	if !errors.Is(err, ErrTargetExists) {
+		t.Fatalf("Expected ErrTargetExists, got %v", err)
+	}
+
+	oldTasks, err := LoadTasks(oldPath)
+	if err != nil || len(oldTasks) != 1 || oldTasks[0].Title != "Old task" {
+		t.Errorf("Expected old list to be untouched, got %v (err %v)", oldTasks, err)
+	}
+	newTasks, err := LoadTasks(newPath)
+	if err != nil || len(newTasks) != 1 || newTasks[0].Title != "Existing task" {
+		t.Errorf("Expected existing list to be untouched, got %v (err %v)", newTasks, err)
+	}
+
+	// With overwrite the old list replaces the existing one
+	if err := RenameTaskList(oldPath, newPath, true); err != nil {
+		t.Fatalf("Expected forced rename to succeed, got %v", err)
+	}
+	if FileExists(oldPath) {
+		t.Error("Expected old path to be gone after rename")
+	}
+	newTasks, err = LoadTasks(newPath)
+	if err != nil || len(newTasks) != 1 || newTasks[0].Title != "Old task" {
+		t.Errorf("Expected renamed list at new path, got %v (err %v)", newTasks, err)
+	}
+	if _, err := os.Stat(newPath + ".bak"); err != nil {
+		t.Errorf("Expected backup of overwritten file, got %v", err)
+	}
+}
+
+func TestRenameTaskListMissingSource(t *testing.T) {
+	dir := t.TempDir()
+	err := RenameTaskList(filepath.Join(dir, "missing.dot"), filepath.Join(dir, "new.dot"), false)
+	if err == nil {
+		t.Error("Expected error when renaming a missing list")
+	}
+}
+
+func TestSaveTasksCompact(t *testing.T) {
+	dir := t.TempDir()
+	indentedPath := filepath.Join(dir, "indented.dot")
+	compactPath := filepath.Join(dir, "compact.dot")
+	tasks := largeTaskTree(3, 5)
+
+	if err := SaveTasks(indentedPath, tasks); err != nil {
+		t.Fatalf("Failed to save indented: %v", err)
+	}
+	if err := SaveTasksWithOptions(compactPath, tasks, SaveOptions{Compact: true}); err != nil {
+		t.Fatalf("Failed to save compact: %v", err)
+	}
+
+	indented, _ := os.ReadFile(indentedPath)
+	compact, _ := os.ReadFile(compactPath)
+	if len(compact) >= len(indented) {
+		t.Errorf("Expected compact file (%d bytes) to be smaller than indented (%d bytes)", len(compact), len(indented))
+	}
+
+	// Both formats load back to the same tree
+	loaded, err := LoadTasks(compactPath)
+	if err != nil {
+		t.Fatalf("Failed to load compact file: %v", err)
+	}
+	if countTasks(loaded) != countTasks(tasks) {
+		t.Errorf("Expected %d tasks after loading compact file, got %d", countTasks(tasks), countTasks(loaded))
+	}
+}
+
+func TestSaveTasksKeepsCreatedAt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.dot")
+	tasks := largeTaskTree(1, 2)
+
+	if err := SaveTasks(path, tasks); err != nil {
+		t.Fatalf("Failed to save: %v", err)
+	}
+	first, err := GetFileInfo(path)
+	if err != nil {
+		t.Fatalf("Failed to read file info: %v", err)
+	}
+	if first.Created.IsZero() {
+		t.Fatal("Expected a new file to record its creation time")
+	}
+
+	// Modify the file later on, as a save an hour after creation would
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatalf("Failed to change modification time: %v", err)
+	}
+	if err := SaveTasks(path, tasks); err != nil {
+		t.Fatalf("Failed to save again: %v", err)
+	}
+	second, err := GetFileInfo(path)
+	if err != nil {
+		t.Fatalf("Failed to read file info: %v", err)
+	}
+	if !second.Created.Equal(first.Created) {
+		t.Errorf("Expected created_at to stay %v across saves, got %v", first.Created, second.Created)
+	}
+
+	// A creation time loaded with the list is written as given
+	loaded, err := LoadFile(path)
+	if err != nil || !loaded.CreatedAt.Equal(first.Created) {
+		t.Errorf("Expected LoadFile to return created_at %v, got %v (%v)", first.Created, loaded.CreatedAt, err)
+	}
+	given := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := SaveTasksWithOptions(path, tasks, SaveOptions{CreatedAt: given}); err != nil {
+		t.Fatalf("Failed to save with a creation time: %v", err)
+	}
+	if third, _ := GetFileInfo(path); !third.Created.Equal(given) {
+		t.Errorf("Expected created_at %v from the options, got %v", given, third.Created)
+	}
+}
+
+func TestGlobalTaskPathNamespaces(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configDir)
+	tasksDir := filepath.Join(configDir, "dotdot", "tasks")
+
+	path, err := GlobalTaskPath("alice/work")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if want := filepath.Join(tasksDir, "alice", "work.dot"); path != want {
+		t.Errorf("Expected %s, got %s", want, path)
+	}
+
+	if name, ok := GlobalTaskName(path); !ok || name != "alice/work" {
+		t.Errorf("Expected namespaced name alice/work, got %q (ok=%v)", name, ok)
+	}
+	if _, ok := GlobalTaskName(filepath.Join(configDir, "elsewhere.dot")); ok {
+		t.Error("Expected a file outside the tasks directory not to be a global list")
+	}
+
+	for _, name := range []string{"../escape", "alice/../../escape", "/abs", "alice//work", "alice/", `a\b`} {
+		if _, err := GlobalTaskPath(name); err == nil {
+			t.Errorf("Expected %q to be rejected", name)
+		}
+	}
+}
+
+func TestListGlobalTasksIncludesNamespaces(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configDir)
+
+	for _, name := range []string{"work", "alice/work", "alice/team/plans"} {
+		path, err := GlobalTaskPath(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := SaveTasks(path, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	names, err := ListGlobalTasks()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	got := strings.Join(names, ",")
+	if got != "alice/team/plans,alice/work,work" {
+		t.Errorf("Unexpected list names: %s", got)
+	}
+}
+
+func TestListLocalTasksIncludesJSON(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	files := map[string]string{
+		"work.dot":      `{"version": "1.0.0", "tasks": []}`,
+		"plans.json":    `{"version": "1.0.0", "tasks": [{"id": "a", "title": "Plan"}]}`,
+		"legacy.json":   `[]`,
+		"package.json":  `{"name": "app", "version": "1.0.0"}`,
+		"settings.json": `{"tasks": "not a list"}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	names, err := ListLocalTasks()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	slices.Sort(names)
+	if got := strings.Join(names, ","); got != "legacy.json,plans.json,work" {
+		t.Errorf("Unexpected list names: %s", got)
+	}
+	for _, name := range names {
+		if !FileExists(TaskFileName(name)) {
+			t.Errorf("Expected list %q to map back to its file, got %s", name, TaskFileName(name))
+		}
+	}
+}
+
+func TestRestoreBackup(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "work.dot")
+	if err := SaveTasks(path, []TaskData{{ID: "a", Title: "Original"}}); err != nil {
+		t.Fatal(err)
+	}
+	// The second save leaves the first version in work.dot.bak
+	if err := SaveTasks(path, []TaskData{{ID: "b", Title: "Mistake"}}); err != nil {
+		t.Fatal(err)
+	}
+	older := path + ".bak.1"
+	if err := os.WriteFile(older, []byte("[]"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(older, past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	backups, err := ListBackups(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(backups) != 2 || backups[0] != path+".bak" || backups[1] != older {
+		t.Fatalf("Expected the backups newest first, got %v", backups)
+	}
+
+	if err := RestoreBackup(path, backups[0]); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	tasks, err := LoadTasks(path)
+	if err != nil || len(tasks) != 1 || tasks[0].Title != "Original" {
+		t.Fatalf("Expected the original task back, got %v (err %v)", tasks, err)
+	}
+
+	// The replaced version becomes the backup, so restoring again undoes it
+	if err := RestoreBackup(path, path+".bak"); err != nil {
+		t.Fatal(err)
+	}
+	if tasks, _ := LoadTasks(path); tasks[0].Title != "Mistake" {
+		t.Errorf("Expected restoring the backup again to swap back, got %v", tasks)
+	}
+
+	broken := filepath.Join(dir, "broken.bak")
+	if err := os.WriteFile(broken, []byte("not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := RestoreBackup(path, broken); err == nil {
+		t.Error("Expected an unreadable backup to be refused")
+	}
+}
+
+func TestListGlobalTasksMissingDir(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	names, err := ListGlobalTasks()
+	if err != nil {
+		t.Fatalf("Expected no error for a missing tasks directory, got %v", err)
+	}
+	if len(names) != 0 {
+		t.Errorf("Expected no lists, got %v", names)
+	}
+}
+
+func TestLoadTasksFormats(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name      string
+		content   string
+		wantTasks int
+		wantErr   bool
+	}{
+		{"Empty", "", 0, false},
+		{"Current", `{"version":"1.0.0","extra":{"x":[1]},"tasks":[{"id":"a","title":"A","subtasks":[{"id":"b","title":"B"}]}]}`, 1, false},
+		{"Legacy", `[{"id":"a","title":"A"},{"id":"b","title":"B"}]`, 2, false},
+		{"NullTasks", `{"version":"1.0.0","tasks":null}`, 0, false},
+		{"Truncated", `{"version":"1.0.0","tasks":[{"id":"a"`, 0, true},
+		{"TrailingData", `[{"id":"a"}] [`, 0, true},
+		{"NotAList", `"tasks"`, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name+".dot")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			tasks, err := LoadTasks(path)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected an error, got %v", tasks)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(tasks) != tt.wantTasks {
+				t.Errorf("Expected %d tasks, got %d", tt.wantTasks, len(tasks))
+			}
+		})
+	}
+}
+
+func TestLoadTasksAssignsMissingIDs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "handwritten.dot")
+	content := `{"version":"1.0.0","tasks":[
+		{"title":"A","subtasks":[{"id":"b","title":"B","subtasks":[{"id":"","title":"C"}]}]},
+		{"id":"d","title":"D","subtasks":[{"title":"E"}]}
+	]}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tasks, err := LoadTasks(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Every task has a distinct ID, and existing IDs are kept
+	seen := make(map[string]bool)
+	var walk func(tasks []TaskData)
+	walk = func(tasks []TaskData) {
+		for _, task := range tasks {
+			if task.ID == "" || seen[task.ID] {
+				t.Errorf("Expected a unique ID for %q, got %q", task.Title, task.ID)
+			}
+			seen[task.ID] = true
+			walk(task.Subtasks)
+		}
+	}
+	walk(tasks)
+	if tasks[0].Subtasks[0].ID != "b" || tasks[1].ID != "d" {
+		t.Error("Expected existing IDs to be kept")
+	}
+
+	// The assigned IDs are stable once saved
+	if err := SaveTasks(path, tasks); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := LoadTasks(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reloaded[0].ID != tasks[0].ID || reloaded[0].Subtasks[0].Subtasks[0].ID != tasks[0].Subtasks[0].Subtasks[0].ID {
+		t.Error("Expected assigned IDs to persist after saving")
+	}
+}
+
+func TestLoadTasksReplacesDuplicateIDs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "merged.dot")
+	content := `{"version":"1.0.0","tasks":[
+		{"id":"a","title":"A","subtasks":[{"id":"a","title":"A again"},{"id":"b","title":"B"}]},
+		{"id":"b","title":"B again","subtasks":[{"id":"a","title":"A a third time"}]}
+	]}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tasks, err := LoadTasks(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, entry := range FlattenTasks(tasks) {
+		if seen[entry.Task.ID] {
+			t.Errorf("Expected a unique ID for %q, got %q", entry.Task.Title, entry.Task.ID)
+		}
+		seen[entry.Task.ID] = true
+	}
+	if len(seen) != 5 {
+		t.Errorf("Expected 5 distinct IDs, got %d", len(seen))
+	}
+	// The first task with an ID keeps it
+	if tasks[0].ID != "a" || tasks[0].Subtasks[1].ID != "b" {
+		t.Error("Expected the first use of each ID to be kept")
+	}
+}
+
+func TestLoadTasksLargeFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "large.dot")
+	tasks := largeTaskTree(4, 10)
+	if err := SaveTasks(path, tasks); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadTasks(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want, got := FlattenTasks(tasks), FlattenTasks(loaded)
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d tasks, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if got[i].Task.ID != want[i].Task.ID || got[i].Task.Title != want[i].Task.Title || got[i].Depth != want[i].Depth {
+			t.Fatalf("Expected task %d to be %s %q at depth %d, got %s %q at depth %d", i+1,
+				want[i].Task.ID, want[i].Task.Title, want[i].Depth, got[i].Task.ID, got[i].Task.Title, got[i].Depth)
+		}
+	}
+}
+
+func BenchmarkLoadTasksStreaming(b *testing.B) {
+	benchmarkLoadTasks(b, LoadTasks)
+}
+
+// BenchmarkLoadTasksBuffered measures the previous read-everything-then-unmarshal approach
+func BenchmarkLoadTasksBuffered(b *testing.B) {
+	benchmarkLoadTasks(b, func(path string) ([]TaskData, error) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var fileData FileData
+		err = json.Unmarshal(data, &fileData)
+		return fileData.Tasks, err
+	})
+}
+
+func benchmarkLoadTasks(b *testing.B, load func(string) ([]TaskData, error)) {
+	path := filepath.Join(b.TempDir(), "bench.dot")
+	if err := SaveTasks(path, largeTaskTree(4, 10)); err != nil { // ~2.4MB indented
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := load(path); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSaveTasksIndented(b *testing.B) {
+	benchmarkSaveTasks(b, SaveOptions{})
+}
+
+func BenchmarkSaveTasksCompact(b *testing.B) {
+	benchmarkSaveTasks(b, SaveOptions{Compact: true})
+}
+
+func benchmarkSaveTasks(b *testing.B, opts SaveOptions) {
+	path := filepath.Join(b.TempDir(), "bench.dot")
+	tasks := largeTaskTree(4, 10) // 11110 tasks
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := SaveTasksWithOptions(path, tasks, opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// largeTaskTree builds a tree with the given depth and fan-out for size-sensitive tests
+func largeTaskTree(depth, width int) []TaskData {
+	return largeTaskSubtree("task", depth, width)
+}
+
+// largeTaskSubtree builds one level of largeTaskTree. IDs spell out the path
+// from the root, so they are unique and loading doesn't have to repair them.
+func largeTaskSubtree(prefix string, depth, width int) []TaskData {
+	if depth == 0 {
+		return nil
+	}
+	tasks := make([]TaskData, width)
+	for i := range tasks {
+		id := fmt.Sprintf("%s-%d", prefix, i)
+		tasks[i] = TaskData{
+			ID:       id,
+			Title:    fmt.Sprintf("Task %d at depth %d with a reasonably long title", i, depth),
+			Status:   i % 3,
+			Subtasks: largeTaskSubtree(id, depth-1, width),
+		}
+	}
+	return tasks
+}
+
+// countTasks counts all tasks in a tree including nested subtasks
+func countTasks(tasks []TaskData) int {
+	count := len(tasks)
+	for _, task := range tasks {
+		count += countTasks(task.Subtasks)
+	}
+	return count
+}
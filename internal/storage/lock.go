@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ErrLocked is returned when another dotdot instance has a task file open
+var ErrLocked = errors.New("task list is open in another dotdot")
+
+// FileLock is an advisory lock on a task file, held while the TUI has it open.
+// It is a .lock file next to the task file recording the holder's process ID;
+// nothing stops other programs from writing the task file regardless.
+type FileLock struct {
+	path string
+	pid  int
+}
+
+// LockFile acquires the lock for a task file. If another process holds it,
+// the error wraps ErrLocked and names that process. With force the lock is
+// taken over anyway, for locks left behind by a dotdot that crashed.
+func LockFile(filePath string, force bool) (*FileLock, error) {
+	lock := &FileLock{path: filePath + ".lock", pid: os.Getpid()}
+	if err := os.MkdirAll(filepath.Dir(lock.path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory for %s: %w", lock.path, err)
+	}
+
+	file, err := os.OpenFile(lock.path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if errors.Is(err, os.ErrExist) {
+		if !force {
+			return nil, fmt.Errorf("%w (process %s, per %s)", ErrLocked, lockHolder(lock.path), lock.path)
+		}
+		if err := os.Remove(lock.path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale lock %s: %w", lock.path, err)
+		}
+		file, err = os.OpenFile(lock.path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create lock %s: %w", lock.path, err)
+	}
+	defer file.Close()
+
+	if _, err := fmt.Fprintf(file, "%d\n", lock.pid); err != nil {
+		os.Remove(lock.path)
+		return nil, fmt.Errorf("failed to write lock %s: %w", lock.path, err)
+	}
+	return lock, nil
+}
+
+// Release removes the lock, unless another instance has since taken it over
+// with force. Releasing a nil lock does nothing.
+func (l *FileLock) Release() error {
+	if l == nil {
+		return nil
+	}
+	if lockHolder(l.path) != strconv.Itoa(l.pid) {
+		return nil
+	}
+	if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove lock %s: %w", l.path, err)
+	}
+	return nil
+}
+
+// lockHolder returns the process ID recorded in a lock file, or "unknown"
+func lockHolder(lockPath string) string {
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return "unknown"
+	}
+	if pid := strings.TrimSpace(string(data)); pid != "" {
+		return pid
+	}
+	return "unknown"
+}
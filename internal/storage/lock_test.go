@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLockFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "work.dot")
+
+	lock, err := LockFile(path, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := LockFile(path, false); !errors.Is(err, ErrLocked) {
+		t.Errorf("Expected a second lock to fail with ErrLocked, got %v", err)
+	}
+
+	// Another process takes the lock over; releasing ours must leave theirs
+	if err := os.WriteFile(path+".lock", []byte("999999\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := lock.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if !FileExists(path + ".lock") {
+		t.Error("Expected releasing a taken-over lock to leave the new holder's lock")
+	}
+
+	// A lock left behind can be taken with force
+	forced, err := LockFile(path, true)
+	if err != nil {
+		t.Fatalf("Expected force to take the lock, got %v", err)
+	}
+	if err := forced.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if FileExists(path + ".lock") {
+		t.Error("Expected the lock file to be removed on release")
+	}
+
+	var none *FileLock
+	if err := none.Release(); err != nil {
+		t.Errorf("Expected releasing a nil lock to do nothing, got %v", err)
+	}
+}
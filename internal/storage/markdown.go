@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Markdown checkbox markers by task status (Todo, Active, Done)
+var markdownCheckboxes = map[int]string{
+	0: "[ ]",
+	1: "[~]",
+	2: "[x]",
+}
+
+// ExportMarkdown renders tasks as a GitHub-style nested checklist with
+// two spaces of indentation per subtask level
+func ExportMarkdown(tasks []TaskData) string {
+	var b strings.Builder
+	writeMarkdown(&b, tasks, 0)
+	return b.String()
+}
+
+func writeMarkdown(b *strings.Builder, tasks []TaskData, depth int) {
+	for _, task := range tasks {
+		checkbox, ok := markdownCheckboxes[task.Status]
+		if !ok {
+			checkbox = markdownCheckboxes[0]
+		}
+		b.WriteString(strings.Repeat("  ", depth))
+		b.WriteString("- " + checkbox + " " + task.Title + "\n")
+		writeMarkdown(b, task.Subtasks, depth+1)
+	}
+}
+
+// markdownItem matches a list item, with or without a checkbox: indentation, checkbox mark, title
+var markdownItem = regexp.MustCompile(`^(\s*)[-*+]\s+(?:\[(.)\]\s+)?(.*)$`)
+
+// markdownTabWidth is how many columns a tab counts for when measuring indentation
+const markdownTabWidth = 4
+
+// markdownNode is a task being built by parseOutline along with its indentation
+type markdownNode struct {
+	indent   int
+	task     TaskData
+	subtasks []*markdownNode
+}
+
+// ImportMarkdown parses a nested Markdown checklist into a task tree. Nesting
+// follows indentation: an item is a subtask of the closest item above it that is
+// indented less, so mixed indentation widths nest as expected. "[x]" items are
+// Done, "[~]" and "[-]" items Active, and all other items Todo. Lines that aren't
+// list items, such as headings, are skipped. Every task gets a fresh ID.
+func ImportMarkdown(r io.Reader) ([]TaskData, error) {
+	return parseOutline(r, false)
+}
+
+// ParseOutline parses pasted text into a task tree like ImportMarkdown, except
+// that plain lines become Todo tasks too, nested by their indentation in the
+// same way. Blank lines are skipped.
+func ParseOutline(text string) []TaskData {
+	tasks, _ := parseOutline(strings.NewReader(text), true) // Reading a string can't fail
+	return tasks
+}
+
+// parseOutline parses list items, and plain lines too if plainLines is set, into
+// a task tree nested by indentation
+func parseOutline(r io.Reader, plainLines bool) ([]TaskData, error) {
+	root := &markdownNode{indent: -1}
+	stack := []*markdownNode{root}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.ReplaceAll(scanner.Text(), "\t", strings.Repeat(" ", markdownTabWidth))
+		var indent, mark, title string
+		if match := markdownItem.FindStringSubmatch(line); match != nil {
+			indent, mark, title = match[1], match[2], match[3]
+		} else if plainLines {
+			title = strings.TrimLeft(line, " ")
+			indent = line[:len(line)-len(title)]
+		}
+		if strings.TrimSpace(title) == "" {
+			continue
+		}
+
+		node := &markdownNode{
+			indent: len(indent),
+			task: TaskData{
+				ID:       uuid.New().String(),
+				Title:    strings.TrimSpace(title),
+				Status:   markdownStatus(mark),
+				Subtasks: []TaskData{},
+			},
+		}
+
+		// Close items indented as far or further; the one left on top is the parent
+		for len(stack) > 1 && stack[len(stack)-1].indent >= node.indent {
+			stack = stack[:len(stack)-1]
+		}
+		parent := stack[len(stack)-1]
+		parent.subtasks = append(parent.subtasks, node)
+		stack = append(stack, node)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return root.build(), nil
+}
+
+// markdownStatus maps a checkbox mark to a task status
+func markdownStatus(mark string) int {
+	switch mark {
+	case "x", "X":
+		return 2
+	case "~", "-":
+		return 1
+	default:
+		return 0
+	}
+}
+
+// build returns the node's subtasks as task data
+func (n *markdownNode) build() []TaskData {
+	tasks := []TaskData{}
+	for _, child := range n.subtasks {
+		task := child.task
+		task.Subtasks = child.build()
+		tasks = append(tasks, task)
+	}
+	return tasks
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExportMarkdown(t *testing.T) {
+	tasks := []TaskData{
+		{Title: "Bake cake", Status: 1, Subtasks: []TaskData{
+			{Title: "Mix batter", Status: 2},
+			{Title: "Preheat oven", Status: 0, Subtasks: []TaskData{
+				{Title: "Check temperature", Status: 0},
+			}},
+		}},
+		{Title: "Clean up", Status: 0},
+	}
+
+	want := "- [~] Bake cake\n" +
+		"  - [x] Mix batter\n" +
+		"  - [ ] Preheat oven\n" +
+		"    - [ ] Check temperature\n" +
+		"- [ ] Clean up\n"
+
+	if got := ExportMarkdown(tasks); got != want {
+		t.Errorf("Unexpected Markdown:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestImportMarkdown(t *testing.T) {
+	input := "# Plan\n\n" +
+		"- [~] Bake cake\n" +
+		"    - [X] Mix batter\n" +
+		"    - [ ] Preheat oven\n" +
+		"\t\t- [-] Check temperature\n" +
+		"  - Decorate\n" +
+		"Some notes\n" +
+		"* [x] Clean up\n"
+
+	tasks, err := ImportMarkdown(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Indentation of 4, then 8 (two tabs), then 2 still nests by relative depth
+	want := "- [~] Bake cake\n" +
+		"  - [x] Mix batter\n" +
+		"  - [ ] Preheat oven\n" +
+		"    - [~] Check temperature\n" +
+		"  - [ ] Decorate\n" +
+		"- [x] Clean up\n"
+	if got := ExportMarkdown(tasks); got != want {
+		t.Errorf("Unexpected tree:\n%s\nwant:\n%s", got, want)
+	}
+
+	seen := make(map[string]bool)
+	var walk func(tasks []TaskData)
+	walk = func(tasks []TaskData) {
+		for _, task := range tasks {
+			if task.ID == "" || seen[task.ID] {
+				t.Errorf("Expected a fresh unique ID for %q", task.Title)
+			}
+			seen[task.ID] = true
+			if task.Subtasks == nil {
+				t.Errorf("Expected non-nil subtasks for %q", task.Title)
+			}
+			walk(task.Subtasks)
+		}
+	}
+	walk(tasks)
+}
+
+func TestParseOutline(t *testing.T) {
+	input := "Groceries\r\n" +
+		"  Milk\n" +
+		"\n" +
+		"  - [x] Eggs\n" +
+		"Laundry\n"
+
+	want := "- [ ] Groceries\n" +
+		"  - [ ] Milk\n" +
+		"  - [x] Eggs\n" +
+		"- [ ] Laundry\n"
+	if got := ExportMarkdown(ParseOutline(input)); got != want {
+		t.Errorf("Unexpected tree:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,139 @@
+package storage
+
+import "fmt"
+
+// MergeConflict describes a task whose content differs between two copies of a list
+type MergeConflict struct {
+	ID     string
+	Left   TaskData // Version kept in the merged output (subtasks omitted)
+	Right  TaskData // Version that was not applied (subtasks omitted)
+	Reason string
+}
+
+func (c MergeConflict) String() string {
+	if c.Reason != "" {
+		return fmt.Sprintf("%s: %s", c.Left.Title, c.Reason)
+	}
+	return fmt.Sprintf("task %s: left %q (status %d) vs right %q (status %d)",
+		c.ID, c.Left.Title, c.Left.Status, c.Right.Title, c.Right.Status)
+}
+
+// MergeTasks merges two divergent copies of a task list using task IDs as the merge key.
+// The left tree is the base of the result: tasks only present in the right copy are
+// added under the same parent after the same preceding sibling where possible, and
+// tasks present in both with different content are kept as on the left and reported
+// as conflicts. Without a common ancestor deletions cannot be detected, so a task
+// missing from one side is kept.
+func MergeTasks(left, right []TaskData) ([]TaskData, []MergeConflict) {
+	merged := copyTaskTree(left)
+	var conflicts []MergeConflict
+
+	// Index the right side for content comparison
+	rightByID := make(map[string]TaskData)
+	walkTaskData(right, func(task TaskData, parentID, prevID string) {
+		if task.ID != "" {
+			rightByID[task.ID] = task
+		}
+	})
+
+	// Report tasks that changed on both sides
+	walkTaskData(merged, func(task TaskData, parentID, prevID string) {
+		other, ok := rightByID[task.ID]
+		if !ok || task.ID == "" {
+			return
+		}
+		if task.Title != other.Title || task.Notes != other.Notes || task.Status != other.Status || task.Estimate != other.Estimate || task.Deferred != other.Deferred || task.Pinned != other.Pinned || task.Priority != other.Priority ||
+			FormatDueDate(task.DueDate) != FormatDueDate(other.DueDate) {
+			conflicts = append(conflicts, MergeConflict{
+				ID:    task.ID,
+				Left:  withoutSubtasks(task),
+				Right: withoutSubtasks(other),
+			})
+		}
+	})
+
+	// Add tasks that only exist on the right, in pre-order so parents come first
+	walkTaskData(right, func(task TaskData, parentID, prevID string) {
+		if task.ID == "" {
+			conflicts = append(conflicts, MergeConflict{
+				Left:   withoutSubtasks(task),
+				Reason: "task without an ID on the right cannot be matched and was skipped",
+			})
+			return
+		}
+		if findTaskData(merged, task.ID) != nil {
+			return
+		}
+
+		container := &merged
+		if parentID != "" {
+			if parent := findTaskData(merged, parentID); parent != nil {
+				container = &parent.Subtasks
+			}
+		}
+
+		// Insert after the same preceding sibling, at the front if it was first,
+		// or at the end if its sibling lives elsewhere in the merged tree
+		index := len(*container)
+		if prevID == "" {
+			index = 0
+		}
+		for i, sibling := range *container {
+			if sibling.ID == prevID {
+				index = i + 1
+				break
+			}
+		}
+
+		added := withoutSubtasks(task)
+		added.Subtasks = []TaskData{}
+		*container = append(*container, TaskData{})
+		copy((*container)[index+1:], (*container)[index:])
+		(*container)[index] = added
+	})
+
+	return merged, conflicts
+}
+
+// walkTaskData visits tasks in pre-order with their parent and preceding sibling IDs
+func walkTaskData(tasks []TaskData, fn func(task TaskData, parentID, prevID string)) {
+	var walk func(tasks []TaskData, parentID string)
+	walk = func(tasks []TaskData, parentID string) {
+		prevID := ""
+		for _, task := range tasks {
+			fn(task, parentID, prevID)
+			walk(task.Subtasks, task.ID)
+			prevID = task.ID
+		}
+	}
+	walk(tasks, "")
+}
+
+// findTaskData returns a pointer to the task with the given ID, or nil
+func findTaskData(tasks []TaskData, id string) *TaskData {
+	for i := range tasks {
+		if tasks[i].ID == id {
+			return &tasks[i]
+		}
+		if found := findTaskData(tasks[i].Subtasks, id); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// copyTaskTree returns a deep copy of a task tree
+func copyTaskTree(tasks []TaskData) []TaskData {
+	result := make([]TaskData, len(tasks))
+	for i, task := range tasks {
+		result[i] = task
+		result[i].Subtasks = copyTaskTree(task.Subtasks)
+	}
+	return result
+}
+
+// withoutSubtasks returns a copy of a task with its subtasks removed
+func withoutSubtasks(task TaskData) TaskData {
+	task.Subtasks = nil
+	return task
+}
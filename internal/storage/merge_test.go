@@ -0,0 +1,62 @@
+package storage
+
+import "testing"
+
+func TestMergeTasks(t *testing.T) {
+	left := []TaskData{
+		{ID: "a", Title: "Shared", Status: 0, Subtasks: []TaskData{
+			{ID: "a1", Title: "Shared child"},
+			{ID: "a2", Title: "Left-only child"},
+		}},
+		{ID: "b", Title: "Edited on left", Status: 2},
+	}
+	right := []TaskData{
+		{ID: "a", Title: "Shared", Status: 0, Subtasks: []TaskData{
+			{ID: "a1", Title: "Shared child"},
+			{ID: "a3", Title: "Right-only child"},
+		}},
+		{ID: "b", Title: "Edited on right", Status: 1},
+		{ID: "c", Title: "Right-only task", Subtasks: []TaskData{
+			{ID: "c1", Title: "Right-only grandchild"},
+		}},
+	}
+
+	merged, conflicts := MergeTasks(left, right)
+
+	// Additions from both sides are kept, right additions placed after their right-hand sibling
+	if len(merged) != 3 || merged[2].ID != "c" {
+		t.Fatalf("Expected right-only task appended at top level, got %+v", merged)
+	}
+	if len(merged[2].Subtasks) != 1 || merged[2].Subtasks[0].ID != "c1" {
+		t.Error("Expected right-only subtree to be merged with its children")
+	}
+	children := merged[0].Subtasks
+	if len(children) != 3 || children[0].ID != "a1" || children[1].ID != "a3" || children[2].ID != "a2" {
+		t.Errorf("Expected children a1, a3, a2, got %+v", children)
+	}
+
+	// Differing content is kept from the left and reported
+	if len(conflicts) != 1 || conflicts[0].ID != "b" {
+		t.Fatalf("Expected one conflict for task b, got %v", conflicts)
+	}
+	if merged[1].Title != "Edited on left" {
+		t.Errorf("Expected left version to be kept, got %q", merged[1].Title)
+	}
+
+	// The inputs are not modified
+	if len(left[0].Subtasks) != 2 || len(left) != 2 {
+		t.Error("Expected left input to be unchanged")
+	}
+}
+
+func TestMergeTasksIdentical(t *testing.T) {
+	tasks := []TaskData{{ID: "a", Title: "Same", Subtasks: []TaskData{{ID: "b", Title: "Child"}}}}
+
+	merged, conflicts := MergeTasks(tasks, tasks)
+	if len(conflicts) != 0 {
+		t.Errorf("Expected no conflicts, got %v", conflicts)
+	}
+	if len(merged) != 1 || len(merged[0].Subtasks) != 1 {
+		t.Errorf("Expected merged tree to match input, got %+v", merged)
+	}
+}
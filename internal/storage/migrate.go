@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// knownVersions lists the file format versions this build can read, oldest first
+var knownVersions = []string{CurrentVersion}
+
+// MigrateFile upgrades a task file to the current format in place and returns
+// a description of each change, or none if the file was already current.
+// Files from a newer version of dotdot are refused rather than downgraded.
+func MigrateFile(filePath string) ([]string, error) {
+	old, legacy, err := readFileData(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if old.Version != "" && !slices.Contains(knownVersions, old.Version) {
+		return nil, fmt.Errorf("file %s has unknown version %s (this dotdot writes %s)", filePath, old.Version, CurrentVersion)
+	}
+
+	var changes []string
+	if legacy {
+		changes = append(changes, "converted the legacy task array to a file with metadata")
+	}
+	if assigned := assignMissingIDs(old.Tasks); assigned > 0 {
+		changes = append(changes, fmt.Sprintf("assigned IDs to %d task(s) without one", assigned))
+	}
+
+	migrated := migrateFileData(old)
+	if old.Version != migrated.Version {
+		from := old.Version
+		if from == "" {
+			from = "none"
+		}
+		changes = append(changes, fmt.Sprintf("stamped version %s (was %s)", migrated.Version, from))
+	}
+	if migrated.CreatedAt.IsZero() {
+		// The file's modification time is the best guess at when it was created
+		migrated.CreatedAt = guessCreationTime(filePath)
+		changes = append(changes, "added created_at "+migrated.CreatedAt.Format(time.RFC3339))
+	}
+	if migrated.UpdatedAt.IsZero() {
+		changes = append(changes, "added updated_at")
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	migrated.UpdatedAt = time.Now()
+	if err := writeFileData(filePath, migrated, SaveOptions{}); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// migrateFileData converts file data of any known version, or a legacy file
+// without one, to the current format. Each format change adds a case that
+// upgrades one version to the next, so old files pass through every step.
+func migrateFileData(old FileData) FileData {
+	data := old
+	if data.Tasks == nil {
+		data.Tasks = []TaskData{}
+	}
+	switch data.Version {
+	case "":
+		// Legacy arrays hold the same tasks as 1.0.0 files, just without metadata
+		data.Version = "1.0.0"
+	}
+	return data
+}
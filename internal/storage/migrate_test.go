@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMigrateFileLegacy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.dot")
+	legacy := `[{"title": "No ID", "status": 0, "subtasks": []}]`
+	if err := os.WriteFile(path, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+	modified := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(path, modified, modified); err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := MigrateFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	report := strings.Join(changes, "\n")
+	for _, want := range []string{"legacy", "assigned IDs to 1", "stamped version " + CurrentVersion + " (was none)", "created_at 2020-01-02"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Expected the report to mention %q, got:\n%s", want, report)
+		}
+	}
+
+	data, legacyAfter, err := readFileData(path)
+	if err != nil {
+		t.Fatalf("Unexpected error reading the migrated file: %v", err)
+	}
+	if legacyAfter || data.Version != CurrentVersion || !data.CreatedAt.Equal(modified) || data.UpdatedAt.IsZero() {
+		t.Errorf("Expected current metadata after migrating, got %+v", data)
+	}
+	if len(data.Tasks) != 1 || data.Tasks[0].ID == "" || data.Tasks[0].Title != "No ID" {
+		t.Errorf("Expected the task to be kept with an ID, got %+v", data.Tasks)
+	}
+
+	// Running it again is a no-op
+	changes, err = MigrateFile(path)
+	if err != nil || len(changes) != 0 {
+		t.Errorf("Expected no changes for a current file, got %v (err %v)", changes, err)
+	}
+}
+
+func TestMigrateFileRefusesUnknownVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "new.dot")
+	content := `{"version": "9.0.0", "tasks": []}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := MigrateFile(path); err == nil {
+		t.Error("Expected a file from a newer version to be refused")
+	}
+	if data, _ := os.ReadFile(path); string(data) != content {
+		t.Errorf("Expected the file to be left alone, got %s", data)
+	}
+}
@@ -0,0 +1,208 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// maxTaskStatus is the highest valid status value (Done)
+const maxTaskStatus = 2
+
+// maxTaskPriority is the highest valid priority value (high)
+const maxTaskPriority = 3
+
+// maxTaskDepth is the deepest nesting NormalizeTasks keeps, counting top-level tasks as 0
+const maxTaskDepth = 10
+
+// assignMissingIDs gives a fresh ID to every task in the tree that has none,
+// in place, and returns how many were assigned
+func assignMissingIDs(tasks []TaskData) int {
+	assigned := 0
+	for i := range tasks {
+		if tasks[i].ID == "" {
+			tasks[i].ID = uuid.New().String()
+			assigned++
+		}
+		assigned += assignMissingIDs(tasks[i].Subtasks)
+	}
+	return assigned
+}
+
+// replaceDuplicateIDs gives a fresh ID to every task that shares its ID with an
+// earlier task in tree order, in place, and returns how many were replaced
+func replaceDuplicateIDs(tasks []TaskData) int {
+	seen := make(map[string]bool)
+	replaced := 0
+	var walk func(tasks []TaskData)
+	walk = func(tasks []TaskData) {
+		for i := range tasks {
+			if seen[tasks[i].ID] {
+				tasks[i].ID = uuid.New().String()
+				replaced++
+			}
+			seen[tasks[i].ID] = true
+			walk(tasks[i].Subtasks)
+		}
+	}
+	walk(tasks)
+	return replaced
+}
+
+// NormalizeTasks returns a copy of a task tree with structural problems repaired,
+// along with a description of each fix. Imports and hand edits can leave tasks
+// without IDs, with IDs shared by several tasks, with unknown statuses, with
+// unparseable estimates or priorities, or with line breaks in titles that the one-line-per-task
+// formats cannot represent. They can also nest tasks deeper than any row has room
+// for, as importing an outline indented one step further on every line does; the
+// subtasks of tasks at maxTaskDepth are moved up to follow them in order.
+func NormalizeTasks(tasks []TaskData) ([]TaskData, []string) {
+	result := copyTaskTree(tasks)
+	seen := make(map[string]bool)
+	var fixes []string
+
+	var capDepth func(tasks []TaskData, depth int) []TaskData
+	capDepth = func(tasks []TaskData, depth int) []TaskData {
+		if depth < maxTaskDepth {
+			for i := range tasks {
+				tasks[i].Subtasks = capDepth(tasks[i].Subtasks, depth+1)
+			}
+			return tasks
+		}
+		if len(tasks) == 0 {
+			return tasks
+		}
+		capped := make([]TaskData, 0, len(tasks))
+		for _, task := range tasks {
+			descendants := FlattenTasks(task.Subtasks)
+			if len(descendants) > 0 {
+				fixes = append(fixes, fmt.Sprintf("%q: moved %d nested subtask(s) up to its level", task.Title, len(descendants)))
+			}
+			task.Subtasks = []TaskData{}
+			capped = append(capped, task)
+			for _, entry := range descendants {
+				moved := *entry.Task
+				moved.Subtasks = []TaskData{}
+				capped = append(capped, moved)
+			}
+		}
+		return capped
+	}
+	result = capDepth(result, 0)
+
+	var walk func(tasks []TaskData)
+	walk = func(tasks []TaskData) {
+		for i := range tasks {
+			task := &tasks[i]
+
+			if task.ID == "" {
+				task.ID = uuid.New().String()
+				fixes = append(fixes, fmt.Sprintf("%q: assigned a missing ID", task.Title))
+			} else if seen[task.ID] {
+				task.ID = uuid.New().String()
+				fixes = append(fixes, fmt.Sprintf("%q: replaced a duplicate ID", task.Title))
+			}
+			seen[task.ID] = true
+
+			if task.Status < 0 || task.Status > maxTaskStatus {
+				fixes = append(fixes, fmt.Sprintf("%q: reset unknown status %d to Todo", task.Title, task.Status))
+				task.Status = StatusTodo
+			}
+
+			if task.Priority < 0 || task.Priority > maxTaskPriority {
+				fixes = append(fixes, fmt.Sprintf("%q: cleared unknown priority %d", task.Title, task.Priority))
+				task.Priority = 0
+			}
+
+			if _, err := ParseEstimate(task.Estimate); err != nil {
+				fixes = append(fixes, fmt.Sprintf("%q: cleared invalid estimate %q", task.Title, task.Estimate))
+				task.Estimate = ""
+			}
+
+			if strings.ContainsAny(task.Title, "\r\n") {
+				task.Title = strings.Join(strings.Fields(task.Title), " ")
+				fixes = append(fixes, fmt.Sprintf("%q: joined a multi-line title", task.Title))
+			}
+
+			if task.Subtasks == nil {
+				task.Subtasks = []TaskData{}
+			}
+			walk(task.Subtasks)
+		}
+	}
+	walk(result)
+
+	return result, fixes
+}
+
+// NormalizeFile repairs a task file in place as NormalizeTasks does and returns
+// a description of each fix, or none if the file needed no repairs. The file is
+// read as stored, so the missing and duplicate IDs that LoadTasks would quietly
+// replace are reported too.
+func NormalizeFile(filePath string) ([]string, error) {
+	fileData, _, err := readFileData(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	normalized, fixes := NormalizeTasks(fileData.Tasks)
+	if len(fixes) == 0 {
+		return nil, nil
+	}
+
+	created := fileData.CreatedAt
+	if created.IsZero() {
+		created = guessCreationTime(filePath)
+	}
+	if err := SaveTasksWithOptions(filePath, normalized, SaveOptions{CreatedAt: created}); err != nil {
+		return nil, err
+	}
+	return fixes, nil
+}
+
+// Validate reports the structural problems in a task file without repairing
+// them: a version other than the current one, tasks without IDs or sharing an
+// ID (which the TUI cannot tell apart), empty titles, and unknown statuses.
+// Tasks are identified by their number on the command line.
+func Validate(fileData FileData) []error {
+	var problems []error
+	switch fileData.Version {
+	case CurrentVersion:
+	case "":
+		problems = append(problems, fmt.Errorf("no file version: legacy format, expected %s", CurrentVersion))
+	default:
+		problems = append(problems, fmt.Errorf("file version %s, expected %s", fileData.Version, CurrentVersion))
+	}
+
+	firstWithID := make(map[string]int)
+	for i, entry := range FlattenTasks(fileData.Tasks) {
+		n, task := i+1, entry.Task
+		switch first, seen := firstWithID[task.ID]; {
+		case task.ID == "":
+			problems = append(problems, fmt.Errorf("task %d %q: missing ID", n, task.Title))
+		case seen:
+			problems = append(problems, fmt.Errorf("task %d %q: duplicate ID %s, also used by task %d", n, task.Title, task.ID, first))
+		default:
+			firstWithID[task.ID] = n
+		}
+
+		if strings.TrimSpace(task.Title) == "" {
+			problems = append(problems, fmt.Errorf("task %d: empty title", n))
+		}
+		if task.Status < 0 || task.Status > maxTaskStatus {
+			problems = append(problems, fmt.Errorf("task %d %q: unknown status %d", n, task.Title, task.Status))
+		}
+	}
+	return problems
+}
+
+// ValidateFile reads a task file as stored, without the repairs LoadTasks
+// makes, and reports its problems as Validate does
+func ValidateFile(filePath string) ([]error, error) {
+	fileData, _, err := readFileData(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return Validate(fileData), nil
+}
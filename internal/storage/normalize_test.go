@@ -0,0 +1,182 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeTasks(t *testing.T) {
+	// Shaped like a careless hand edit
+	tasks := []TaskData{
+		{ID: "a", Title: "Parent", Status: 1, Subtasks: []TaskData{
+			{ID: "a", Title: "Child reusing its parent's ID", Status: 7},
+			{Title: "Child without an ID", Estimate: "soon", Priority: 5},
+		}},
+		{ID: "b", Title: "Line one\nline two", Status: -1},
+	}
+
+	normalized, fixes := NormalizeTasks(tasks)
+
+	if len(fixes) != 7 {
+		t.Fatalf("Expected 7 fixes, got %d: %v", len(fixes), fixes)
+	}
+
+	parent := normalized[0]
+	if parent.ID != "a" || parent.Status != 1 {
+		t.Errorf("Expected the valid parent to be left alone, got %+v", parent)
+	}
+	children := parent.Subtasks
+	if children[0].ID == "" || children[0].ID == "a" {
+		t.Errorf("Expected the duplicate ID to be replaced, got %q", children[0].ID)
+	}
+	if children[0].Status != 0 {
+		t.Errorf("Expected unknown status reset to Todo, got %d", children[0].Status)
+	}
+	if children[1].ID == "" || children[1].Estimate != "" || children[1].Priority != 0 {
+		t.Errorf("Expected an assigned ID, cleared estimate and cleared priority, got %+v", children[1])
+	}
+	if children[1].Subtasks == nil {
+		t.Error("Expected missing subtask lists to become empty lists")
+	}
+
+	last := normalized[1]
+	if last.Title != "Line one line two" || last.Status != 0 {
+		t.Errorf("Expected a joined title and Todo status, got %+v", last)
+	}
+
+	// The input is not modified
+	if tasks[0].Subtasks[0].ID != "a" || tasks[1].Status != -1 {
+		t.Error("Expected NormalizeTasks to leave its input unchanged")
+	}
+
+	// A normalized tree needs no further fixes
+	if _, again := NormalizeTasks(normalized); len(again) != 0 {
+		t.Errorf("Expected no fixes on a normalized tree, got %v", again)
+	}
+}
+
+func TestNormalizeImportedOutline(t *testing.T) {
+	// Every line indented one step further than the one above, as in an outline
+	// whose indentation went wrong, imports as a single chain
+	var outline strings.Builder
+	for i := 0; i <= maxTaskDepth+2; i++ {
+		fmt.Fprintf(&outline, "%s- [ ] Step %d\n", strings.Repeat("  ", i), i)
+	}
+	outline.WriteString("- [x] Separate\n")
+	imported, err := ImportMarkdown(strings.NewReader(outline.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	normalized, fixes := NormalizeTasks(imported)
+	if len(fixes) != 1 {
+		t.Fatalf("Expected 1 fix, got %d: %v", len(fixes), fixes)
+	}
+	if want := fmt.Sprintf("%q: moved 2 nested subtask(s) up to its level", fmt.Sprintf("Step %d", maxTaskDepth)); fixes[0] != want {
+		t.Errorf("Expected fix %q, got %q", want, fixes[0])
+	}
+
+	// The chain stops at maxTaskDepth, with the steps below it following in order
+	flat := FlattenTasks(normalized)
+	if len(flat) != maxTaskDepth+4 {
+		t.Fatalf("Expected every task to be kept, got %d", len(flat))
+	}
+	for i, entry := range flat[:maxTaskDepth+3] {
+		if want := fmt.Sprintf("Step %d", i); entry.Task.Title != want {
+			t.Errorf("Expected task %d to be %q, got %q", i, want, entry.Task.Title)
+		}
+		if want := min(i, maxTaskDepth); entry.Depth != want {
+			t.Errorf("Expected %q at depth %d, got %d", entry.Task.Title, want, entry.Depth)
+		}
+	}
+	if last := flat[len(flat)-1]; last.Task.Title != "Separate" || last.Depth != 0 || last.Task.Status != 2 {
+		t.Errorf("Expected the last top-level task to be left alone, got %+v at depth %d", *last.Task, last.Depth)
+	}
+
+	// Fresh IDs from the import are kept, and nesting within the limit is fine
+	if flat[maxTaskDepth+2].Task.ID != FlattenTasks(imported)[maxTaskDepth+2].Task.ID {
+		t.Error("Expected moved tasks to keep their IDs")
+	}
+	if _, again := NormalizeTasks(normalized); len(again) != 0 {
+		t.Errorf("Expected no fixes on a normalized tree, got %v", again)
+	}
+	shallow, _ := ImportMarkdown(strings.NewReader("- [ ] Parent\n        - [ ] Deeply indented child\n"))
+	if _, fixes := NormalizeTasks(shallow); len(fixes) != 0 {
+		t.Errorf("Expected indentation within the limit to need no fixes, got %v", fixes)
+	}
+}
+
+func TestNormalizeFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.dot")
+	stored := `{"version": "` + CurrentVersion + `", "tasks": [{"id": "a", "title": "First"}, {"id": "a", "title": "Copy"}, {"title": "No ID"}]}`
+	if err := os.WriteFile(path, []byte(stored), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The problems LoadTasks repairs quietly are reported from the file as stored
+	fixes, err := NormalizeFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{`"Copy": replaced a duplicate ID`, `"No ID": assigned a missing ID`}
+	if len(fixes) != len(want) || fixes[0] != want[0] || fixes[1] != want[1] {
+		t.Errorf("Expected fixes %v, got %v", want, fixes)
+	}
+	if problems, err := ValidateFile(path); err != nil || len(problems) != 0 {
+		t.Errorf("Expected the saved file to be valid, got %v (err %v)", problems, err)
+	}
+
+	if fixes, err := NormalizeFile(path); err != nil || len(fixes) != 0 {
+		t.Errorf("Expected nothing left to fix, got %v (err %v)", fixes, err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	fileData := FileData{
+		Version: "0.9.0",
+		Tasks: []TaskData{
+			{ID: "a", Title: "Parent", Subtasks: []TaskData{
+				{ID: "a", Title: "Reused ID", Status: 7},
+				{Title: "  "},
+			}},
+			{ID: "b", Title: "Fine", Status: StatusDone},
+		},
+	}
+
+	problems := Validate(fileData)
+	want := []string{
+		"file version 0.9.0, expected " + CurrentVersion,
+		`task 2 "Reused ID": duplicate ID a, also used by task 1`,
+		`task 2 "Reused ID": unknown status 7`,
+		`task 3 "  ": missing ID`,
+		"task 3: empty title",
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("Expected %d problems, got %d: %v", len(want), len(problems), problems)
+	}
+	for i, problem := range problems {
+		if problem.Error() != want[i] {
+			t.Errorf("Expected problem %q, got %q", want[i], problem)
+		}
+	}
+
+	valid := FileData{Version: CurrentVersion, Tasks: []TaskData{{ID: "a", Title: "Fine"}}}
+	if problems := Validate(valid); len(problems) != 0 {
+		t.Errorf("Expected no problems, got %v", problems)
+	}
+}
+
+func TestValidateFileEmpty(t *testing.T) {
+	// An empty file is a new, empty list rather than one without a version
+	path := filepath.Join(t.TempDir(), "new.dot")
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	problems, err := ValidateFile(path)
+	if err != nil || len(problems) != 0 {
+		t.Errorf("Expected an empty file to be valid, got %v (err %v)", problems, err)
+	}
+}
@@ -0,0 +1,36 @@
+package storage
+
+// ListStats counts the tasks in a list at all depths, in total and by status
+type ListStats struct {
+	Total  int `json:"total"`
+	Todo   int `json:"todo"`
+	Active int `json:"active"`
+	Done   int `json:"done"`
+}
+
+// CountTasks counts the tasks and their subtasks by status. Tasks with an
+// unknown status count toward the total only.
+func CountTasks(tasks []TaskData) ListStats {
+	var stats ListStats
+	for _, entry := range FlattenTasks(tasks) {
+		stats.Total++
+		switch entry.Task.Status {
+		case StatusTodo:
+			stats.Todo++
+		case StatusActive:
+			stats.Active++
+		case StatusDone:
+			stats.Done++
+		}
+	}
+	return stats
+}
+
+// PercentDone returns the share of tasks that are Done as a whole percentage,
+// rounded down so that 100 means everything is finished, or 0 for an empty list
+func (s ListStats) PercentDone() int {
+	if s.Total == 0 {
+		return 0
+	}
+	return s.Done * 100 / s.Total
+}
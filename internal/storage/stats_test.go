@@ -0,0 +1,30 @@
+package storage
+
+import "testing"
+
+func TestCountTasks(t *testing.T) {
+	tasks := []TaskData{
+		{ID: "a", Status: StatusDone, Subtasks: []TaskData{
+			{ID: "a1", Status: StatusDone},
+			{ID: "a2", Status: 1, Subtasks: []TaskData{{ID: "a2x"}}},
+		}},
+		{ID: "b"},
+		{ID: "c", Status: 7},
+	}
+
+	stats := CountTasks(tasks)
+	want := ListStats{Total: 6, Todo: 2, Active: 1, Done: 2}
+	if stats != want {
+		t.Errorf("Expected %+v, got %+v", want, stats)
+	}
+	if got := stats.PercentDone(); got != 33 {
+		t.Errorf("Expected 33%% done, got %d", got)
+	}
+
+	if got := CountTasks(nil).PercentDone(); got != 0 {
+		t.Errorf("Expected an empty list to be 0%% done, got %d", got)
+	}
+	if got := (ListStats{Total: 3, Done: 2, Todo: 1}).PercentDone(); got != 66 {
+		t.Errorf("Expected unfinished lists to round down, got %d", got)
+	}
+}
@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AllStatuses is the FormatTree status filter that shows every task
+const AllStatuses = -1
+
+// statusNames are the names of the task statuses (Todo, Active, Done) on the command line
+var statusNames = []string{"todo", "active", "done"}
+
+// ParseStatus parses a status name such as "todo" or "Done"
+func ParseStatus(s string) (int, error) {
+	for status, name := range statusNames {
+		if strings.EqualFold(strings.TrimSpace(s), name) {
+			return status, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown status %q: use %s", s, strings.Join(statusNames, ", "))
+}
+
+// FormatTree renders tasks as a plain-text tree for reading or grepping, one task
+// per line with its number from FlattenTasks, indentation for its depth, and a
+// checkbox for its status. With a status other than AllStatuses only tasks with
+// that status are listed, still under their original numbers.
+func FormatTree(tasks []TaskData, status int) string {
+	flat := FlattenTasks(tasks)
+	width := len(fmt.Sprint(len(flat)))
+
+	var b strings.Builder
+	for i, entry := range flat {
+		task := entry.Task
+		if status != AllStatuses && task.Status != status {
+			continue
+		}
+		checkbox, ok := markdownCheckboxes[task.Status]
+		if !ok {
+			checkbox = markdownCheckboxes[0]
+		}
+		fmt.Fprintf(&b, "%*d. %s%s %s\n", width, i+1, strings.Repeat("  ", entry.Depth), checkbox, task.Title)
+	}
+	return b.String()
+}
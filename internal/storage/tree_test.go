@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestFormatTree(t *testing.T) {
+	var tasks []TaskData
+	for i := range 9 {
+		tasks = append(tasks, TaskData{ID: fmt.Sprint(i), Title: fmt.Sprintf("Task %d", i+1)})
+	}
+	tasks[0].Status = 1
+	tasks[0].Subtasks = []TaskData{{ID: "sub", Title: "Sub", Status: 2}}
+
+	tree := FormatTree(tasks, AllStatuses)
+	lines := strings.Split(strings.TrimSuffix(tree, "\n"), "\n")
+	if len(lines) != 10 {
+		t.Fatalf("Expected a line per task, got:\n%s", tree)
+	}
+	if lines[0] != " 1. [~] Task 1" || lines[1] != " 2.   [x] Sub" || lines[9] != "10. [ ] Task 9" {
+		t.Errorf("Unexpected tree:\n%s", tree)
+	}
+
+	// Filtering keeps the numbers the done command uses
+	if done := FormatTree(tasks, 2); done != " 2.   [x] Sub\n" {
+		t.Errorf("Expected only the Done task, got:\n%s", done)
+	}
+}
+
+func TestParseStatus(t *testing.T) {
+	for name, want := range map[string]int{"todo": 0, "Active": 1, " DONE ": 2} {
+		if got, err := ParseStatus(name); err != nil || got != want {
+			t.Errorf("%q: expected %d, got %d (%v)", name, want, got, err)
+		}
+	}
+	if _, err := ParseStatus("finished"); err == nil {
+		t.Error("Expected an unknown status to be rejected")
+	}
+}
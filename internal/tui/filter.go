@@ -0,0 +1,292 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Display filtering: which tasks are shown and navigable
+
+// walkVisibleTasks calls fn for each displayed task in display order with its depth.
+// Hidden tasks are skipped along with their subtrees unless a descendant is visible,
+// and the subtasks of collapsed tasks and hidden deferred tasks are skipped entirely.
+// While focused on a sibling group, only that group and its subtrees are walked.
+func (m Model) walkVisibleTasks(fn func(task *Task, depth int)) {
+	var walk func(tasks []Task, depth int)
+	walk = func(tasks []Task, depth int) {
+		for _, i := range m.displayOrder(tasks) {
+			task := &tasks[i]
+			if !m.isTaskVisible(task) {
+				continue
+			}
+			fn(task, depth)
+			if !task.collapsed {
+				walk(task.subtasks, depth+1)
+			}
+		}
+	}
+	if parent := m.focusedParent(); parent != nil {
+		walk(parent.subtasks, len(m.getParentChainIDs(parent.id))+1)
+		return
+	}
+	walk(m.tasks, 0)
+}
+
+// displayOrder returns the indexes of a sibling group in the order they are shown:
+// stored order, or with Done tasks moved to the end while doneLast is on
+func (m Model) displayOrder(tasks []Task) []int {
+	order := make([]int, 0, len(tasks))
+	for i := range tasks {
+		if !m.doneLast || tasks[i].status != Done {
+			order = append(order, i)
+		}
+	}
+	if m.doneLast {
+		for i := range tasks {
+			if tasks[i].status == Done {
+				order = append(order, i)
+			}
+		}
+	}
+	return order
+}
+
+// toggleDoneLast switches between showing tasks in stored order and showing
+// Done tasks at the end of each group, without changing the stored order
+func (m *Model) toggleDoneLast() {
+	m.doneLast = !m.doneLast
+	if m.doneLast {
+		m.setStatus("Showing Done tasks last (display only)")
+	} else {
+		m.setStatus("Showing tasks in stored order")
+	}
+}
+
+// focusedParent returns the parent of the sibling group being focused on, or nil
+// when the whole tree is shown
+func (m Model) focusedParent() *Task {
+	if m.focusParentID == "" {
+		return nil
+	}
+	return m.findTaskByID(m.focusParentID)
+}
+
+// toggleFocusGroup hides everything but the current task's sibling group and
+// their subtrees, or shows the whole tree again
+func (m *Model) toggleFocusGroup() {
+	if m.focusParentID != "" {
+		m.focusParentID = ""
+		m.setStatus("Showing all tasks")
+		return
+	}
+
+	parent, index := m.findParentTask(m.cursorID)
+	if index < 0 {
+		return
+	}
+	if parent == nil {
+		m.setStatus("Already showing the top-level group")
+		return
+	}
+	m.focusParentID = parent.id
+	m.setStatus(fmt.Sprintf("Focused on the subtasks of '%s'", parent.title))
+}
+
+// isTaskVisible reports whether a task is displayed: either it matches the
+// active filters itself or it is an ancestor of a task that does
+func (m Model) isTaskVisible(task *Task) bool {
+	// Deferred tasks hide their whole subtree
+	if m.isDeferredHidden(task) {
+		return false
+	}
+	if m.taskMatchesFilters(task) {
+		// Optionally drop matches left looking empty because every subtask is filtered out
+		if m.hideEmptyParents && m.childrenAllHidden(task) && !(m.editing && task.id == m.cursorID) {
+			return false
+		}
+		return true
+	}
+	for i := range task.subtasks {
+		if m.isTaskVisible(&task.subtasks[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// taskMatchesFilters reports whether a task itself passes the active filters
+func (m Model) taskMatchesFilters(task *Task) bool {
+	// Never hide the task being edited
+	if m.editing && task.id == m.cursorID {
+		return true
+	}
+	if m.filtering && task.status != m.filterStatus {
+		return false
+	}
+	if m.filterTag != "" && !task.HasTag(m.filterTag) {
+		return false
+	}
+	return true
+}
+
+// isDeferredHidden reports whether a task is hidden because it is deferred
+func (m Model) isDeferredHidden(task *Task) bool {
+	if !task.deferred || m.showDeferred {
+		return false
+	}
+	// Never hide the task being edited
+	return !(m.editing && task.id == m.cursorID)
+}
+
+// childrenAllHidden reports whether a task has subtasks but the active filters hide all of them
+func (m Model) childrenAllHidden(task *Task) bool {
+	if !m.hasActiveFilter() || len(task.subtasks) == 0 {
+		return false
+	}
+	for i := range task.subtasks {
+		if m.isTaskVisible(&task.subtasks[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// toggleEmptyParents switches between noting and hiding parents whose subtasks are all filtered out
+func (m *Model) toggleEmptyParents() {
+	m.hideEmptyParents = !m.hideEmptyParents
+	if m.hideEmptyParents {
+		m.setStatus("Hiding parents with all subtasks filtered out")
+	} else {
+		m.setStatus("Showing parents with all subtasks filtered out")
+	}
+	m.ensureCursorVisible()
+}
+
+// toggleDeferred defers the current task, hiding it from the main view, or brings it back
+func (m *Model) toggleDeferred() {
+	task := m.getCurrentTask()
+	if task == nil {
+		return
+	}
+
+	// Pick where the cursor goes if the task disappears from view
+	nextID := m.getNextTaskID()
+	if nextID == m.cursorID {
+		nextID = m.getPreviousTaskID()
+	}
+
+	m.takeSnapshot(m.taskLabel("defer", m.cursorID))
+	deferred := !task.deferred
+	m.modifyCurrentTask(func(task *Task) {
+		task.deferred = deferred
+	})
+
+	if deferred {
+		m.setStatus("Task deferred (someday/maybe)")
+		if !m.showDeferred {
+			m.cursorID = nextID
+			m.ensureCursorVisible()
+		}
+	} else {
+		m.setStatus("Task no longer deferred")
+	}
+}
+
+// toggleShowDeferred switches between hiding and showing deferred tasks
+func (m *Model) toggleShowDeferred() {
+	m.showDeferred = !m.showDeferred
+	if m.showDeferred {
+		m.setStatus("Showing deferred tasks")
+	} else {
+		m.setStatus("Hiding deferred tasks")
+	}
+	m.ensureCursorVisible()
+}
+
+// hasActiveFilter reports whether any display filter is hiding tasks
+func (m Model) hasActiveFilter() bool {
+	return m.filtering || m.filterTag != ""
+}
+
+// cycleStatusFilter steps the status filter through All -> Todo -> Active -> Done -> All
+func (m *Model) cycleStatusFilter() {
+	switch {
+	case !m.filtering:
+		m.filtering = true
+		m.filterStatus = Todo
+	case m.filterStatus == Done:
+		m.filtering = false
+	default:
+		m.filterStatus++
+	}
+	m.ensureCursorVisible()
+}
+
+// clearFilters removes all display filters
+func (m *Model) clearFilters() {
+	m.filtering = false
+	m.filterTag = ""
+}
+
+// promptTagFilter asks for a tag and shows only the tasks with it, or all tasks
+// again when the answer is empty
+func (m *Model) promptTagFilter() {
+	m.openPrompt("Filter by tag (empty shows all)", m.filterTag, func(m *Model, value string) {
+		tag := strings.TrimPrefix(strings.TrimSpace(value), "#")
+		if tag == "" {
+			m.filterTag = ""
+			m.setStatus("Showing all tags")
+			return
+		}
+		if !m.anyTaskHasTag(m.tasks, tag) {
+			m.setStatus(fmt.Sprintf("No tasks tagged #%s", tag))
+			return
+		}
+		m.filterTag = tag
+		m.ensureCursorVisible()
+	})
+}
+
+// anyTaskHasTag reports whether any task in the tree has the given tag
+func (m Model) anyTaskHasTag(tasks []Task, tag string) bool {
+	for i := range tasks {
+		if tasks[i].HasTag(tag) || m.anyTaskHasTag(tasks[i].subtasks, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterDescription returns a short label for the active filters, or "" when none
+func (m Model) filterDescription() string {
+	var parts []string
+	if parent := m.focusedParent(); parent != nil {
+		parts = append(parts, fmt.Sprintf("in '%s'", parent.title))
+	}
+	if m.filtering {
+		parts = append(parts, "only "+m.filterStatus.String())
+	}
+	if m.filterTag != "" {
+		parts = append(parts, "tagged #"+m.filterTag)
+	}
+	if m.showDeferred {
+		parts = append(parts, "with deferred")
+	}
+	if m.doneLast {
+		parts = append(parts, "done last")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ensureCursorVisible moves the cursor to the first visible task if its task is hidden
+func (m *Model) ensureCursorVisible() {
+	ids := m.getAllTaskIDs()
+	for _, id := range ids {
+		if id == m.cursorID {
+			return
+		}
+	}
+	if len(ids) > 0 {
+		m.cursorID = ids[0]
+	}
+}
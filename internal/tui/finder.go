@@ -0,0 +1,160 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/v2/key"
+	"github.com/charmbracelet/bubbles/v2/textinput"
+	tea "github.com/charmbracelet/bubbletea/v2"
+)
+
+// Fuzzy finder: type a few characters of a title to jump to the task
+
+// taskFinder is the open fuzzy finder, shown as a prompt in the footer
+type taskFinder struct {
+	input      textinput.Model
+	matches    []string // IDs of the tasks matching the query, best first
+	selected   int      // Index into matches of the task under the cursor
+	previousID string   // Cursor position before the finder opened, restored on cancel
+}
+
+// openFinder starts a fuzzy search over every task, including folded and filtered out ones
+func (m *Model) openFinder() {
+	if len(m.tasks) == 0 {
+		m.setStatus("No tasks to jump to")
+		return
+	}
+
+	ti := textinput.New()
+	ti.Prompt = ""
+	ti.SetStyles(GetTextInputStyles())
+	ti.Focus()
+
+	m.finder = &taskFinder{input: ti, previousID: m.cursorID}
+}
+
+// fuzzyScore scores how well query matches title as a case-insensitive subsequence,
+// favouring runs of consecutive characters and matches at the start of words.
+// ok is false when the query's characters don't all appear in order.
+func fuzzyScore(query, title string) (score int, ok bool) {
+	q := []rune(strings.ToLower(query))
+	t := []rune(strings.ToLower(title))
+	qi, last := 0, -1
+	for ti := 0; ti < len(t) && qi < len(q); ti++ {
+		if t[ti] != q[qi] {
+			continue
+		}
+		score++
+		if ti == last+1 {
+			score += 2 // Consecutive characters
+		}
+		if ti == 0 || !unicode.IsLetter(t[ti-1]) && !unicode.IsDigit(t[ti-1]) {
+			score += 3 // Start of a word
+		}
+		if last >= 0 {
+			score -= min(ti-last-1, 3) // Gaps, capped so a late strong match still wins
+		}
+		last = ti
+		qi++
+	}
+	return score, qi == len(q)
+}
+
+// updateFinderMatches ranks every task against the query and previews the best
+// match, or moves the cursor back to where it was when nothing matches
+func (m *Model) updateFinderMatches() {
+	query := strings.TrimSpace(m.finder.input.Value())
+	m.finder.matches = nil
+	m.finder.selected = 0
+	if query == "" {
+		m.cursorID = m.finder.previousID
+		return
+	}
+
+	scores := make(map[string]int)
+	m.traverseTasks(func(task *Task) bool {
+		if score, ok := fuzzyScore(query, task.title); ok {
+			scores[task.id] = score
+			m.finder.matches = append(m.finder.matches, task.id)
+		}
+		return false
+	})
+	// Ties keep tree order
+	sort.SliceStable(m.finder.matches, func(i, j int) bool {
+		return scores[m.finder.matches[i]] > scores[m.finder.matches[j]]
+	})
+
+	if len(m.finder.matches) == 0 {
+		m.cursorID = m.finder.previousID
+		return
+	}
+	m.previewMatch()
+}
+
+// previewMatch puts the cursor on the selected match while it is shown in the
+// list. A hidden match is named in the footer instead, and revealed on enter.
+func (m *Model) previewMatch() {
+	m.cursorID = m.finder.previousID
+	if id := m.finder.matches[m.finder.selected]; slices.Contains(m.getAllTaskIDs(), id) {
+		m.cursorID = id
+	}
+}
+
+func (m Model) handleFinderMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keyMap.Confirm):
+		finder := m.finder
+		m.finder = nil
+		m.cursorID = finder.previousID
+		if len(finder.matches) == 0 {
+			m.setStatus("No matching task")
+			return m, nil
+		}
+		id := finder.matches[finder.selected]
+		m.revealTask(id)
+		m.jumpTo(id)
+		return m, nil
+	case key.Matches(msg, m.keyMap.Cancel):
+		m.cursorID = m.finder.previousID
+		m.finder = nil
+		m.setStatus("Cancelled")
+		return m, nil
+	case key.Matches(msg, m.keyMap.PrevMatch):
+		if m.finder.selected > 0 {
+			m.finder.selected--
+			m.previewMatch()
+		}
+		return m, nil
+	case key.Matches(msg, m.keyMap.NextMatch):
+		if m.finder.selected < len(m.finder.matches)-1 {
+			m.finder.selected++
+			m.previewMatch()
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.finder.input, cmd = m.finder.input.Update(msg)
+	m.updateFinderMatches()
+	return m, cmd
+}
+
+// renderFinder renders the finder's prompt line for the footer
+func (m Model) renderFinder() string {
+	line := PromptStyle.Render("Jump to: ") + m.finder.input.View()
+	if m.finder.input.Value() == "" {
+		return line
+	}
+	if len(m.finder.matches) == 0 {
+		return line + HelpStyle.Render("  no matches")
+	}
+	count := fmt.Sprintf("  %d/%d", m.finder.selected+1, len(m.finder.matches))
+	if id := m.finder.matches[m.finder.selected]; id != m.cursorID {
+		count += fmt.Sprintf("  hidden: %s", m.findTaskByID(id).title)
+	}
+	return line + HelpStyle.Render(count)
+}
@@ -0,0 +1,61 @@
+package tui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+)
+
+// Undo history browser: lists the undo stack and jumps back several steps at once
+
+// historyBrowser is the open undo history overlay
+type historyBrowser struct {
+	selected int // Index into the history, 0 being the most recent change
+}
+
+// openHistory shows the undo history overlay
+func (m *Model) openHistory() {
+	if len(m.undoStack) == 0 {
+		m.setStatus("Nothing to undo")
+		return
+	}
+	m.history = &historyBrowser{}
+}
+
+// undoSteps undoes the given number of changes, reporting the total as one status message
+func (m *Model) undoSteps(steps int) {
+	undone := 0
+	for ; undone < steps && len(m.undoStack) > 0; undone++ {
+		m.undo()
+	}
+	if undone > 1 {
+		m.setStatus(fmt.Sprintf("Undid %d changes", undone))
+	}
+}
+
+func (m Model) handleHistoryMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch m.handleListOverlay(msg, &m.history.selected, len(m.undoStack), m.keyMap.UndoHistory) {
+	case overlayConfirm:
+		steps := m.history.selected + 1
+		m.history = nil
+		m.undoSteps(steps)
+	case overlayClose:
+		m.history = nil
+	}
+	return m, nil
+}
+
+// renderHistory renders the undo history, most recent first, in place of the task list.
+// It returns the rows and the lines on which the selected entry starts and ends.
+func (m Model) renderHistory(width int) ([]string, int, int) {
+	entries := make([]string, len(m.undoStack))
+	for i := range m.undoStack {
+		snapshot := m.undoStack[len(m.undoStack)-1-i]
+		label := snapshot.label
+		if label == "" {
+			label = "unlabelled change"
+		}
+		entries[i] = fmt.Sprintf("%d. %s", i+1, label)
+	}
+	return m.renderListOverlay(width, "Undo history: ↵ to go back to before a change, esc to close", entries, m.history.selected)
+}
@@ -1,69 +1,236 @@
 package tui
 
-import "github.com/charmbracelet/bubbles/v2/key"
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/v2/key"
+)
 
 // KeyMap defines all keyboard shortcuts for the application
 type KeyMap struct {
 	// Navigation
-	Up    key.Binding
-	Down  key.Binding
-	Left  key.Binding
-	Right key.Binding
+	Up           key.Binding
+	Down         key.Binding
+	Left         key.Binding
+	Right        key.Binding
+	ToggleFold   key.Binding
+	GoToTop      key.Binding
+	GoToBottom   key.Binding
+	HalfPageUp   key.Binding
+	HalfPageDown key.Binding
+	PageUp       key.Binding
+	PageDown     key.Binding
+	JumpBack     key.Binding
+	FindTask     key.Binding
+	GoToParent   key.Binding
+	NextSibling  key.Binding
+	PrevSibling  key.Binding
 
 	// Task creation
-	NewTaskBelow    key.Binding
-	NewSubtask      key.Binding
-	NewTaskInParent key.Binding
+	NewTaskBelow           key.Binding
+	NewTaskBelowSameStatus key.Binding
+	NewSubtask             key.Binding
+	NewTaskInParent        key.Binding
+
+	// Task creation options
+	ToggleSmartNewTask key.Binding
+	ToggleAutoAdvance  key.Binding
+	ToggleSinkDone     key.Binding
+	ToggleAutoRollup   key.Binding
 
 	// Task management
-	MoveUp       key.Binding
-	MoveDown     key.Binding
-	IndentTask   key.Binding
-	UnindentTask key.Binding
-	DeleteTask   key.Binding
+	MoveUp            key.Binding
+	MoveDown          key.Binding
+	MoveToTop         key.Binding
+	MoveToBottom      key.Binding
+	IndentTask        key.Binding
+	UnindentTask      key.Binding
+	PromoteTask       key.Binding
+	UnindentToDepth   key.Binding
+	ReverseGroup      key.Binding
+	Normalize         key.Binding
+	ClearDone         key.Binding
+	DuplicateTaskOnly key.Binding
+	WrapInParent      key.Binding
+	DeleteTask        key.Binding
+	SetEstimate       key.Binding
+	SetDueDate        key.Binding
+	RaisePriority     key.Binding
+	LowerPriority     key.Binding
+	SortByPriority    key.Binding
+	SetStatus         key.Binding
+	ToggleDone        key.Binding
+	AdvanceChildren   key.Binding
 
 	// Edit mode
 	EditTask                key.Binding
+	EditNotes               key.Binding
+	AppendToTask            key.Binding
+	PrependToTask           key.Binding
 	Confirm                 key.Binding
 	Cancel                  key.Binding
 	NewTaskBelowFromEdit    key.Binding
 	NewSubtaskFromEdit      key.Binding
 	NewTaskInParentFromEdit key.Binding
+	ConfirmEdit             key.Binding
 
 	// Undo/Redo
-	Undo key.Binding
-	Redo key.Binding
+	Undo        key.Binding
+	Redo        key.Binding
+	UndoHistory key.Binding
+	Trash       key.Binding
 
 	// Clipboard
 	Copy           key.Binding
+	Cut            key.Binding
+	CopyMarkdown   key.Binding
+	CopySubtree    key.Binding
 	Paste          key.Binding
 	PasteAsSubtask key.Binding
 
+	// Display
+	FilterStatus       key.Binding
+	FilterTag          key.Binding
+	ToggleEmptyParents key.Binding
+	ToggleTruncate     key.Binding
+	ToggleCenterCursor key.Binding
+	ToggleDeferred     key.Binding
+	TogglePin          key.Binding
+	ShowDeferred       key.Binding
+	ShowPinned         key.Binding
+	FocusGroup         key.Binding
+	DoneLast           key.Binding
+
+	// Files
+	SaveAs        key.Binding
+	ForceSave     key.Binding
+	ExportSubtree key.Binding
+	MoveSubtree   key.Binding
+	MoveToList    key.Binding
+	OpenDirectory key.Binding
+
+	// Prompts
+	Yes       key.Binding
+	PrevMatch key.Binding
+	NextMatch key.Binding
+
 	// General
-	Help key.Binding
-	Quit key.Binding
+	Help     key.Binding
+	HideHelp key.Binding
+	Quit     key.Binding
 }
 
 // ShortHelp returns keybindings to be shown in the mini help view.
 func (k KeyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.Up, k.Down, k.Left, k.Right, k.NewTaskBelow, k.EditTask, k.Help, k.Quit}
+	return []key.Binding{k.Up, k.Down, k.Left, k.Right, k.ToggleDone, k.NewTaskBelow, k.EditTask, k.Help, k.Quit}
 }
 
 // FullHelp returns keybindings for the expanded help view.
 func (k KeyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
 		// Navigation
-		{k.Up, k.Down, k.Left, k.Right},
+		{k.Up, k.Down, k.Left, k.Right, k.GoToTop, k.GoToBottom, k.HalfPageUp, k.HalfPageDown, k.PageUp, k.PageDown, k.GoToParent, k.NextSibling, k.PrevSibling, k.JumpBack, k.FindTask, k.ToggleFold},
 		// Task Operations
-		{k.NewTaskBelow, k.NewSubtask, k.NewTaskInParent, k.EditTask},
+		{k.NewTaskBelow, k.NewTaskBelowSameStatus, k.NewSubtask, k.NewTaskInParent, k.EditTask, k.EditNotes, k.AppendToTask, k.PrependToTask, k.ToggleSmartNewTask, k.ToggleAutoAdvance, k.ToggleSinkDone, k.ToggleAutoRollup},
 		// Task Management
-		{k.MoveUp, k.MoveDown, k.IndentTask, k.UnindentTask, k.DeleteTask},
+		{k.MoveUp, k.MoveDown, k.MoveToTop, k.MoveToBottom, k.IndentTask, k.UnindentTask, k.PromoteTask, k.UnindentToDepth, k.ReverseGroup, k.Normalize, k.ClearDone, k.DuplicateTaskOnly, k.WrapInParent, k.DeleteTask, k.SetEstimate, k.SetDueDate, k.RaisePriority, k.LowerPriority, k.SortByPriority, k.SetStatus, k.ToggleDone, k.AdvanceChildren, k.ToggleDeferred, k.TogglePin},
 		// Edit & Actions
-		{k.Undo, k.Redo, k.Copy, k.Paste, k.PasteAsSubtask},
+		{k.Undo, k.Redo, k.UndoHistory, k.Trash, k.Copy, k.Cut, k.CopyMarkdown, k.CopySubtree, k.Paste, k.PasteAsSubtask},
 		// Edit Mode Actions (hidden as same as Normal mode)
-		// {k.NewTaskBelowFromEdit, k.NewSubtaskFromEdit, k.NewTaskInParentFromEdit},
+		// {k.NewTaskBelowFromEdit, k.NewSubtaskFromEdit, k.NewTaskInParentFromEdit, k.ConfirmEdit},
 		// General
-		{k.Help, k.Quit},
+		{k.FilterStatus, k.FilterTag, k.ShowDeferred, k.ShowPinned, k.FocusGroup, k.DoneLast, k.ToggleEmptyParents, k.ToggleTruncate, k.ToggleCenterCursor, k.SaveAs, k.ForceSave, k.ExportSubtree, k.MoveSubtree, k.MoveToList, k.OpenDirectory, k.Help, k.HideHelp, k.Quit},
+	}
+}
+
+// bindingName converts a KeyMap field name to its keys.json name
+func bindingName(field string) string {
+	var b strings.Builder
+	for i, r := range field {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// ApplyOverrides replaces the keys of the named bindings, keeping the defaults for
+// the rest. An empty key list disables a binding. It fails on unknown names and on
+// keys that would trigger more than one action in the task list.
+func (k *KeyMap) ApplyOverrides(overrides map[string][]string) error {
+	v := reflect.ValueOf(k).Elem()
+	fields := make(map[string]*key.Binding)
+	for i := 0; i < v.NumField(); i++ {
+		fields[bindingName(v.Type().Field(i).Name)] = v.Field(i).Addr().Interface().(*key.Binding)
+	}
+
+	for name, keys := range overrides {
+		binding, ok := fields[name]
+		if !ok {
+			return fmt.Errorf("unknown keybinding %q", name)
+		}
+		if len(keys) == 0 {
+			binding.SetEnabled(false)
+			continue
+		}
+		binding.SetKeys(keys...)
+		binding.SetHelp(strings.Join(keys, "/"), binding.Help().Desc)
+	}
+
+	return k.checkConflicts()
+}
+
+// promptBindings are only used while editing a title or answering a prompt, so
+// they may share keys with the task list's actions
+var promptBindings = map[string]bool{
+	"confirm":                      true,
+	"cancel":                       true,
+	"yes":                          true,
+	"prev_match":                   true,
+	"next_match":                   true,
+	"new_task_below_from_edit":     true,
+	"new_subtask_from_edit":        true,
+	"new_task_in_parent_from_edit": true,
+	"confirm_edit":                 true,
+}
+
+// checkConflicts reports a key bound to two of the actions available in the task list
+func (k KeyMap) checkConflicts() error {
+	owners := make(map[string]string)
+	v := reflect.ValueOf(k)
+	for i := 0; i < v.NumField(); i++ {
+		name := bindingName(v.Type().Field(i).Name)
+		binding := v.Field(i).Interface().(key.Binding)
+		if promptBindings[name] || !binding.Enabled() {
+			continue
+		}
+		for _, keyName := range binding.Keys() {
+			if other, ok := owners[keyName]; ok {
+				return fmt.Errorf("key %q is bound to both %s and %s", keyName, other, name)
+			}
+			owners[keyName] = name
+		}
+	}
+	return nil
+}
+
+// ChangeBindings returns the keybindings that change tasks (or start editing them),
+// and so discard the redo history
+func (k KeyMap) ChangeBindings() []key.Binding {
+	return []key.Binding{
+		k.Left, k.Right, k.SetStatus, k.ToggleDone, k.AdvanceChildren,
+		k.NewTaskBelow, k.NewTaskBelowSameStatus, k.NewSubtask, k.NewTaskInParent,
+		k.EditTask, k.EditNotes, k.AppendToTask, k.PrependToTask,
+		k.MoveUp, k.MoveDown, k.MoveToTop, k.MoveToBottom, k.IndentTask, k.UnindentTask, k.PromoteTask, k.UnindentToDepth, k.ReverseGroup,
+		k.Normalize, k.ClearDone, k.DuplicateTaskOnly, k.WrapInParent, k.DeleteTask, k.SetEstimate, k.SetDueDate, k.RaisePriority, k.LowerPriority, k.SortByPriority, k.ToggleDeferred, k.TogglePin,
+		k.Cut, k.Paste, k.PasteAsSubtask, k.MoveSubtree, k.MoveToList,
 	}
 }
 
@@ -87,12 +254,64 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("l", "right"),
 			key.WithHelp("→/l", "status forward"),
 		),
+		GoToTop: key.NewBinding(
+			key.WithKeys("g", "home"),
+			key.WithHelp("g", "go to top"),
+		),
+		GoToBottom: key.NewBinding(
+			key.WithKeys("G", "end"),
+			key.WithHelp("G", "go to bottom"),
+		),
+		HalfPageUp: key.NewBinding(
+			key.WithKeys("ctrl+u"),
+			key.WithHelp("ctrl+u", "half page up"),
+		),
+		HalfPageDown: key.NewBinding(
+			key.WithKeys("ctrl+d"),
+			key.WithHelp("ctrl+d", "half page down"),
+		),
+		PageUp: key.NewBinding(
+			key.WithKeys("pgup", "ctrl+b"),
+			key.WithHelp("pgup/ctrl+b", "page up"),
+		),
+		PageDown: key.NewBinding(
+			key.WithKeys("pgdown", "ctrl+f"),
+			key.WithHelp("pgdn/ctrl+f", "page down"),
+		),
+		GoToParent: key.NewBinding(
+			key.WithKeys("backspace"),
+			key.WithHelp("backspace", "go to parent"),
+		),
+		NextSibling: key.NewBinding(
+			key.WithKeys("J"),
+			key.WithHelp("J", "next sibling"),
+		),
+		PrevSibling: key.NewBinding(
+			key.WithKeys("K"),
+			key.WithHelp("K", "previous sibling"),
+		),
+		JumpBack: key.NewBinding(
+			key.WithKeys("'"),
+			key.WithHelp("'", "jump back"),
+		),
+		FindTask: key.NewBinding(
+			key.WithKeys(":"),
+			key.WithHelp(":", "jump to task"),
+		),
+		ToggleFold: key.NewBinding(
+			key.WithKeys("tab"),
+			key.WithHelp("tab", "fold/unfold subtasks"),
+		),
 
 		// Task creation
 		NewTaskBelow: key.NewBinding(
 			key.WithKeys("enter"),
 			key.WithHelp("↵", "new task below"),
 		),
+		NewTaskBelowSameStatus: key.NewBinding(
+			key.WithKeys("alt+enter"),
+			key.WithHelp("alt+↵", "new task below (same status)"),
+		),
 		NewSubtask: key.NewBinding(
 			key.WithKeys("shift+enter"),
 			key.WithHelp("shift+↵", "new subtask"),
@@ -102,6 +321,24 @@ func DefaultKeyMap() KeyMap {
 			key.WithHelp("ctrl+↵", "new task in parent"),
 		),
 
+		// Task creation options
+		ToggleSmartNewTask: key.NewBinding(
+			key.WithKeys("ctrl+t"),
+			key.WithHelp("ctrl+t", "toggle smart new task"),
+		),
+		ToggleAutoAdvance: key.NewBinding(
+			key.WithKeys("A"),
+			key.WithHelp("A", "toggle auto-advance on done"),
+		),
+		ToggleSinkDone: key.NewBinding(
+			key.WithKeys("b"),
+			key.WithHelp("b", "toggle sinking done tasks"),
+		),
+		ToggleAutoRollup: key.NewBinding(
+			key.WithKeys("C"),
+			key.WithHelp("C", "toggle auto-complete parents"),
+		),
+
 		// Task management
 		MoveUp: key.NewBinding(
 			key.WithKeys("ctrl+k", "ctrl+up"),
@@ -111,6 +348,14 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("ctrl+j", "ctrl+down"),
 			key.WithHelp("ctrl+↓/j", "move task down"),
 		),
+		MoveToTop: key.NewBinding(
+			key.WithKeys("alt+k", "alt+up"),
+			key.WithHelp("alt+↑/k", "move task to top of group"),
+		),
+		MoveToBottom: key.NewBinding(
+			key.WithKeys("alt+j", "alt+down"),
+			key.WithHelp("alt+↓/j", "move task to bottom of group"),
+		),
 		IndentTask: key.NewBinding(
 			key.WithKeys("ctrl+l", "ctrl+right"),
 			key.WithHelp("ctrl+→/l", "indent task"),
@@ -119,16 +364,101 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("ctrl+h", "ctrl+left"),
 			key.WithHelp("ctrl+←/h", "unindent task"),
 		),
+		PromoteTask: key.NewBinding(
+			key.WithKeys("<"),
+			key.WithHelp("<", "promote to top level"),
+		),
+		UnindentToDepth: key.NewBinding(
+			key.WithKeys("L"),
+			key.WithHelp("L 0-9", "unindent to depth"),
+		),
+		ReverseGroup: key.NewBinding(
+			key.WithKeys("R"),
+			key.WithHelp("R", "reverse siblings"),
+		),
+		Normalize: key.NewBinding(
+			key.WithKeys("N"),
+			key.WithHelp("N", "normalize list"),
+		),
+		ClearDone: key.NewBinding(
+			key.WithKeys("alt+d"),
+			key.WithHelp("alt+d", "clear done tasks"),
+		),
+		WrapInParent: key.NewBinding(
+			key.WithKeys("W"),
+			key.WithHelp("W", "wrap in new parent"),
+		),
+		DuplicateTaskOnly: key.NewBinding(
+			key.WithKeys("D"),
+			key.WithHelp("D", "duplicate task (no subtasks)"),
+		),
 		DeleteTask: key.NewBinding(
 			key.WithKeys("d"),
 			key.WithHelp("d", "delete task"),
 		),
 
+		SetEstimate: key.NewBinding(
+			key.WithKeys("t"),
+			key.WithHelp("t", "set estimate"),
+		),
+		SetDueDate: key.NewBinding(
+			key.WithKeys("@"),
+			key.WithHelp("@", "set due date"),
+		),
+		RaisePriority: key.NewBinding(
+			key.WithKeys("+", "="),
+			key.WithHelp("+", "raise priority"),
+		),
+		LowerPriority: key.NewBinding(
+			key.WithKeys("-"),
+			key.WithHelp("-", "lower priority"),
+		),
+		SortByPriority: key.NewBinding(
+			key.WithKeys("!"),
+			key.WithHelp("!", "sort siblings by priority"),
+		),
+		AdvanceChildren: key.NewBinding(
+			key.WithKeys(">"),
+			key.WithHelp(">", "advance subtasks' status"),
+		),
+		ToggleDeferred: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", "defer (someday/maybe)"),
+		),
+		TogglePin: key.NewBinding(
+			key.WithKeys("*"),
+			key.WithHelp("*", "pin/unpin"),
+		),
+		ShowPinned: key.NewBinding(
+			key.WithKeys("&"),
+			key.WithHelp("&", "show/hide pinned"),
+		),
+		SetStatus: key.NewBinding(
+			key.WithKeys("1", "2", "3"),
+			key.WithHelp("1-3", "set Todo/Active/Done"),
+		),
+		ToggleDone: key.NewBinding(
+			key.WithKeys("space"),
+			key.WithHelp("space", "toggle done"),
+		),
+
 		// Edit mode
 		EditTask: key.NewBinding(
 			key.WithKeys("e"),
 			key.WithHelp("e", "edit task"),
 		),
+		EditNotes: key.NewBinding(
+			key.WithKeys("E"),
+			key.WithHelp("E", "edit notes"),
+		),
+		AppendToTask: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", "append to title"),
+		),
+		PrependToTask: key.NewBinding(
+			key.WithKeys("i"),
+			key.WithHelp("i", "prepend to title"),
+		),
 		Confirm: key.NewBinding(
 			key.WithKeys("enter"),
 			key.WithHelp("↵", "confirm"),
@@ -149,6 +479,10 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("ctrl+enter"),
 			key.WithHelp("ctrl+↵", "save & new task in parent"),
 		),
+		ConfirmEdit: key.NewBinding(
+			key.WithKeys("alt+enter", "ctrl+s"),
+			key.WithHelp("alt+↵", "save & stop editing"),
+		),
 
 		// Undo/Redo
 		Undo: key.NewBinding(
@@ -159,12 +493,32 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("r"),
 			key.WithHelp("r", "redo"),
 		),
+		UndoHistory: key.NewBinding(
+			key.WithKeys("U"),
+			key.WithHelp("U", "undo history"),
+		),
+		Trash: key.NewBinding(
+			key.WithKeys("T"),
+			key.WithHelp("T", "trash"),
+		),
 
 		// Clipboard
 		Copy: key.NewBinding(
 			key.WithKeys("y"),
 			key.WithHelp("y", "copy task"),
 		),
+		Cut: key.NewBinding(
+			key.WithKeys("c"),
+			key.WithHelp("c", "cut task with subtasks"),
+		),
+		CopyMarkdown: key.NewBinding(
+			key.WithKeys("Y"),
+			key.WithHelp("Y", "copy list as Markdown"),
+		),
+		CopySubtree: key.NewBinding(
+			key.WithKeys("alt+y"),
+			key.WithHelp("alt+y", "copy task and subtasks as Markdown"),
+		),
 		Paste: key.NewBinding(
 			key.WithKeys("p"),
 			key.WithHelp("p", "paste task"),
@@ -174,11 +528,89 @@ func DefaultKeyMap() KeyMap {
 			key.WithHelp("P", "paste as subtask"),
 		),
 
+		// Display
+		FilterStatus: key.NewBinding(
+			key.WithKeys("F"),
+			key.WithHelp("F", "filter by status"),
+		),
+		FilterTag: key.NewBinding(
+			key.WithKeys("#"),
+			key.WithHelp("#", "filter by tag"),
+		),
+		ShowDeferred: key.NewBinding(
+			key.WithKeys("B"),
+			key.WithHelp("B", "show/hide deferred"),
+		),
+		FocusGroup: key.NewBinding(
+			key.WithKeys("f"),
+			key.WithHelp("f", "focus sibling group"),
+		),
+		DoneLast: key.NewBinding(
+			key.WithKeys("o"),
+			key.WithHelp("o", "show done last"),
+		),
+		ToggleEmptyParents: key.NewBinding(
+			key.WithKeys("H"),
+			key.WithHelp("H", "hide/show emptied parents"),
+		),
+		ToggleTruncate: key.NewBinding(
+			key.WithKeys("w"),
+			key.WithHelp("w", "toggle wrap/truncate"),
+		),
+		ToggleCenterCursor: key.NewBinding(
+			key.WithKeys("z"),
+			key.WithHelp("z", "toggle centered scrolling"),
+		),
+
+		// Files
+		SaveAs: key.NewBinding(
+			key.WithKeys("S"),
+			key.WithHelp("S", "save as"),
+		),
+		ForceSave: key.NewBinding(
+			key.WithKeys("ctrl+s"),
+			key.WithHelp("ctrl+s", "save over outside changes"),
+		),
+		ExportSubtree: key.NewBinding(
+			key.WithKeys("x"),
+			key.WithHelp("x", "export task to new list"),
+		),
+		MoveSubtree: key.NewBinding(
+			key.WithKeys("X"),
+			key.WithHelp("X", "move task to new list"),
+		),
+		MoveToList: key.NewBinding(
+			key.WithKeys("m"),
+			key.WithHelp("m", "move task to another list"),
+		),
+		OpenDirectory: key.NewBinding(
+			key.WithKeys("O"),
+			key.WithHelp("O", "open file's folder"),
+		),
+
+		// Prompts
+		Yes: key.NewBinding(
+			key.WithKeys("y", "Y"),
+			key.WithHelp("y", "yes"),
+		),
+		PrevMatch: key.NewBinding(
+			key.WithKeys("up", "ctrl+p"),
+			key.WithHelp("↑", "previous match"),
+		),
+		NextMatch: key.NewBinding(
+			key.WithKeys("down", "ctrl+n"),
+			key.WithHelp("↓", "next match"),
+		),
+
 		// General
 		Help: key.NewBinding(
 			key.WithKeys("?"),
 			key.WithHelp("?", "toggle help"),
 		),
+		HideHelp: key.NewBinding(
+			key.WithKeys("~"),
+			key.WithHelp("~", "hide/show help bar"),
+		),
 		Quit: key.NewBinding(
 			key.WithKeys("q", "ctrl+c"),
 			key.WithHelp("q", "quit"),
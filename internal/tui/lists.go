@@ -0,0 +1,141 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"dotdot/internal/storage"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+)
+
+// Moving tasks between lists: pick another task list and move the current subtree into it
+
+// listChoice is a task list that tasks can be moved to
+type listChoice struct {
+	label string // List name as shown in the picker
+	path  string
+}
+
+// listPicker is the open list picker overlay
+type listPicker struct {
+	taskID   string // Task being moved
+	choices  []listChoice
+	selected int
+}
+
+// openListPicker shows the lists the current task can be moved to
+func (m *Model) openListPicker() {
+	task := m.getCurrentTask()
+	if task == nil {
+		return
+	}
+
+	choices, err := m.otherLists()
+	if err != nil {
+		m.setError("Failed to list task lists: " + err.Error())
+		return
+	}
+	if len(choices) == 0 {
+		m.setStatus("No other task lists to move to")
+		return
+	}
+	m.listPicker = &listPicker{taskID: task.id, choices: choices}
+}
+
+// otherLists returns the global task lists followed by the local ones in the
+// current directory, leaving out the open list
+func (m Model) otherLists() ([]listChoice, error) {
+	var choices []listChoice
+	current, _ := filepath.Abs(m.filePath)
+	add := func(label, path string) {
+		if abs, err := filepath.Abs(path); err == nil && abs != current {
+			choices = append(choices, listChoice{label: label, path: path})
+		}
+	}
+
+	globals, err := storage.ListGlobalTasks()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range globals {
+		if path, err := storage.GlobalTaskPath(name); err == nil {
+			add(name, path)
+		}
+	}
+
+	locals, err := storage.ListLocalTasks()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range locals {
+		add("./"+storage.TaskFileName(name), storage.TaskFileName(name))
+	}
+	return choices, nil
+}
+
+// moveToList appends a task and its subtasks to another list with fresh IDs and
+// removes them from this one. Undo brings the task back here but leaves the
+// copy in the other list.
+func (m *Model) moveToList(taskID string, choice listChoice) {
+	task := m.findTaskByID(taskID)
+	if task == nil {
+		return
+	}
+	title := task.title
+
+	// The task is written to the other list first, so this one must be able to
+	// save without it, or the task would end up in both
+	if m.filePath != "" {
+		if !m.autoSave {
+			m.setError("Move failed: autosave is off, so this list wouldn't be saved without the task")
+			return
+		}
+		if err := m.checkFileUnchanged(); err != nil {
+			m.setError("Move failed: " + err.Error())
+			return
+		}
+	}
+	if err := storage.AppendSubtree(choice.path, ToTaskData(*task), m.saveOptions()); err != nil {
+		m.setError("Move failed: " + err.Error())
+		return
+	}
+
+	m.takeSnapshot(m.taskLabel("move to "+choice.label, taskID))
+	parent, index := m.findParentTask(taskID)
+	removeTaskFromSlice(m.getTaskContainer(parent), index)
+	if m.cursorID == taskID {
+		m.updateCursorAfterDeletion()
+	}
+	m.setStatus(fmt.Sprintf("Moved '%s' to %s", title, choice.label))
+	m.autoSaveIfEnabled()
+	if m.unsaved {
+		m.setError(fmt.Sprintf("%s; '%s' is in %s too until this list is saved", m.lastError, title, choice.label))
+	}
+}
+
+func (m Model) handleListPickerMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch m.handleListOverlay(msg, &m.listPicker.selected, len(m.listPicker.choices), m.keyMap.MoveToList) {
+	case overlayConfirm:
+		picker := m.listPicker
+		m.listPicker = nil
+		m.moveToList(picker.taskID, picker.choices[picker.selected])
+	case overlayClose:
+		m.listPicker = nil
+	}
+	return m, nil
+}
+
+// renderListPicker renders the lists to move a task to in place of the task list.
+// It returns the rows and the lines on which the selected entry starts and ends.
+func (m Model) renderListPicker(width int) ([]string, int, int) {
+	title := "task"
+	if task := m.findTaskByID(m.listPicker.taskID); task != nil {
+		title = fmt.Sprintf("'%s'", task.title)
+	}
+	entries := make([]string, len(m.listPicker.choices))
+	for i, choice := range m.listPicker.choices {
+		entries[i] = choice.label
+	}
+	return m.renderListOverlay(width, fmt.Sprintf("Move %s to: ↵ to move, esc to cancel", title), entries, m.listPicker.selected)
+}
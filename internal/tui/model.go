@@ -1,10 +1,15 @@
 package tui
 
 import (
+	"errors"
 	"fmt"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
+	"time"
 
+	"dotdot/internal/config"
 	"dotdot/internal/storage"
 
 	"github.com/charmbracelet/bubbles/v2/help"
@@ -13,36 +18,87 @@ import (
 	"github.com/charmbracelet/bubbles/v2/viewport"
 	tea "github.com/charmbracelet/bubbletea/v2"
 	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/google/uuid"
 )
 
 type Model struct {
-	width          int
-	height         int
-	tasks          []Task
-	cursorID       string
-	previousID     string
-	editing        bool
-	textInput      textinput.Model
-	viewport       viewport.Model
-	filePath       string          // Path to the current task file
-	autoSave       bool            // Enable auto-save after operations
-	lastError      string          // Last error message to display
-	showError      bool            // Whether to show the error message
-	undoStack      []ModelSnapshot // History for undo operations
-	redoStack      []ModelSnapshot // History for redo operations
-	maxHistorySize int             // Maximum number of history entries
-	statusMessage  string          // Debug/status message to display
-	help           help.Model      // Help component
-	keyMap         KeyMap          // Key bindings
-	showFullHelp   bool            // Toggle between short and full help
+	width            int
+	height           int
+	tasks            []Task
+	cursorID         string
+	previousID       string
+	editing          bool
+	textInput        textinput.Model
+	viewport         viewport.Model
+	filePath         string                // Path to the current task file
+	autoSave         bool                  // Enable auto-save after operations
+	unsaved          bool                  // The file is behind the task list: the last auto-save failed, or autosave is off
+	fileModTime      time.Time             // Modification time of the file when last loaded or saved
+	fileCreated      time.Time             // Creation time recorded in the file, kept on save without rereading the file; zero if not known yet
+	lastError        string                // Last error message to display
+	showError        bool                  // Whether to show the error message
+	undoStack        []ModelSnapshot       // History for undo operations
+	redoStack        []ModelSnapshot       // History for redo operations
+	maxHistorySize   int                   // Maximum number of history entries, unbounded when <= 0
+	statusMessage    string                // Debug/status message to display
+	help             help.Model            // Help component
+	keyMap           KeyMap                // Key bindings
+	showFullHelp     bool                  // Toggle between short and full help
+	hideHelp         bool                  // Hide the help footer entirely to make room for tasks
+	prompt           *inputPrompt          // Active text prompt, if any
+	confirm          *confirmPrompt        // Pending yes/no confirmation, if any
+	history          *historyBrowser       // Open undo history overlay, if any
+	trash            []Task                // Deleted subtrees this session, most recent last
+	trashView        *trashBrowser         // Open trash overlay, if any
+	listPicker       *listPicker           // Open overlay for picking a list to move a task to, if any
+	notesEditor      *notesEditor          // Open editor for the notes of a task, if any
+	finder           *taskFinder           // Open fuzzy finder for jumping to a task, if any
+	clipboardTask    *Task                 // Subtree cut with its subtasks, separate from the system clipboard
+	wrapParentID     string                // New parent from wrapInNewParent whose title is being entered, "" once it has one
+	smartNewTask     bool                  // New tasks below an Active parent with subtasks become subtasks
+	compactJSON      bool                  // Save files without JSON indentation
+	sinkDoneTasks    bool                  // Move tasks to the bottom of their group when marked Done
+	autoAdvance      bool                  // Move the cursor to the next incomplete task after marking one Done
+	autoRollup       bool                  // Mark parents Done when all their subtasks are, and Active again when one reopens
+	confirmRedoLoss  bool                  // Ask before a change discards the redo history
+	confirmDelete    bool                  // Ask before deleting a task that has subtasks
+	readOnly         bool                  // Browse only: keys that change the list or its file do nothing
+	bulletSymbols    map[TaskStatus]string // Bullet shown for each status, defaults merged with config overrides
+	bulletOverrides  map[TaskStatus]string // Bullets from the config, applied over both the Unicode and the ASCII set
+	truncateTitles   bool                  // Truncate long titles to one line instead of wrapping
+	filtering        bool                  // Whether the status filter is active
+	filterStatus     TaskStatus            // Only tasks with this status (and their ancestors) are shown when filtering
+	filterTag        string                // Only tasks with this tag (and their ancestors) are shown, "" for all
+	hideEmptyParents bool                  // Hide filter matches whose subtasks are all filtered out instead of noting it
+	showDeferred     bool                  // Show deferred (someday/maybe) tasks instead of hiding them
+	showPinned       bool                  // Show the pinned section above the task list
+	pinnedCursor     int                   // Entry of the pinned section under the cursor, 0 while in the task list
+	doneLast         bool                  // Show Done tasks at the end of each group without reordering the stored list
+	focusParentID    string                // Parent of the sibling group the view is focused on, "" for the whole tree
+	jumpHistory      []string              // Cursor positions before large moves, most recent last
+	inline           bool                  // Running without the alt-screen, so the view height is capped
+	ascii            bool                  // Drawing with plain ASCII symbols instead of Unicode ones
+	centerCursor     bool                  // Keep the selected task in the middle of the viewport when scrolling
+	yOffset          int                   // Scroll position from the last update, so scrolling follows the cursor both ways
+	unindentPending  bool                  // Waiting for the number of the depth to unindent the current task to
 }
 
 type Task struct {
-	id       string
-	title    string
-	status   TaskStatus
-	subtasks []Task
+	id        string
+	title     string
+	notes     string // Optional longer description, may span several lines
+	status    TaskStatus
+	undone    TaskStatus    // Status to go back to when Done is toggled off
+	estimate  time.Duration // Optional time estimate, zero when unset
+	due       *time.Time    // Optional due date (midnight of the day), nil when unset
+	priority  int           // NoPriority to HighPriority
+	collapsed bool          // Whether subtasks are folded away in the view
+	deferred  bool          // Someday/maybe: hidden from the main view unless deferred tasks are shown
+	pinned    bool          // Listed in the pinned section at the top while it is shown
+	created   time.Time     // When the task was created, zero for tasks from older files
+	updated   time.Time     // When the title, notes or status last changed, zero if never
+	subtasks  []Task
 }
 
 type TaskStatus int
@@ -53,11 +109,35 @@ const (
 	Done
 )
 
+// Task priority levels
+const (
+	NoPriority = iota
+	LowPriority
+	MediumPriority
+	HighPriority
+)
+
+// String returns the display name of a status
+func (s TaskStatus) String() string {
+	switch s {
+	case Todo:
+		return "Todo"
+	case Active:
+		return "Active"
+	case Done:
+		return "Done"
+	default:
+		return fmt.Sprintf("Status(%d)", int(s))
+	}
+}
+
 // ModelSnapshot represents a state snapshot for undo/redo functionality
 type ModelSnapshot struct {
 	tasks      []Task
 	cursorID   string
 	previousID string
+	label      string // Description of the change made after the snapshot, shown on undo/redo
+	trashed    *Task  // Trash entry the change took out, put back on undo
 }
 
 // NewTask creates a new task with auto-generated UUID
@@ -66,6 +146,7 @@ func NewTask(title string, status TaskStatus, subtasks ...Task) Task {
 		id:       uuid.New().String(),
 		title:    title,
 		status:   status,
+		created:  time.Now(),
 		subtasks: subtasks,
 	}
 }
@@ -80,6 +161,11 @@ func NewTaskWithID(id, title string, status TaskStatus, subtasks ...Task) Task {
 	}
 }
 
+// touch records that a task's title, notes or status just changed
+func (t *Task) touch() {
+	t.updated = time.Now()
+}
+
 // Accessor methods for Task
 func (t Task) ID() string {
 	return t.id
@@ -89,6 +175,10 @@ func (t Task) Title() string {
 	return t.title
 }
 
+func (t Task) Notes() string {
+	return t.notes
+}
+
 func (t Task) Status() TaskStatus {
 	return t.status
 }
@@ -97,11 +187,77 @@ func (t Task) Subtasks() []Task {
 	return t.subtasks
 }
 
+func (t Task) Estimate() time.Duration {
+	return t.estimate
+}
+
+func (t Task) CreatedAt() time.Time {
+	return t.created
+}
+
+func (t Task) UpdatedAt() time.Time {
+	return t.updated
+}
+
+func (t Task) Collapsed() bool {
+	return t.collapsed
+}
+
+func (t Task) Deferred() bool {
+	return t.deferred
+}
+
+func (t Task) Pinned() bool {
+	return t.pinned
+}
+
+func (t Task) DueDate() *time.Time {
+	return t.due
+}
+
+func (t Task) Priority() int {
+	return t.priority
+}
+
+// tagPattern matches a #tag in a title: a # starting a word, followed by letters,
+// digits, dashes or underscores. The tag itself is the first group.
+var tagPattern = regexp.MustCompile(`(?:^|\s)#([\p{L}\p{N}_-]+)`)
+
+// Tags returns the #tags in the task's title without the #, in order and without repeats
+func (t Task) Tags() []string {
+	var tags []string
+	for _, match := range tagPattern.FindAllStringSubmatch(t.title, -1) {
+		if !slices.ContainsFunc(tags, func(tag string) bool { return strings.EqualFold(tag, match[1]) }) {
+			tags = append(tags, match[1])
+		}
+	}
+	return tags
+}
+
+// HasTag reports whether the task's title has the given tag, ignoring case
+func (t Task) HasTag(tag string) bool {
+	return slices.ContainsFunc(t.Tags(), func(t string) bool { return strings.EqualFold(t, tag) })
+}
+
+// DescendantCount returns the number of subtasks at all depths below the task
+func (t Task) DescendantCount() int {
+	count := len(t.subtasks)
+	for _, subtask := range t.subtasks {
+		count += subtask.DescendantCount()
+	}
+	return count
+}
+
 func NewModel() Model {
 	return NewModelWithFile("")
 }
 
 func NewModelWithFile(filePath string) Model {
+	return NewModelWithConfig(filePath, config.Default())
+}
+
+// NewModelWithConfig creates a model for the given file using the user's configuration
+func NewModelWithConfig(filePath string, cfg config.Config) Model {
 	ti := textinput.New()
 	ti.Placeholder = "Task text..."
 	ti.Prompt = ""
@@ -110,13 +266,15 @@ func NewModelWithFile(filePath string) Model {
 	// ti.Cursor.Style = tea.CursorBar
 	var tasks []Task
 	var cursorID string
+	var created time.Time
 
 	var loadError string
 
 	// Load tasks from file if specified, otherwise use mock data
 	if filePath != "" {
-		if loadedTasks, err := loadTasksFromFile(filePath); err == nil {
+		if loadedTasks, loadedCreated, err := loadTasksFromFile(filePath); err == nil {
 			tasks = loadedTasks
+			created = loadedCreated
 		} else {
 			// On error, start with empty task list and show error
 			tasks = []Task{}
@@ -142,42 +300,208 @@ func NewModelWithFile(filePath string) Model {
 	helpModel.Width = 80 // Default width, will be updated on first WindowSizeMsg
 
 	return Model{
-		tasks:          tasks,
-		cursorID:       cursorID,
-		previousID:     "",
-		editing:        false,
-		textInput:      ti,
-		viewport:       vp,
-		filePath:       filePath,
-		autoSave:       filePath != "", // Enable auto-save when file path is provided
-		lastError:      loadError,
-		showError:      loadError != "",
-		undoStack:      make([]ModelSnapshot, 0),
-		redoStack:      make([]ModelSnapshot, 0),
-		maxHistorySize: 50,
-		help:           helpModel,
-		keyMap:         DefaultKeyMap(),
-		showFullHelp:   false,
-	}
-}
-
-func (m Model) Init() tea.Cmd { return nil }
+		tasks:           tasks,
+		cursorID:        cursorID,
+		previousID:      "",
+		editing:         false,
+		textInput:       ti,
+		viewport:        vp,
+		filePath:        filePath,
+		autoSave:        filePath != "", // Enable auto-save when file path is provided
+		fileModTime:     modTimeOf(filePath),
+		fileCreated:     created,
+		lastError:       loadError,
+		showError:       loadError != "",
+		undoStack:       make([]ModelSnapshot, 0),
+		redoStack:       make([]ModelSnapshot, 0),
+		maxHistorySize:  DefaultMaxHistory,
+		help:            helpModel,
+		keyMap:          DefaultKeyMap(),
+		showFullHelp:    false,
+		smartNewTask:    cfg.SmartNewTask,
+		compactJSON:     cfg.CompactJSON,
+		sinkDoneTasks:   cfg.SinkDoneTasks,
+		autoAdvance:     cfg.AutoAdvanceOnDone,
+		autoRollup:      cfg.AutoCompleteParents,
+		confirmRedoLoss: cfg.ConfirmRedoDiscard,
+		confirmDelete:   true,
+		bulletSymbols:   withBulletOverrides(BulletSymbols, bulletOverridesFor(cfg)),
+		bulletOverrides: bulletOverridesFor(cfg),
+	}
+}
+
+// OverrideKeys rebinds the named keybindings, as loaded from keys.json
+func (m *Model) OverrideKeys(overrides map[string][]string) error {
+	return m.keyMap.ApplyOverrides(overrides)
+}
+
+// SetConfirmDelete sets whether deleting a task with subtasks asks for confirmation first
+func (m *Model) SetConfirmDelete(confirm bool) {
+	m.confirmDelete = confirm
+}
+
+// SetReadOnly opens the task list for browsing only: autosave is off and keys
+// that would change the list or its file show a message instead
+func (m *Model) SetReadOnly(readOnly bool) {
+	m.readOnly = readOnly
+	if readOnly {
+		m.autoSave = false
+	}
+}
+
+// readOnlyBlocked returns the keys that do nothing in read-only mode: every
+// change to the task list, the undo history that would bring changes back, and saving
+func (m Model) readOnlyBlocked() []key.Binding {
+	return append(m.keyMap.ChangeBindings(),
+		m.keyMap.Undo, m.keyMap.Redo, m.keyMap.UndoHistory, m.keyMap.Trash, m.keyMap.SaveAs, m.keyMap.ForceSave)
+}
+
+// SetLockedOut turns autosave off because another dotdot has the task file
+// open, so the two don't silently overwrite each other's changes. Changes are
+// kept in memory until saved explicitly.
+func (m *Model) SetLockedOut(reason error) {
+	m.autoSave = false
+	m.setError(fmt.Sprintf("%v; autosave is off. Press %s to save anyway, or reopen with --force if that dotdot is gone",
+		reason, m.keyMap.ForceSave.Help().Key))
+}
+
+// RestoreCursor puts the cursor back on the task it was on when the list was
+// last closed. Tasks that have since been deleted, folded away or hidden are
+// skipped, leaving the cursor on the first task.
+func (m *Model) RestoreCursor(taskID string) {
+	if slices.Contains(m.getAllTaskIDs(), taskID) {
+		m.cursorID = taskID
+	}
+}
+
+// CursorID returns the ID of the task under the cursor, or "" for an empty list
+func (m Model) CursorID() string {
+	return m.cursorID
+}
+
+// DefaultMaxHistory is the number of undo steps kept unless SetMaxHistory changes it
+const DefaultMaxHistory = 50
+
+// SetMaxHistory sets how many undo and redo steps are kept; n <= 0 keeps them all
+func (m *Model) SetMaxHistory(n int) {
+	m.maxHistorySize = n
+}
+
+// maxInlineHeight caps the view height when running without the alt-screen
+const maxInlineHeight = 20
+
+// SetInline marks the model as running inline rather than in the alt-screen
+func (m *Model) SetInline(inline bool) {
+	m.inline = inline
+}
+
+// SetASCII switches the bullets, indentation connector, cursor and fold marker to
+// plain ASCII, for terminals or fonts that can't show the Unicode symbols
+func (m *Model) SetASCII(ascii bool) {
+	m.ascii = ascii
+	base := BulletSymbols
+	if ascii {
+		base = ASCIIBulletSymbols
+	}
+	m.bulletSymbols = withBulletOverrides(base, m.bulletOverrides)
+}
+
+// bulletWidth returns the width of the bullet column
+func (m Model) bulletWidth() int {
+	if m.ascii {
+		return ASCIIBulletWidth
+	}
+	return BulletWidth
+}
+
+// foldSymbol returns the indicator shown for parents with hidden or shown subtasks
+func (m Model) foldSymbol(collapsed bool) string {
+	switch {
+	case m.ascii && collapsed:
+		return ASCIIFoldCollapsedSymbol
+	case m.ascii:
+		return ASCIIFoldExpandedSymbol
+	case collapsed:
+		return FoldCollapsedSymbol
+	}
+	return FoldExpandedSymbol
+}
+
+// viewHeight returns the number of terminal lines the view may use
+func (m Model) viewHeight() int {
+	if m.inline && m.height > maxInlineHeight {
+		return maxInlineHeight
+	}
+	return m.height
+}
+
+func (m Model) Init() tea.Cmd { return m.watchFile() }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	updated, cmd := m.update(msg)
+
+	// Remember where the view scrolled to, so the next move scrolls relative to
+	// it. Only keys, resizes and reloads can move the cursor or change the layout.
+	next := updated.(Model)
+	switch msg.(type) {
+	case tea.KeyMsg, tea.WindowSizeMsg, fileCheckMsg:
+		next.trackScroll()
+	}
+	return next, cmd
+}
+
+// update handles a message without tracking the scroll position
+func (m Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
+		m.resizeNotesEditor()
+	case fileCheckMsg:
+		m.handleFileCheck(msg)
+		return m, m.watchFile()
 	case tea.KeyMsg:
-		if m.editing {
+		switch {
+		case m.confirm != nil:
+			return m.handleConfirmMode(msg)
+		case m.prompt != nil:
+			return m.handlePromptMode(msg)
+		case m.finder != nil:
+			return m.handleFinderMode(msg)
+		case m.history != nil:
+			return m.handleHistoryMode(msg)
+		case m.trashView != nil:
+			return m.handleTrashMode(msg)
+		case m.listPicker != nil:
+			return m.handleListPickerMode(msg)
+		case m.notesEditor != nil:
+			return m.handleNotesMode(msg)
+		case m.pinnedCursor > 0:
+			return m.handlePinnedMode(msg)
+		case m.editing:
 			return m.handleEditingMode(msg)
-		} else {
+		default:
 			return m.handleNormalMode(msg)
 		}
 	}
 
 	var cmd tea.Cmd
 	m.textInput, cmd = m.textInput.Update(msg)
+	if m.prompt != nil {
+		var promptCmd tea.Cmd
+		m.prompt.input, promptCmd = m.prompt.input.Update(msg)
+		cmd = tea.Batch(cmd, promptCmd)
+	}
+	if m.finder != nil {
+		var finderCmd tea.Cmd
+		m.finder.input, finderCmd = m.finder.input.Update(msg)
+		cmd = tea.Batch(cmd, finderCmd)
+	}
+	if m.notesEditor != nil {
+		var notesCmd tea.Cmd
+		m.notesEditor.area, notesCmd = m.notesEditor.area.Update(msg)
+		cmd = tea.Batch(cmd, notesCmd)
+	}
 
 	return m, cmd
 }
@@ -190,7 +514,7 @@ func (m Model) handleEditingMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		// Enter key: save current edit, then create new task below and enter edit mode
 		// Special case: if current task is empty, delete it and enter normal mode
 		if m.textInput.Value() == "" {
-			m.deleteCurrentTask()
+			m.discardEmptyTask()
 			m.editing = false
 			m.textInput.Blur()
 			return m, cmd
@@ -226,49 +550,149 @@ func (m Model) handleEditingMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.textInput.Focus()
 		}
 		return m, cmd
+	case key.Matches(msg, m.keyMap.ConfirmEdit):
+		// Alt+Enter: save current edit and return to normal mode without creating a task
+		// Special case: if current task is empty, delete it as Enter does
+		if m.textInput.Value() == "" {
+			m.discardEmptyTask()
+		} else {
+			m.editTaskTitle(m.cursorID, m.textInput.Value())
+		}
+		m.editing = false
+		m.textInput.Blur()
+		return m, cmd
 	case key.Matches(msg, m.keyMap.Cancel):
 		// ESC: If the task title is empty, delete the task
 		currentTask := m.getCurrentTask()
 		if currentTask != nil && currentTask.title == "" {
-			m.deleteCurrentTask()
+			m.discardEmptyTask()
 		}
 		m.editing = false
 		m.textInput.Blur()
 		return m, cmd
 	}
 
-	m.statusMessage = msg.String() // No blinking messages?
 	m.textInput, cmd = m.textInput.Update(msg)
 	return m, cmd
 }
 
 func (m Model) handleNormalMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// A status message answers the previous key, so it goes once the next one is pressed
+	m.clearStatus()
+
+	if m.readOnly && key.Matches(msg, m.readOnlyBlocked()...) {
+		m.setStatus("Read-only: the task list can't be changed")
+		return m, nil
+	}
+
+	if m.unindentPending {
+		// The key after the unindent-to-depth key picks the depth
+		m.unindentPending = false
+		if s := msg.String(); len(s) == 1 && s[0] >= '0' && s[0] <= '9' {
+			m.unindentToDepth(int(s[0] - '0'))
+		} else {
+			m.setStatus("Cancelled")
+		}
+		return m, nil
+	}
+
+	if m.confirmRedoLoss && len(m.redoStack) > 0 && key.Matches(msg, m.keyMap.ChangeBindings()...) {
+		m.askConfirm(fmt.Sprintf("This discards %d redo step(s). Continue?", len(m.redoStack)), func(m *Model) {
+			m.redoStack = m.redoStack[:0]
+			updated, _ := m.handleNormalMode(msg)
+			*m = updated.(Model)
+		})
+		return m, nil
+	}
+
 	switch {
 	case key.Matches(msg, m.keyMap.Quit):
 		return m, tea.Quit
 	case key.Matches(msg, m.keyMap.Cancel):
-		// Clear error messages on ESC
+		// Clear error messages on ESC, then any active filter
 		if m.showError {
 			m.clearError()
 			return m, nil
 		}
-		// If no error to clear, do nothing
+		if m.hasActiveFilter() {
+			m.clearFilters()
+			return m, nil
+		}
 	case key.Matches(msg, m.keyMap.Up):
+		if m.enterPinnedSection() {
+			return m, nil
+		}
 		m.cursorID = m.getPreviousTaskID()
 	case key.Matches(msg, m.keyMap.Down):
 		m.cursorID = m.getNextTaskID()
+	case key.Matches(msg, m.keyMap.GoToTop):
+		m.jumpToTop()
+	case key.Matches(msg, m.keyMap.GoToBottom):
+		m.jumpToBottom()
+	case key.Matches(msg, m.keyMap.HalfPageUp):
+		m.moveCursorBy(-max(m.viewport.Height()/2, 1))
+	case key.Matches(msg, m.keyMap.HalfPageDown):
+		m.moveCursorBy(max(m.viewport.Height()/2, 1))
+	case key.Matches(msg, m.keyMap.PageUp):
+		m.moveCursorBy(-max(m.viewport.Height(), 1))
+	case key.Matches(msg, m.keyMap.PageDown):
+		m.moveCursorBy(max(m.viewport.Height(), 1))
+	case key.Matches(msg, m.keyMap.GoToParent):
+		m.goToParent()
+	case key.Matches(msg, m.keyMap.NextSibling):
+		m.goToSibling(1)
+	case key.Matches(msg, m.keyMap.PrevSibling):
+		m.goToSibling(-1)
+	case key.Matches(msg, m.keyMap.JumpBack):
+		m.jumpBack()
+	case key.Matches(msg, m.keyMap.FindTask):
+		m.openFinder()
+	case key.Matches(msg, m.keyMap.EditNotes):
+		return m, m.openNotesEditor()
 	case key.Matches(msg, m.keyMap.Left):
 		m.changeTaskStatusBackward()
 	case key.Matches(msg, m.keyMap.Right):
 		m.changeTaskStatusForward()
+	case key.Matches(msg, m.keyMap.SetStatus):
+		// The binding's keys pick statuses in order, 1-3 by default
+		if n := slices.Index(m.keyMap.SetStatus.Keys(), msg.String()); n >= 0 && n <= int(Done) {
+			m.setStatusDirect(TaskStatus(n))
+		}
+	case key.Matches(msg, m.keyMap.ToggleDone):
+		m.toggleDone()
+	case key.Matches(msg, m.keyMap.AdvanceChildren):
+		m.advanceChildrenStatus()
 	case key.Matches(msg, m.keyMap.MoveUp):
 		m.moveTaskUp()
 	case key.Matches(msg, m.keyMap.MoveDown):
 		m.moveTaskDown()
+	case key.Matches(msg, m.keyMap.MoveToTop):
+		m.moveTaskToEdge(true)
+	case key.Matches(msg, m.keyMap.MoveToBottom):
+		m.moveTaskToEdge(false)
 	case key.Matches(msg, m.keyMap.UnindentTask):
 		m.unindentTask()
 	case key.Matches(msg, m.keyMap.IndentTask):
 		m.indentTask()
+	case key.Matches(msg, m.keyMap.PromoteTask):
+		m.promoteTaskToTopLevel()
+	case key.Matches(msg, m.keyMap.UnindentToDepth):
+		m.startUnindentToDepth()
+	case key.Matches(msg, m.keyMap.ReverseGroup):
+		m.reverseSiblings()
+	case key.Matches(msg, m.keyMap.Normalize):
+		m.normalizeTasks()
+	case key.Matches(msg, m.keyMap.ClearDone):
+		m.clearDoneTasks()
+	case key.Matches(msg, m.keyMap.DuplicateTaskOnly):
+		m.duplicateTaskShallow()
+	case key.Matches(msg, m.keyMap.WrapInParent):
+		if m.wrapInNewParent() {
+			m.editing = true
+			m.textInput.SetValue("")
+			m.textInput.Focus()
+		}
+		return m, nil
 	case key.Matches(msg, m.keyMap.NewTaskBelow):
 		m.previousID = m.cursorID
 		newTaskID := m.createNewTaskBelow()
@@ -279,6 +703,16 @@ func (m Model) handleNormalMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.textInput.Focus()
 		}
 		return m, nil
+	case key.Matches(msg, m.keyMap.NewTaskBelowSameStatus):
+		m.previousID = m.cursorID
+		newTaskID := m.createNewTaskBelowWithSameStatus()
+		if newTaskID != "" {
+			m.cursorID = newTaskID
+			m.editing = true
+			m.textInput.SetValue("")
+			m.textInput.Focus()
+		}
+		return m, nil
 	case key.Matches(msg, m.keyMap.NewSubtask):
 		m.previousID = m.cursorID
 		newTaskID := m.createNewSubtask()
@@ -305,18 +739,147 @@ func (m Model) handleNormalMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case key.Matches(msg, m.keyMap.Redo):
 		m.redo()
 		return m, nil
+	case key.Matches(msg, m.keyMap.UndoHistory):
+		m.openHistory()
+		return m, nil
+	case key.Matches(msg, m.keyMap.Trash):
+		m.openTrash()
+		return m, nil
 	case key.Matches(msg, m.keyMap.Copy):
 		m.copyCurrentTaskToClipboard()
 		return m, nil
+	case key.Matches(msg, m.keyMap.Cut):
+		m.cutCurrentTask()
+		return m, nil
+	case key.Matches(msg, m.keyMap.CopyMarkdown):
+		m.copyListAsMarkdown()
+		return m, nil
+	case key.Matches(msg, m.keyMap.CopySubtree):
+		m.copySubtreeAsMarkdown()
+		return m, nil
 	case key.Matches(msg, m.keyMap.Paste):
 		m.pasteTaskFromClipboard()
 		return m, nil
 	case key.Matches(msg, m.keyMap.PasteAsSubtask):
 		m.pasteTaskAsSubtask()
 		return m, nil
+	case key.Matches(msg, m.keyMap.ToggleSmartNewTask):
+		m.smartNewTask = !m.smartNewTask
+		if m.smartNewTask {
+			m.setStatus("Smart new task on: tasks below Active parents become subtasks")
+		} else {
+			m.setStatus("Smart new task off")
+		}
+		return m, nil
+	case key.Matches(msg, m.keyMap.ToggleAutoRollup):
+		m.autoRollup = !m.autoRollup
+		if m.autoRollup {
+			m.setStatus("Auto-complete parents on: parents follow their subtasks to Done and back")
+		} else {
+			m.setStatus("Auto-complete parents off")
+		}
+		return m, nil
+	case key.Matches(msg, m.keyMap.ToggleAutoAdvance):
+		m.autoAdvance = !m.autoAdvance
+		if m.autoAdvance {
+			m.setStatus("Auto-advance on: marking a task Done moves to the next incomplete task")
+		} else {
+			m.setStatus("Auto-advance off")
+		}
+		return m, nil
+	case key.Matches(msg, m.keyMap.ToggleSinkDone):
+		m.sinkDoneTasks = !m.sinkDoneTasks
+		if m.sinkDoneTasks {
+			m.setStatus("Sinking Done tasks: marking a task Done moves it to the bottom of its group")
+		} else {
+			m.setStatus("Done tasks stay in place")
+		}
+		return m, nil
+	case key.Matches(msg, m.keyMap.SetEstimate):
+		m.promptEstimate()
+		return m, nil
+	case key.Matches(msg, m.keyMap.SetDueDate):
+		m.promptDueDate()
+		return m, nil
+	case key.Matches(msg, m.keyMap.RaisePriority):
+		m.changePriority(1)
+		return m, nil
+	case key.Matches(msg, m.keyMap.LowerPriority):
+		m.changePriority(-1)
+		return m, nil
+	case key.Matches(msg, m.keyMap.SortByPriority):
+		m.sortByPriority()
+		return m, nil
+	case key.Matches(msg, m.keyMap.FilterStatus):
+		m.cycleStatusFilter()
+		return m, nil
+	case key.Matches(msg, m.keyMap.FilterTag):
+		m.promptTagFilter()
+		return m, nil
+	case key.Matches(msg, m.keyMap.ToggleFold):
+		m.toggleFold()
+		return m, nil
+	case key.Matches(msg, m.keyMap.ToggleDeferred):
+		m.toggleDeferred()
+		return m, nil
+	case key.Matches(msg, m.keyMap.ShowDeferred):
+		m.toggleShowDeferred()
+		return m, nil
+	case key.Matches(msg, m.keyMap.TogglePin):
+		m.togglePin()
+		return m, nil
+	case key.Matches(msg, m.keyMap.ShowPinned):
+		m.toggleShowPinned()
+		return m, nil
+	case key.Matches(msg, m.keyMap.FocusGroup):
+		m.toggleFocusGroup()
+		return m, nil
+	case key.Matches(msg, m.keyMap.DoneLast):
+		m.toggleDoneLast()
+		return m, nil
+	case key.Matches(msg, m.keyMap.ToggleEmptyParents):
+		m.toggleEmptyParents()
+		return m, nil
+	case key.Matches(msg, m.keyMap.ToggleTruncate):
+		m.truncateTitles = !m.truncateTitles
+		return m, nil
+	case key.Matches(msg, m.keyMap.ToggleCenterCursor):
+		m.centerCursor = !m.centerCursor
+		if m.centerCursor {
+			m.setStatus("Centered scrolling on")
+		} else {
+			m.setStatus("Centered scrolling off")
+		}
+		return m, nil
+	case key.Matches(msg, m.keyMap.SaveAs):
+		m.promptSaveAs()
+	case key.Matches(msg, m.keyMap.ForceSave):
+		m.forceSave()
+		return m, nil
+	case key.Matches(msg, m.keyMap.ExportSubtree):
+		m.promptExportSubtree(false)
+		return m, nil
+	case key.Matches(msg, m.keyMap.MoveSubtree):
+		m.promptExportSubtree(true)
+		return m, nil
+	case key.Matches(msg, m.keyMap.MoveToList):
+		m.openListPicker()
+		return m, nil
+	case key.Matches(msg, m.keyMap.OpenDirectory):
+		m.openContainingDirectory()
+		return m, nil
 	case key.Matches(msg, m.keyMap.Help):
+		if m.hideHelp {
+			// Bring the footer back first rather than toggling an invisible view
+			m.hideHelp = false
+			return m, nil
+		}
 		m.showFullHelp = !m.showFullHelp
 		return m, nil
+	case key.Matches(msg, m.keyMap.HideHelp):
+		// No status message here, it would take back the line being freed
+		m.hideHelp = !m.hideHelp
+		return m, nil
 	case key.Matches(msg, m.keyMap.EditTask):
 		m.editing = true
 		task := m.getCurrentTask()
@@ -325,104 +888,131 @@ func (m Model) handleNormalMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		m.textInput.Focus()
 		return m, nil
+	case key.Matches(msg, m.keyMap.AppendToTask):
+		m.editCurrentTaskAt(false)
+		return m, nil
+	case key.Matches(msg, m.keyMap.PrependToTask):
+		m.editCurrentTaskAt(true)
+		return m, nil
 	case key.Matches(msg, m.keyMap.DeleteTask):
-		m.deleteCurrentTask()
+		m.deleteCurrentTaskConfirmed()
 		return m, nil
 	}
 	return m, nil
 }
 
 func (m Model) View() string {
-	// Calculate inner width for content
-	innerWidth := m.width - TotalPadding
-	if innerWidth < 0 {
-		innerWidth = 0
-	}
+	view, _ := m.render()
+	return view
+}
 
+// chrome builds the header and footer around the task list and returns them
+// with the number of lines left for the viewport between them
+func (m Model) chrome(innerWidth int) (header, footer string, viewportHeight int) {
 	// Build header (title)
 	titleText := "Task Manager"
 	if m.filePath != "" {
 		titleText = m.getTaskListDisplayName()
 	}
-	header := lipgloss.NewStyle().
+	if filter := m.filterDescription(); filter != "" {
+		titleText += " " + FilterIndicatorStyle.Render("["+filter+"]")
+	}
+	header = lipgloss.NewStyle().
 		Width(innerWidth).
 		Render(titleText)
+	if pinned := m.renderPinnedSection(innerWidth); pinned != "" {
+		header = lipgloss.JoinVertical(lipgloss.Left, header, pinned)
+	}
 
 	// Update help model width and build footer (error messages, status, and help)
 	m.help.Width = innerWidth
-	footerParts := m.buildFooterParts(innerWidth)
-
-	var footer string
-	if len(footerParts) > 0 {
+	if footerParts := m.buildFooterParts(innerWidth); len(footerParts) > 0 {
 		footer = lipgloss.NewStyle().
 			Width(innerWidth).
 			Render(lipgloss.JoinVertical(lipgloss.Left, footerParts...))
 	}
 
-	// Calculate viewport dimensions based on actual header and footer
-	headerHeight := lipgloss.Height(header)
+	// Calculate viewport height based on actual header and footer
 	footerHeight := 0
 	if footer != "" {
 		footerHeight = lipgloss.Height(footer)
 	}
+	viewportHeight = max(m.viewHeight()-lipgloss.Height(header)-footerHeight-2, 0) // -2 for padding
+	return header, footer, viewportHeight
+}
 
-	viewportWidth := innerWidth
-	viewportHeight := m.height - headerHeight - footerHeight - 2 // -2 for padding
-	if viewportWidth < 0 {
-		viewportWidth = 0
-	}
-	if viewportHeight < 0 {
-		viewportHeight = 0
+// visibleLayout lists every visible task and subtask in display order
+func (m Model) visibleLayout() []rowLayout {
+	var layout []rowLayout
+	m.walkVisibleTasks(func(task *Task, indentLevel int) {
+		layout = append(layout, rowLayout{task: task, depth: indentLevel})
+	})
+	return layout
+}
+
+// trackScroll records where the view scrolls to after the cursor moves or the
+// window resizes, so the next frame scrolls relative to it. The task list's
+// rows are measured but not rendered; overlays are short, so they are rendered.
+func (m *Model) trackScroll() {
+	if m.history != nil || m.trashView != nil || m.listPicker != nil || m.notesEditor != nil {
+		_, m.yOffset = m.render()
+		return
 	}
 
-	// Update viewport dimensions
-	m.viewport.SetWidth(viewportWidth)
+	layout := m.visibleLayout()
+	if len(layout) == 0 {
+		m.yOffset = 0 // Just the empty list's help text, which never scrolls
+		return
+	}
+	innerWidth := max(m.width-TotalPadding, 0)
+	_, _, viewportHeight := m.chrome(innerWidth)
 	m.viewport.SetHeight(viewportHeight)
+	contentHeight, cursorTop, cursorBottom := m.measureRows(layout, innerWidth)
+	m.yOffset = m.scrollOffset(cursorTop, cursorBottom, contentHeight)
+}
 
-	// Build scrollable content (tasks)
-	var rows []string
-	cursorTaskPosition := 0
-	cursorTaskFound := false
+// render builds the view and returns it with the viewport's scroll offset
+func (m Model) render() (string, int) {
+	// Calculate inner width for content
+	innerWidth := m.width - TotalPadding
+	if innerWidth < 0 {
+		innerWidth = 0
+	}
 
-	// Get parent chain for underlining parent tasks
-	parentChainIDs := m.getParentChainIDs(m.cursorID)
+	header, footer, viewportHeight := m.chrome(innerWidth)
 
-	// Helper function to recursively render tasks and subtasks
-	var renderTasks func(tasks []Task, indentLevel int)
-	renderTasks = func(tasks []Task, indentLevel int) {
-		for _, task := range tasks {
-			isSelected := task.id == m.cursorID
-			row := m.renderRow(task, innerWidth, indentLevel, isSelected, m.editing, parentChainIDs)
-			if !cursorTaskFound {
-				cursorTaskPosition += lipgloss.Height(row)
-				if isSelected {
-					cursorTaskFound = true
-				}
-			}
-			rows = append(rows, row)
-			if len(task.subtasks) > 0 {
-				renderTasks(task.subtasks, indentLevel+1)
-			}
-		}
-	}
+	// Update viewport dimensions
+	m.viewport.SetWidth(innerWidth)
+	m.viewport.SetHeight(viewportHeight)
 
-	renderTasks(m.tasks, 0)
+	// Build scrollable content (tasks)
+	windowTop := 0 // Line of the full content at which the viewport's content starts
+	layout := m.visibleLayout()
 
-	// Add helpful message if no tasks exist
-	if len(m.tasks) == 0 {
+	switch {
+	case m.history != nil:
+		// The undo history overlay takes the place of the task list while open
+		rows, top, bottom := m.renderHistory(innerWidth)
+		m.setViewportRows(rows, top, bottom)
+	case m.trashView != nil:
+		rows, top, bottom := m.renderTrash(innerWidth)
+		m.setViewportRows(rows, top, bottom)
+	case m.listPicker != nil:
+		rows, top, bottom := m.renderListPicker(innerWidth)
+		m.setViewportRows(rows, top, bottom)
+	case m.notesEditor != nil:
+		rows, top, bottom := m.renderNotesEditor()
+		m.setViewportRows(rows, top, bottom)
+	case len(m.tasks) == 0:
+		// Add helpful message if no tasks exist
 		helpText := HelpStyle.Render("No tasks yet. Press 'n' to create your first task, or 'q' to quit.")
-		rows = append(rows, "", helpText) // Empty line for spacing
-	}
-
-	// Set viewport content
-	content := lipgloss.JoinVertical(lipgloss.Left, rows...)
-	m.viewport.SetContent(content)
-	viewportOffset := 0
-	if cursorTaskPosition > m.viewport.Height()-2 {
-		viewportOffset = cursorTaskPosition - (m.viewport.Height() - 2)
+		m.setViewportRows([]string{"", helpText}, 0, 0) // Empty line for spacing
+	case len(layout) == 0:
+		helpText := HelpStyle.Render("No tasks match the current filter. Press ESC to clear it.")
+		m.setViewportRows([]string{"", helpText}, 0, 0) // Empty line for spacing
+	default:
+		windowTop = m.setViewportTasks(layout, innerWidth)
 	}
-	m.viewport.SetYOffset(viewportOffset)
-
 	// Combine header, viewport, and footer
 	var viewParts []string
 	viewParts = append(viewParts, header)
@@ -440,17 +1030,200 @@ func (m Model) View() string {
 		MaxWidth(m.width).
 		Render(view)
 
-	return container
+	return container, windowTop + m.viewport.YOffset()
+}
+
+// rowLayout is a visible task's place in the scrollable content
+type rowLayout struct {
+	task   *Task
+	depth  int
+	top    int // First line of the row
+	height int // Lines the row takes
+}
+
+// setViewportRows shows rows in the viewport, scrolled to keep the lines from
+// cursorTop to cursorBottom in view
+func (m *Model) setViewportRows(rows []string, cursorTop, cursorBottom int) {
+	content := lipgloss.JoinVertical(lipgloss.Left, rows...)
+	m.viewport.SetContent(content)
+	m.viewport.SetYOffset(m.scrollOffset(cursorTop, cursorBottom, lipgloss.Height(content)))
+}
+
+// setViewportTasks shows the task rows in the viewport, scrolled to keep the
+// cursor in view. Every row is measured to place the cursor, but only the rows
+// in view are rendered, so long lists don't pay for styling thousands of rows
+// each frame. It returns the line of the full list the viewport content starts at.
+func (m *Model) setViewportTasks(layout []rowLayout, width int) int {
+	contentHeight, cursorTop, cursorBottom := m.measureRows(layout, width)
+	offset := m.scrollOffset(cursorTop, cursorBottom, contentHeight)
+
+	// Get parent chain for underlining parent tasks
+	parentChainIDs := m.getParentChainIDs(m.cursorID)
+
+	var rows []string
+	windowTop := offset
+	for _, row := range layout {
+		if row.top+row.height <= offset || row.top >= offset+m.viewport.Height() {
+			continue
+		}
+		if len(rows) == 0 {
+			windowTop = row.top
+		}
+		isSelected := row.task.id == m.cursorID
+		rows = append(rows, m.renderRow(*row.task, width, row.depth, isSelected, m.editing, parentChainIDs))
+	}
+
+	m.viewport.SetContent(lipgloss.JoinVertical(lipgloss.Left, rows...))
+	m.viewport.SetYOffset(offset - windowTop)
+	return windowTop
+}
+
+// measureRows fills in where each row starts and how many lines it takes, and
+// returns the height of the whole list and the lines the cursor's row spans
+func (m Model) measureRows(layout []rowLayout, width int) (contentHeight, cursorTop, cursorBottom int) {
+	for i := range layout {
+		row := &layout[i]
+		row.top = contentHeight
+		row.height = m.rowHeight(*row.task, width, row.depth, row.task.id == m.cursorID)
+		contentHeight += row.height
+		if row.task.id == m.cursorID {
+			cursorTop, cursorBottom = row.top, contentHeight
+		}
+	}
+	if cursorBottom == 0 {
+		// The cursor isn't shown, so scroll as if it were on the last row
+		cursorTop, cursorBottom = layout[len(layout)-1].top, contentHeight
+	}
+	return contentHeight, cursorTop, cursorBottom
+}
+
+// scrollOffset returns the scroll position for content of the given height that
+// keeps the lines from cursorTop to cursorBottom in view
+func (m Model) scrollOffset(cursorTop, cursorBottom, contentHeight int) int {
+	height := m.viewport.Height()
+	if m.centerCursor {
+		return centeredOffset(cursorBottom, height, contentHeight)
+	}
+	offset := followOffset(m.yOffset, cursorTop, cursorBottom, height)
+	if maxOffset := contentHeight - height; offset > maxOffset {
+		offset = maxOffset
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return offset
+}
+
+// rowHeight returns how many lines a task's row takes, measuring the title
+// without rendering the row. Titles that fit on one line skip wrapping entirely.
+func (m Model) rowHeight(task Task, width, indentLevel int, isSelected bool) int {
+	text := task.title
+	if m.editing && isSelected {
+		text = m.textInput.View()
+	} else if m.truncateTitles {
+		return 1
+	}
+
+	textColWidth := m.titleWidth(task, width, indentLevel)
+	if ansi.StringWidth(text) <= textColWidth {
+		return 1
+	}
+	return lipgloss.Height(lipgloss.NewStyle().Width(textColWidth).Render(text))
+}
+
+// followOffset returns the viewport offset that keeps the cursor row, spanning
+// lines cursorTop to cursorBottom, in view while moving as little as possible
+// from the previous offset: down once the row comes within two lines of the
+// bottom, up once the row's first line goes above the top
+func followOffset(previous, cursorTop, cursorBottom, height int) int {
+	offset := previous
+	if cursorBottom > offset+height-2 {
+		offset = cursorBottom - (height - 2)
+	}
+	if cursorTop < offset {
+		offset = cursorTop
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return offset
+}
+
+// centeredOffset returns the viewport offset that keeps the cursor row, ending at
+// line cursorBottom, in the middle of the viewport, without scrolling past either
+// end of the content
+func centeredOffset(cursorBottom, height, contentHeight int) int {
+	offset := cursorBottom - (height+1)/2
+	if maxOffset := contentHeight - height; offset > maxOffset {
+		offset = maxOffset
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return offset
 }
 
+// renderRow renders a task as cursor, indentation, bullet, priority and text columns.
+// The columns are joined as blocks, so wrapped title lines hang-indent under
+// the first line's text instead of returning to the bullet column.
 func (m Model) renderRow(task Task, width int, indentLevel int, isSelected bool, isEditing bool, parentChainIDs []string) string {
 	indent := m.renderIndentation(indentLevel)
 	bulletRendered := m.renderBullet(task.status, isEditing, isSelected)
 	cursorRendered := m.renderCursor(isSelected, isEditing)
-	textColWidth := m.calculateTextWidth(width, indentLevel)
+	priorityRendered := renderPriority(task)
+	metaRendered := m.renderMeta(task)
+	textColWidth := m.titleWidth(task, width, indentLevel)
 	textRendered := m.renderText(task, textColWidth, isSelected, isEditing, parentChainIDs)
 
-	return lipgloss.JoinHorizontal(lipgloss.Top, cursorRendered, lipgloss.NewStyle().Render(indent), bulletRendered, textRendered)
+	return lipgloss.JoinHorizontal(lipgloss.Top, cursorRendered, lipgloss.NewStyle().Render(indent), bulletRendered, priorityRendered, textRendered, metaRendered)
+}
+
+// renderMeta renders the right-hand column of task metadata (fold marker, progress, estimates, due date)
+func (m Model) renderMeta(task Task) string {
+	var parts []string
+
+	if task.pinned {
+		parts = append(parts, PinnedStyle.Render(m.pinnedSymbol()))
+	}
+	if task.notes != "" {
+		parts = append(parts, m.notesSymbol())
+	}
+
+	// Collapsed parents show how many subtasks are hidden under the fold
+	if task.collapsed && len(task.subtasks) > 0 {
+		parts = append(parts, FoldMarkerStyle.Render(fmt.Sprintf("%s (%d)", m.foldSymbol(true), len(task.subtasks))))
+	} else if m.childrenAllHidden(&task) {
+		parts = append(parts, "(children hidden)")
+	} else if len(task.subtasks) > 0 {
+		parts = append(parts, FoldMarkerStyle.Render(m.foldSymbol(false)))
+	}
+
+	// Parents show how many of their leaf tasks are Done, folded or not
+	if done, total := taskProgress(task); total > 0 {
+		parts = append(parts, fmt.Sprintf("%d/%d", done, total))
+	}
+
+	if len(task.subtasks) > 0 {
+		if total := totalEstimate(task); total > 0 {
+			parts = append(parts, "Σ"+storage.FormatEstimate(total))
+		}
+	} else if task.estimate > 0 {
+		parts = append(parts, storage.FormatEstimate(task.estimate))
+	}
+
+	if task.due != nil {
+		parts = append(parts, "due "+storage.FormatDueDate(task.due))
+	}
+
+	if len(parts) == 0 {
+		return ""
+	}
+	return MetaStyle.Render(" " + strings.Join(parts, " "))
+}
+
+// metaWidth returns the display width taken by a rendered meta column
+func metaWidth(meta string) int {
+	return lipgloss.Width(meta)
 }
 
 func (m Model) renderIndentation(indentLevel int) string {
@@ -458,7 +1231,9 @@ func (m Model) renderIndentation(indentLevel int) string {
 	for i := 0; i < indentLevel-1; i++ {
 		indent += strings.Repeat(" ", IndentWidth)
 	}
-	if indentLevel > 0 {
+	if indentLevel > 0 && m.ascii {
+		indent += "`-"
+	} else if indentLevel > 0 {
 		indent += "╰ "
 	}
 	return indent
@@ -469,7 +1244,7 @@ func (m Model) renderBullet(status TaskStatus, isEditing bool, isSelected bool)
 	if isEditing && !isSelected {
 		style = BulletDimmedStyle
 	}
-	return style.Render(BulletSymbols[status] + " ")
+	return style.Width(m.bulletWidth()).Render(m.bulletSymbols[status] + " ")
 }
 
 func (m Model) renderCursor(isSelected bool, isEditing bool) string {
@@ -478,6 +1253,9 @@ func (m Model) renderCursor(isSelected bool, isEditing bool) string {
 
 	if isSelected {
 		cursorSymbol = "▐"
+		if m.ascii {
+			cursorSymbol = ">"
+		}
 		style = CursorSelectedStyle
 	} else if isEditing && !isSelected {
 		style = CursorDimmedStyle
@@ -486,8 +1264,33 @@ func (m Model) renderCursor(isSelected bool, isEditing bool) string {
 	return style.Render(cursorSymbol + " ")
 }
 
+// titleWidth returns the width left for a task's title in its row, between the
+// priority marker and the metadata column
+func (m Model) titleWidth(task Task, width int, indentLevel int) int {
+	textColWidth := m.calculateTextWidth(width, indentLevel) - lipgloss.Width(renderPriority(task)) - metaWidth(m.renderMeta(task))
+	if textColWidth < 0 {
+		textColWidth = 0
+	}
+	return textColWidth
+}
+
+// renderPriority renders the marker shown before a task's title for its priority, or ""
+func renderPriority(task Task) string {
+	if task.priority <= NoPriority || task.priority >= len(PrioritySymbols) {
+		return ""
+	}
+	style := PriorityLowStyle
+	switch task.priority {
+	case HighPriority:
+		style = PriorityHighStyle
+	case MediumPriority:
+		style = PriorityMediumStyle
+	}
+	return style.Render(PrioritySymbols[task.priority] + " ")
+}
+
 func (m Model) calculateTextWidth(width int, indentLevel int) int {
-	textColWidth := width - CursorWidth - BulletWidth - (indentLevel * IndentWidth)
+	textColWidth := width - CursorWidth - m.bulletWidth() - (indentLevel * IndentWidth)
 	if textColWidth < 0 {
 		textColWidth = 0
 	}
@@ -510,47 +1313,293 @@ func (m Model) renderText(task Task, width int, isSelected bool, isEditing bool,
 
 	style := GetTaskStyle(task.status)
 	if isEditing && !isSelected {
-		style = lipgloss.NewStyle().Foreground(lipgloss.Color(DimmedColor))
+		style = DimmedStyle
 	} else if (isSelected || isParentOfSelected) && !isEditing {
 		style = style.Underline(true)
 	}
+	if task.deferred && !isEditing {
+		style = style.Foreground(DimmedStyle.GetForeground()).Italic(true)
+	}
+	if !isEditing {
+		switch taskDueState(task, time.Now()) {
+		case overdue:
+			style = style.Foreground(OverdueStyle.GetForeground())
+		case dueToday:
+			style = style.Foreground(DueTodayStyle.GetForeground())
+		}
+	}
+
+	title := task.title
+	if m.truncateTitles {
+		title = truncateTitle(title, width)
+	}
+
+	// Tags stand out unless the whole title is dimmed or Done
+	if task.status != Done && !isEditing && tagPattern.MatchString(title) {
+		return renderTagged(title, width, style)
+	}
 
 	// Apply width constraints and styling in one operation to ensure proper wrapping
-	return style.Width(width).Render(task.title)
+	return style.Width(width).Render(title)
+}
+
+// renderTagged renders a title wrapped to width with style, coloring its #tags
+// with the tag color. Wrapping happens first so the colors don't affect where lines break.
+func renderTagged(title string, width int, style lipgloss.Style) string {
+	tagStyle := style.Foreground(TagStyle.GetForeground())
+	lines := strings.Split(lipgloss.NewStyle().Width(width).Render(title), "\n")
+	for i, line := range lines {
+		text := strings.TrimRight(line, " ")
+		var b strings.Builder
+		start := 0
+		for _, span := range tagPattern.FindAllStringSubmatchIndex(text, -1) {
+			tagStart := span[2] - 1 // Include the #
+			b.WriteString(style.Render(text[start:tagStart]))
+			b.WriteString(tagStyle.Render(text[tagStart:span[3]]))
+			start = span[3]
+		}
+		if start < len(text) {
+			b.WriteString(style.Render(text[start:]))
+		}
+		b.WriteString(line[len(text):]) // Padding
+		lines[i] = b.String()
+	}
+	return strings.Join(lines, "\n")
 }
 
-// loadTasksFromFile loads tasks from a file using the storage package
-func loadTasksFromFile(filePath string) ([]Task, error) {
-	taskData, err := storage.LoadTasks(filePath)
+// truncateTitle shortens a title to fit on one line of the given width,
+// breaking at the last word boundary that fits and appending an ellipsis
+func truncateTitle(title string, width int) string {
+	if ansi.StringWidth(title) <= width {
+		return title
+	}
+	if width <= 1 {
+		return ansi.Truncate(title, width, "")
+	}
+
+	truncated := ansi.Truncate(title, width-1, "")
+	if i := strings.LastIndex(truncated, " "); i > 0 {
+		truncated = truncated[:i]
+	}
+	return strings.TrimRight(truncated, " ") + "…"
+}
+
+// isTitleTruncated reports whether a task's title is cut off in its row
+func (m Model) isTitleTruncated(task *Task, width int) bool {
+	if !m.truncateTitles || task == nil {
+		return false
+	}
+	indentLevel := len(m.getParentChainIDs(task.id))
+	return ansi.StringWidth(task.title) > m.titleWidth(*task, width, indentLevel)
+}
+
+// loadTasksFromFile loads tasks from a file using the storage package, along
+// with the file's creation time for keeping it on save
+func loadTasksFromFile(filePath string) ([]Task, time.Time, error) {
+	fileData, err := storage.LoadFile(filePath)
 	if err != nil {
-		return nil, err
+		return nil, time.Time{}, err
 	}
 
-	return FromTaskDataSlice(taskData), nil
+	return FromTaskDataSlice(fileData.Tasks), fileData.CreatedAt, nil
 }
 
-// saveTasksToFile saves tasks to a file using the storage package
+// errFileChanged is returned instead of saving over changes made outside dotdot
+var errFileChanged = errors.New("the task file was changed outside dotdot")
+
+// saveTasksToFile saves tasks to a file using the storage package, refusing to
+// overwrite the file if something else changed it since it was loaded or saved
 func (m *Model) saveTasksToFile() error {
 	if m.filePath == "" {
 		return nil // No file path specified, skip saving
 	}
+	if err := m.checkFileUnchanged(); err != nil {
+		return err
+	}
+
+	return m.writeTasksToFile()
+}
+
+// checkFileUnchanged returns errFileChanged if something else changed the task
+// file since it was loaded or saved
+func (m Model) checkFileUnchanged() error {
+	if !modTimeOf(m.filePath).Equal(m.fileModTime) {
+		return fmt.Errorf("%w; press %s to save over it", errFileChanged, m.keyMap.ForceSave.Help().Key)
+	}
+	return nil
+}
 
+// writeTasksToFile saves tasks to the current file unconditionally
+func (m *Model) writeTasksToFile() error {
+	if m.fileCreated.IsZero() {
+		// Not loaded from this file, e.g. after save as; read it once
+		m.fileCreated = storage.CreationTime(m.filePath)
+	}
+	opts := m.saveOptions()
+	opts.CreatedAt = m.fileCreated
 	taskData := ToTaskDataSlice(m.tasks)
-	return storage.SaveTasks(m.filePath, taskData)
+	return storage.SaveTasksWithOptions(m.filePath, taskData, opts)
+}
+
+// saveOptions returns the storage options for writing the task file
+func (m Model) saveOptions() storage.SaveOptions {
+	return storage.SaveOptions{Compact: m.compactJSON}
 }
 
 // autoSaveIfEnabled saves tasks if auto-save is enabled
 func (m *Model) autoSaveIfEnabled() {
-	if m.autoSave {
-		if err := m.saveTasksToFile(); err != nil {
-			m.setError("Save failed: " + err.Error())
-		} else {
-			// Clear any previous error on successful save
-			m.clearError()
-		}
+	if !m.autoSave {
+		// Changes to a file that isn't autosaved, e.g. because another dotdot has
+		// it open, are unsaved until saved explicitly. In read-only mode only
+		// folding gets here, which isn't worth keeping over the file's changes.
+		m.unsaved = m.filePath != "" && !m.readOnly
+		return
+	}
+	if err := m.saveTasksToFile(); err != nil {
+		m.setError("Save failed: " + err.Error())
+		m.unsaved = true
+	} else {
+		// Clear any previous error on successful save
+		m.clearError()
+		m.unsaved = false
+		m.recordFileModTime()
 	}
 }
 
+// forceSave saves the task list over a file that was changed outside dotdot
+func (m *Model) forceSave() {
+	if m.filePath == "" {
+		m.setStatus("No file to save to; use save as")
+		return
+	}
+	if err := m.writeTasksToFile(); err != nil {
+		m.setError("Save failed: " + err.Error())
+		return
+	}
+	m.unsaved = false
+	m.recordFileModTime()
+	m.clearError()
+	m.setStatus("Saved " + m.filePath)
+}
+
+// promptSaveAs asks for a new file path and saves the task list there
+func (m *Model) promptSaveAs() {
+	m.openPrompt("Save as", m.filePath, func(m *Model, value string) {
+		m.saveAs(value)
+	})
+}
+
+// saveAs saves the task list to a new path, asking before overwriting an existing file
+func (m *Model) saveAs(path string) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		m.setStatus("Save cancelled: no file name given")
+		return
+	}
+	if filepath.Ext(path) == "" {
+		path += ".dot"
+	}
+
+	if path != m.filePath && storage.FileExists(path) {
+		m.askConfirm(fmt.Sprintf("Overwrite existing file %s?", path), func(m *Model) {
+			m.writeFileAs(path)
+		})
+		return
+	}
+
+	m.writeFileAs(path)
+}
+
+// writeFileAs writes the task list to path and makes it the current file
+func (m *Model) writeFileAs(path string) {
+	if err := storage.SaveTasksWithOptions(path, ToTaskDataSlice(m.tasks), m.saveOptions()); err != nil {
+		m.setError("Save failed: " + err.Error())
+		return
+	}
+
+	m.filePath = path
+	m.fileCreated = time.Time{} // Read from the new file on the next save
+	m.autoSave = true
+	m.unsaved = false
+	m.recordFileModTime()
+	m.clearError()
+	m.setStatus("Saved as " + path)
+}
+
+// promptExportSubtree asks for a file name to save the current task and its
+// subtasks to as a new list, removing them from this list if move is set
+func (m *Model) promptExportSubtree(move bool) {
+	task := m.getCurrentTask()
+	if task == nil {
+		return
+	}
+	label := "Export task to"
+	if move {
+		label = "Move task to"
+	}
+	taskID := task.id
+	m.openPrompt(label, "", func(m *Model, value string) {
+		m.exportSubtree(taskID, value, move)
+	})
+}
+
+// exportSubtree saves a task and its subtasks to a new list file, asking before
+// overwriting an existing file. Names without a folder go next to the current file.
+func (m *Model) exportSubtree(taskID, path string, move bool) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		m.setStatus("Export cancelled: no file name given")
+		return
+	}
+	if filepath.Ext(path) == "" {
+		path += ".dot"
+	}
+	if m.filePath != "" && !filepath.IsAbs(path) && filepath.Dir(path) == "." {
+		path = filepath.Join(filepath.Dir(m.filePath), path)
+	}
+	if path == m.filePath {
+		m.setError("Cannot export to the open list's own file")
+		return
+	}
+
+	if storage.FileExists(path) {
+		m.askConfirm(fmt.Sprintf("Overwrite existing file %s?", path), func(m *Model) {
+			m.writeSubtree(taskID, path, move)
+		})
+		return
+	}
+
+	m.writeSubtree(taskID, path, move)
+}
+
+// writeSubtree writes a task and its subtasks, keeping their IDs, as the only
+// top-level task of the list at path
+func (m *Model) writeSubtree(taskID, path string, move bool) {
+	task := m.findTaskByID(taskID)
+	if task == nil {
+		return
+	}
+	title := task.title
+	if err := storage.SaveTasksWithOptions(path, []storage.TaskData{ToTaskData(*task)}, m.saveOptions()); err != nil {
+		m.setError("Export failed: " + err.Error())
+		return
+	}
+
+	if !move {
+		m.setStatus(fmt.Sprintf("Exported '%s' to %s", title, path))
+		return
+	}
+
+	m.takeSnapshot(m.taskLabel("move to new list", taskID))
+	parent, index := m.findParentTask(taskID)
+	removeTaskFromSlice(m.getTaskContainer(parent), index)
+	if m.cursorID == taskID {
+		m.updateCursorAfterDeletion()
+	}
+	m.setStatus(fmt.Sprintf("Moved '%s' to %s", title, path))
+	m.autoSaveIfEnabled()
+}
+
 // setError sets an error message to display to the user
 func (m *Model) setError(message string) {
 	m.lastError = message
@@ -573,8 +1622,37 @@ func (m *Model) clearStatus() {
 	m.statusMessage = ""
 }
 
-// getTaskListDisplayName returns a user-friendly name for the current task list
+// editCurrentTaskAt enters edit mode on the current task with the cursor at the
+// start or end of its title
+func (m *Model) editCurrentTaskAt(start bool) {
+	task := m.getCurrentTask()
+	if task == nil {
+		return
+	}
+
+	m.editing = true
+	m.textInput.SetValue(task.title)
+	if start {
+		m.textInput.CursorStart()
+	} else {
+		m.textInput.CursorEnd()
+	}
+	m.textInput.Focus()
+}
+
+// getTaskListDisplayName returns a user-friendly name for the current task list,
+// noting when it is open read-only
 func (m Model) getTaskListDisplayName() string {
+	name := m.taskListName()
+	if m.readOnly {
+		name += " (read-only)"
+	}
+	return name
+}
+
+// taskListName names the current task list by its global name, its path
+// relative to the current directory, or its file name and directory
+func (m Model) taskListName() string {
 	if m.filePath == "" {
 		return "Untitled"
 	}
@@ -583,14 +1661,9 @@ func (m Model) getTaskListDisplayName() string {
 	filename := filepath.Base(m.filePath)
 	name := strings.TrimSuffix(filename, filepath.Ext(filename))
 
-	// Check if it's a global task list (in config directory)
-	configDir := ""
-	if homeDir, err := storage.GetConfigDir(); err == nil {
-		configDir = filepath.Join(homeDir, "dotdot", "tasks")
-	}
-
-	if configDir != "" && strings.HasPrefix(m.filePath, configDir) {
-		return fmt.Sprintf("%s (global)", name)
+	// Global task lists show their namespaced name, e.g. "alice/work"
+	if globalName, ok := storage.GlobalTaskName(m.filePath); ok {
+		return fmt.Sprintf("%s (global)", strings.TrimSuffix(globalName, filepath.Ext(filename)))
 	}
 
 	// For local files, show relative path if not in current directory
@@ -620,10 +1693,19 @@ func ToTaskData(task Task) storage.TaskData {
 	}
 
 	return storage.TaskData{
-		ID:       task.ID(),
-		Title:    task.Title(),
-		Status:   int(task.Status()),
-		Subtasks: subtasks,
+		ID:        task.ID(),
+		Title:     task.Title(),
+		Notes:     task.Notes(),
+		Status:    int(task.Status()),
+		Estimate:  storage.FormatEstimate(task.Estimate()),
+		DueDate:   task.DueDate(),
+		Priority:  task.Priority(),
+		Deferred:  task.Deferred(),
+		Pinned:    task.Pinned(),
+		Collapsed: task.Collapsed(),
+		CreatedAt: task.CreatedAt(),
+		UpdatedAt: task.UpdatedAt(),
+		Subtasks:  subtasks,
 	}
 }
 
@@ -643,7 +1725,20 @@ func FromTaskData(data storage.TaskData) Task {
 		subtasks[i] = FromTaskData(subtaskData)
 	}
 
-	return NewTaskWithID(data.ID, data.Title, TaskStatus(data.Status), subtasks...)
+	task := NewTaskWithID(data.ID, data.Title, TaskStatus(data.Status), subtasks...)
+	task.notes = data.Notes
+	task.deferred = data.Deferred
+	task.pinned = data.Pinned
+	task.collapsed = data.Collapsed
+	task.due = data.DueDate
+	task.priority = data.Priority
+	task.created = data.CreatedAt
+	task.updated = data.UpdatedAt
+	// Invalid estimates in the file are dropped rather than failing the load
+	if estimate, err := storage.ParseEstimate(data.Estimate); err == nil {
+		task.estimate = estimate
+	}
+	return task
 }
 
 // FromTaskDataSlice converts a slice of storage TaskData to TUI Tasks
@@ -665,13 +1760,39 @@ func (m Model) buildFooterParts(width int) []string {
 	}
 
 	if m.statusMessage != "" {
-		statusMsg := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("240")).
-			Render("Status: " + m.statusMessage)
+		statusMsg := StatusStyle.Render("Status: " + m.statusMessage)
 		footerParts = append(footerParts, statusMsg)
 	}
 
-	// Add help section
+	// Show the full title of a truncated selection so it can be read without editing
+	if !m.editing {
+		if task := m.getCurrentTask(); m.isTitleTruncated(task, width) {
+			footerParts = append(footerParts, FullTitleStyle.Width(width).Render(task.title))
+		}
+	}
+
+	// Show the selection's notes while browsing the task list
+	if !m.editing && m.notesEditor == nil && m.history == nil && m.trashView == nil && m.listPicker == nil {
+		if notes := m.renderFooterNotes(width); notes != "" {
+			footerParts = append(footerParts, notes)
+		}
+	}
+
+	promptLine := m.renderPrompt()
+	if promptLine != "" {
+		footerParts = append(footerParts, promptLine)
+	}
+
+	// Add help section, topped by the cursor position unless a message or
+	// prompt is already taking up the footer
+	if m.hideHelp {
+		return footerParts
+	}
+	if promptLine == "" && !m.showError && m.statusMessage == "" {
+		if position := m.renderPosition(); position != "" {
+			footerParts = append(footerParts, position)
+		}
+	}
 	var helpView string
 	if m.showFullHelp {
 		helpView = m.help.FullHelpView(m.keyMap.FullHelp())
@@ -685,5 +1806,33 @@ func (m Model) buildFooterParts(width int) []string {
 	return footerParts
 }
 
+// renderPosition renders where the cursor is among the visible tasks, e.g.
+// "12/48", and how many tasks the list holds and how many are Done. It is
+// only shown while browsing the task list.
+func (m Model) renderPosition() string {
+	if m.editing || m.notesEditor != nil || m.history != nil || m.trashView != nil || m.listPicker != nil || m.pinnedCursor > 0 {
+		return ""
+	}
+	ids := m.getAllTaskIDs()
+	position := slices.Index(ids, m.cursorID)
+	if position < 0 {
+		return ""
+	}
+
+	total, done := 0, 0
+	var walk func(tasks []Task)
+	walk = func(tasks []Task) {
+		for _, task := range tasks {
+			total++
+			if task.status == Done {
+				done++
+			}
+			walk(task.subtasks)
+		}
+	}
+	walk(m.tasks)
+	return MetaStyle.Render(fmt.Sprintf("%d/%d · %d tasks, %d done", position+1, len(ids), total, done))
+}
+
 // Ensure Model implements tea.Model
 var _ tea.Model = (*Model)(nil)
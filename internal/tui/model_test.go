@@ -1,8 +1,24 @@
 package tui
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
+	"unicode"
+
+	"dotdot/internal/config"
+	"dotdot/internal/storage"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
 )
 
 func TestTaskManipulation(t *testing.T) {
@@ -153,6 +169,49 @@ func TestBoundaryConditions(t *testing.T) {
 	}
 }
 
+func TestMoveTaskToEdge(t *testing.T) {
+	model := NewModel()
+	model.tasks = GetMinimalMockTasks()
+
+	// The last task moves to the top with its subtasks
+	model.cursorID = model.tasks[3].id
+	model.moveTaskToEdge(true)
+	if model.tasks[0].title != "Fourth task with subtasks" || len(model.tasks[0].subtasks) != 2 {
+		t.Errorf("Expected the fourth task and its subtasks first, got %q with %d subtasks", model.tasks[0].title, len(model.tasks[0].subtasks))
+	}
+	if model.tasks[1].title != "First task" || model.tasks[3].title != "Third task" {
+		t.Errorf("Expected the other tasks to keep their order, got %q ... %q", model.tasks[1].title, model.tasks[3].title)
+	}
+	if model.cursorID != model.tasks[0].id {
+		t.Error("Expected the cursor to stay on the moved task")
+	}
+	if len(model.undoStack) != 1 {
+		t.Errorf("Expected 1 snapshot, got %d", len(model.undoStack))
+	}
+
+	// Moving to the top again is a no-op without a snapshot
+	model.moveTaskToEdge(true)
+	if len(model.undoStack) != 1 {
+		t.Errorf("Expected no snapshot for a task already first, got %d", len(model.undoStack))
+	}
+
+	// A subtask moves to the bottom of its own group
+	model.cursorID = model.tasks[0].subtasks[0].id
+	model.moveTaskToEdge(false)
+	if got := model.tasks[0].subtasks[1].title; got != "Subtask 1" {
+		t.Errorf("Expected 'Subtask 1' last in its group, got %q", got)
+	}
+	if len(model.tasks) != 4 {
+		t.Errorf("Expected 4 top-level tasks, got %d", len(model.tasks))
+	}
+
+	model.undo()
+	model.undo()
+	if model.tasks[3].title != "Fourth task with subtasks" || model.tasks[3].subtasks[0].title != "Subtask 1" {
+		t.Error("Expected undo to restore the original order")
+	}
+}
+
 func TestCursorPositioningDuringIndentation(t *testing.T) {
 	model := NewModel()
 	model.tasks = GetMinimalMockTasks()
@@ -395,4 +454,3329 @@ func TestUndoRedoFunctionality(t *testing.T) {
 			t.Errorf("Expected to undo at most %d operations, undid %d", model.maxHistorySize, undoCount)
 		}
 	})
+
+	t.Run("UnboundedHistory", func(t *testing.T) {
+		model := NewModel()
+		model.tasks = GetMinimalMockTasks()
+		model.cursorID = model.tasks[0].id
+		model.SetMaxHistory(0)
+
+		for i := 0; i < DefaultMaxHistory+10; i++ {
+			model.editTaskTitle(model.cursorID, fmt.Sprintf("Title %d", i))
+		}
+		if len(model.undoStack) != DefaultMaxHistory+10 {
+			t.Errorf("Expected every change kept with no limit, got %d", len(model.undoStack))
+		}
+	})
+}
+
+func TestSaveAsRequiresConfirmationToOverwrite(t *testing.T) {
+	dir := t.TempDir()
+	existingPath := filepath.Join(dir, "existing.dot")
+	if err := storage.SaveTasks(existingPath, []storage.TaskData{{ID: "keep", Title: "Keep me"}}); err != nil {
+		t.Fatalf("Failed to create existing file: %v", err)
+	}
+
+	model := NewModel()
+	model.tasks = GetMinimalMockTasks()
+
+	// Saving over an existing file asks for confirmation first
+	model.saveAs(existingPath)
+	if model.confirm == nil {
+		t.Fatal("Expected a confirmation prompt before overwriting")
+	}
+	if model.filePath == existingPath {
+		t.Error("File path should not change before confirmation")
+	}
+
+	// Declining leaves the existing file untouched
+	updated, _ := model.Update(tea.KeyPressMsg{Code: 'n', Text: "n"})
+	model = updated.(Model)
+	if model.confirm != nil {
+		t.Error("Expected confirmation to be dismissed")
+	}
+	loaded, err := storage.LoadTasks(existingPath)
+	if err != nil || len(loaded) != 1 || loaded[0].Title != "Keep me" {
+		t.Fatalf("Expected existing file to be untouched, got %v (err %v)", loaded, err)
+	}
+
+	// Accepting overwrites and switches to the new file
+	model.saveAs(existingPath)
+	updated, _ = model.Update(tea.KeyPressMsg{Code: 'y', Text: "y"})
+	model = updated.(Model)
+	if model.filePath != existingPath {
+		t.Errorf("Expected file path to be %s, got %s", existingPath, model.filePath)
+	}
+	loaded, err = storage.LoadTasks(existingPath)
+	if err != nil || len(loaded) != len(model.tasks) {
+		t.Errorf("Expected %d saved tasks, got %d (err %v)", len(model.tasks), len(loaded), err)
+	}
+}
+
+func TestExportSubtreeToNewList(t *testing.T) {
+	dir := t.TempDir()
+	model := NewModel()
+	model.filePath = filepath.Join(dir, "main.dot")
+	model.tasks = GetMinimalMockTasks()
+	parent := model.tasks[3]
+	model.cursorID = parent.id
+
+	press := func(msg tea.KeyPressMsg) {
+		updated, _ := model.Update(msg)
+		model = updated.(Model)
+	}
+	typeText := func(text string) {
+		for _, r := range text {
+			press(tea.KeyPressMsg{Code: r, Text: string(r)})
+		}
+	}
+
+	// x exports a copy, next to the current file, keeping IDs
+	press(tea.KeyPressMsg{Code: 'x', Text: "x"})
+	typeText("sub")
+	press(tea.KeyPressMsg{Code: tea.KeyEnter})
+	exported, err := storage.LoadTasks(filepath.Join(dir, "sub.dot"))
+	if err != nil || len(exported) != 1 {
+		t.Fatalf("Expected the subtree saved as one top-level task, got %v (err %v)", exported, err)
+	}
+	if exported[0].ID != parent.id || len(exported[0].Subtasks) != 2 || exported[0].Subtasks[0].ID != parent.subtasks[0].id {
+		t.Error("Expected the exported subtree to keep its IDs and subtasks")
+	}
+	if len(model.tasks) != 4 {
+		t.Error("Expected exporting to leave the current list alone")
+	}
+
+	// X moves it, asking before overwriting the existing file
+	press(tea.KeyPressMsg{Code: 'X', Text: "X"})
+	typeText("sub")
+	press(tea.KeyPressMsg{Code: tea.KeyEnter})
+	if model.confirm == nil {
+		t.Fatal("Expected a confirmation before overwriting")
+	}
+	press(tea.KeyPressMsg{Code: 'y', Text: "y"})
+	if len(model.tasks) != 3 || model.findTaskByID(parent.id) != nil {
+		t.Error("Expected moving to remove the subtree from the current list")
+	}
+	if model.getCurrentTask() == nil {
+		t.Error("Expected the cursor to move to a remaining task")
+	}
+
+	// One undo brings it back
+	model.undo()
+	if model.findTaskByID(parent.id) == nil {
+		t.Error("Expected undo to restore the moved subtree")
+	}
+}
+
+func TestSaveAsNewFileSkipsConfirmation(t *testing.T) {
+	newPath := filepath.Join(t.TempDir(), "fresh")
+
+	model := NewModel()
+	model.tasks = GetMinimalMockTasks()
+	model.saveAs(newPath)
+
+	if model.confirm != nil {
+		t.Error("Did not expect a confirmation for a new file")
+	}
+	if model.filePath != newPath+".dot" {
+		t.Errorf("Expected .dot extension to be added, got %s", model.filePath)
+	}
+	if !storage.FileExists(newPath + ".dot") {
+		t.Error("Expected new file to be written")
+	}
+}
+
+func TestWrappedTitlesHangIndent(t *testing.T) {
+	model := NewModel()
+	model.tasks = GetMultiLineMockTasks()
+	model.cursorID = model.tasks[0].id
+
+	width := 50
+	var check func(tasks []Task, indentLevel int)
+	check = func(tasks []Task, indentLevel int) {
+		for _, task := range tasks {
+			row := ansi.Strip(model.renderRow(task, width, indentLevel, false, false, nil))
+			lines := strings.Split(row, "\n")
+
+			// Text starts after the cursor, indentation and bullet columns
+			textStart := CursorWidth + indentLevel*IndentWidth + BulletWidth
+			for i, line := range lines[1:] {
+				prefix := []rune(line)[:textStart]
+				if strings.TrimSpace(string(prefix)) != "" {
+					t.Errorf("Continuation line %d of %q is not hang-indented: %q", i+1, task.title, line)
+				}
+				if len(strings.TrimSpace(line)) > 0 && []rune(line)[textStart] == ' ' {
+					t.Errorf("Continuation line %d of %q starts past the text column: %q", i+1, task.title, line)
+				}
+			}
+			check(task.subtasks, indentLevel+1)
+		}
+	}
+	check(model.tasks, 0)
+}
+
+func TestWideCharacterTitlesFitWidth(t *testing.T) {
+	model := NewModel()
+	parent := NewTask("日本語のタスク名はとても長いので折り返す必要があります 🎉🎉 done", Active,
+		NewTask("絵文字 🚀🚀🚀 と漢字が混ざったサブタスクのタイトル #タグ", Todo))
+	parent.estimate = 90 * time.Minute
+	model.tasks = []Task{parent}
+	model.cursorID = parent.id
+	updated, _ := model.Update(tea.WindowSizeMsg{Width: 40, Height: 30})
+	model = updated.(Model)
+	innerWidth := model.width - TotalPadding
+
+	view, _ := model.render()
+	for i, line := range strings.Split(ansi.Strip(view), "\n") {
+		if w := ansi.StringWidth(line); w > model.width {
+			t.Errorf("Line %d is %d cells wide, more than %d: %q", i, w, model.width, line)
+		}
+	}
+	row := ansi.Strip(model.renderRow(parent, innerWidth, 0, true, false, nil))
+	if lines := strings.Split(row, "\n"); len(lines) < 2 || !strings.HasPrefix(lines[1], strings.Repeat(" ", CursorWidth+BulletWidth)) {
+		t.Errorf("Expected wrapped lines to stay under the title column, got %q", row)
+	}
+}
+
+func TestSmartNewTask(t *testing.T) {
+	tests := []struct {
+		name          string
+		smartNewTask  bool
+		status        TaskStatus
+		withSubtasks  bool
+		wantAsSubtask bool
+	}{
+		{"OffActiveParent", false, Active, true, false},
+		{"OnActiveParent", true, Active, true, true},
+		{"OnActiveLeaf", true, Active, false, false},
+		{"OnTodoParent", true, Todo, true, false},
+		{"OnDoneParent", true, Done, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := NewModel()
+			model.smartNewTask = tt.smartNewTask
+
+			var subtasks []Task
+			if tt.withSubtasks {
+				subtasks = []Task{NewTask("Child", Todo)}
+			}
+			model.tasks = []Task{NewTask("Parent", tt.status, subtasks...), NewTask("Other", Todo)}
+			model.cursorID = model.tasks[0].id
+
+			newTaskID := model.createNewTaskBelow()
+			parent, index := model.findParentTask(newTaskID)
+
+			if tt.wantAsSubtask {
+				if parent == nil || parent.id != model.tasks[0].id || index != 0 {
+					t.Errorf("Expected new task to be the first subtask of the parent, got parent %v index %d", parent, index)
+				}
+			} else if parent != nil || index != 1 {
+				t.Errorf("Expected new task to be a sibling directly below, got parent %v index %d", parent, index)
+			}
+
+			// Creation is a single undoable step either way
+			model.undo()
+			if model.findTaskByID(newTaskID) != nil {
+				t.Error("Expected undo to remove the new task")
+			}
+		})
+	}
+}
+
+func TestSmartNewTaskUnfoldsParent(t *testing.T) {
+	model := NewModel()
+	model.smartNewTask = true
+	model.tasks = []Task{NewTask("Parent", Active, NewTask("Child", Todo))}
+	model.tasks[0].collapsed = true
+	model.cursorID = model.tasks[0].id
+
+	newTaskID := model.createNewTaskBelow()
+	if model.tasks[0].collapsed {
+		t.Error("Expected the folded parent to unfold for its new subtask")
+	}
+	if !slices.Contains(model.getAllTaskIDs(), newTaskID) {
+		t.Error("Expected the new subtask to be visible")
+	}
+}
+
+func TestPromoteTaskToTopLevel(t *testing.T) {
+	model := NewModel()
+	deep := NewTask("Deep", Active, NewTask("Deep child", Todo))
+	model.tasks = []Task{
+		NewTask("Root", Todo,
+			NewTask("Middle", Todo, deep, NewTask("Middle sibling", Todo)),
+		),
+		NewTask("Next root", Todo),
+	}
+	model.cursorID = deep.id
+
+	model.promoteTaskToTopLevel()
+
+	// Task is inserted directly after its outermost ancestor with its subtree intact
+	if len(model.tasks) != 3 || model.tasks[1].id != deep.id {
+		t.Fatalf("Expected promoted task at top-level index 1, got %d top-level tasks", len(model.tasks))
+	}
+	if len(model.tasks[1].subtasks) != 1 || model.tasks[1].subtasks[0].title != "Deep child" {
+		t.Error("Expected promoted task to keep its subtasks")
+	}
+	if middle := model.tasks[0].subtasks[0]; len(middle.subtasks) != 1 || middle.subtasks[0].title != "Middle sibling" {
+		t.Error("Expected promoted task to be removed from its old parent")
+	}
+	if model.cursorID != deep.id {
+		t.Error("Expected cursor to stay on the promoted task")
+	}
+
+	// A single undo restores the original nesting
+	model.undo()
+	if len(model.tasks) != 2 || len(model.tasks[0].subtasks[0].subtasks) != 2 {
+		t.Error("Expected one undo to restore the original structure")
+	}
+
+	// Top-level tasks are left alone
+	model.cursorID = model.tasks[1].id
+	undoDepth := len(model.undoStack)
+	model.promoteTaskToTopLevel()
+	if len(model.undoStack) != undoDepth {
+		t.Error("Promoting a top-level task should not take a snapshot")
+	}
+}
+
+func TestUnindentToDepth(t *testing.T) {
+	model := NewModel()
+	deep := NewTask("Deep", Active, NewTask("Deep child", Todo))
+	model.tasks = []Task{
+		NewTask("Root", Todo,
+			NewTask("Middle", Todo,
+				NewTask("Inner", Todo, deep),
+				NewTask("Inner sibling", Todo),
+			),
+		),
+		NewTask("Next root", Todo),
+	}
+	model.cursorID = deep.id
+	press := func(code rune) {
+		updated, _ := model.Update(tea.KeyPressMsg{Code: code, Text: string(code)})
+		model = updated.(Model)
+	}
+
+	// L then 1 makes the task a sibling of its depth 1 ancestor, directly after it
+	press('L')
+	press('1')
+	root := model.tasks[0]
+	if len(root.subtasks) != 2 || root.subtasks[1].id != deep.id {
+		t.Fatalf("Expected task after its depth 1 ancestor, got %d tasks under Root", len(root.subtasks))
+	}
+	if len(root.subtasks[1].subtasks) != 1 {
+		t.Error("Expected the task to keep its subtasks")
+	}
+	if len(root.subtasks[0].subtasks[0].subtasks) != 0 {
+		t.Error("Expected the task to be removed from its old parent")
+	}
+
+	// A single undo restores the original nesting
+	model.undo()
+	if len(model.tasks[0].subtasks[0].subtasks[0].subtasks) != 1 {
+		t.Error("Expected one undo to restore the original structure")
+	}
+
+	// Depths past the parent are clamped to moving out one level
+	press('L')
+	press('9')
+	middle := model.tasks[0].subtasks[0]
+	if len(middle.subtasks) != 3 || middle.subtasks[1].id != deep.id {
+		t.Error("Expected a depth past the parent to unindent one level")
+	}
+
+	// Depth 0 moves it to the top level
+	press('L')
+	press('0')
+	if len(model.tasks) != 3 || model.tasks[1].id != deep.id {
+		t.Error("Expected depth 0 to move the task to the top level")
+	}
+
+	// Any other key cancels, and top-level tasks are left alone
+	undoDepth := len(model.undoStack)
+	model.cursorID = model.tasks[0].subtasks[0].id
+	press('L')
+	press('x')
+	press('L')
+	model.cursorID = model.tasks[1].id
+	press('L')
+	press('0')
+	if len(model.undoStack) != undoDepth || model.unindentPending {
+		t.Error("Expected cancelled and top-level unindents to change nothing")
+	}
+}
+
+func TestTruncatedTitleShownInFooter(t *testing.T) {
+	model := NewModel()
+	model.tasks = GetMultiLineMockTasks()
+	model.truncateTitles = true
+	width := 50
+
+	// Long titles render on a single line ending in an ellipsis
+	longTask := model.tasks[1]
+	row := ansi.Strip(model.renderRow(longTask, width, 0, false, false, nil))
+	if strings.Contains(row, "\n") {
+		t.Errorf("Expected truncated row to be a single line, got %q", row)
+	}
+	if !strings.Contains(row, "…") {
+		t.Errorf("Expected truncated row to end with an ellipsis, got %q", row)
+	}
+
+	// The footer shows the full title only when the selection is truncated
+	model.cursorID = longTask.id
+	footer := ansi.Strip(strings.Join(model.buildFooterParts(width), "\n"))
+	if !strings.Contains(strings.Join(strings.Fields(footer), " "), longTask.title) {
+		t.Error("Expected footer to contain the full title of the truncated selection")
+	}
+
+	model.cursorID = model.tasks[0].id
+	parts := model.buildFooterParts(width)
+	model.truncateTitles = false
+	if len(parts) != len(model.buildFooterParts(width)) {
+		t.Error("Expected no extra footer line for a title that fits")
+	}
+}
+
+func TestTruncateTitleBreaksAtWord(t *testing.T) {
+	got := truncateTitle("Preheat the oven to temperature", 16)
+	if got != "Preheat the…" {
+		t.Errorf("Expected truncation at a word boundary, got %q", got)
+	}
+	if got := truncateTitle("Short", 16); got != "Short" {
+		t.Errorf("Expected short titles to be unchanged, got %q", got)
+	}
+}
+
+func TestCreateTaskBelowWithSameStatus(t *testing.T) {
+	model := NewModel()
+	model.tasks = GetMinimalMockTasks()
+
+	for _, task := range model.tasks[:3] {
+		model.cursorID = task.id
+		newTaskID := model.createNewTaskBelowWithSameStatus()
+		newTask := model.findTaskByID(newTaskID)
+		if newTask == nil || newTask.status != task.status {
+			t.Errorf("Expected new sibling of %q to have status %d", task.title, task.status)
+		}
+	}
+
+	// The regular variant still starts new tasks as Todo
+	model.cursorID = model.tasks[0].id // Done task
+	newTask := model.findTaskByID(model.createNewTaskBelow())
+	if newTask == nil || newTask.status != Todo {
+		t.Error("Expected regular new task to start as Todo")
+	}
+}
+
+func TestEstimates(t *testing.T) {
+	model := NewModel()
+	model.tasks = GetMinimalMockTasks()
+	parent := model.tasks[3]
+
+	// Valid estimates are set on leaves and roll up to the parent
+	model.setEstimate(parent.subtasks[0].id, "1h")
+	model.setEstimate(parent.subtasks[1].id, "30m")
+	if got := totalEstimate(model.tasks[3]); got != 90*time.Minute {
+		t.Errorf("Expected rolled up estimate of 1h30m, got %v", got)
+	}
+	if meta := ansi.Strip(model.renderMeta(model.tasks[3])); !strings.Contains(meta, "Σ1h30m") {
+		t.Errorf("Expected parent meta to show rollup, got %q", meta)
+	}
+
+	// Invalid estimates are rejected with a status note and no change
+	undoDepth := len(model.undoStack)
+	model.setEstimate(parent.subtasks[0].id, "whenever")
+	if model.findTaskByID(parent.subtasks[0].id).estimate != time.Hour {
+		t.Error("Expected invalid estimate to leave the existing value")
+	}
+	if model.statusMessage == "" || len(model.undoStack) != undoDepth {
+		t.Error("Expected a status note and no snapshot for an invalid estimate")
+	}
+
+	// Estimates survive conversion to storage and back
+	restored := FromTaskDataSlice(ToTaskDataSlice(model.tasks))
+	if got := totalEstimate(restored[3]); got != 90*time.Minute {
+		t.Errorf("Expected estimates to round trip, got %v", got)
+	}
+
+	// Undo restores the previous estimate
+	model.undo()
+	if model.findTaskByID(parent.subtasks[1].id).estimate != 0 {
+		t.Error("Expected undo to clear the last estimate")
+	}
+}
+
+func TestDueDates(t *testing.T) {
+	model := NewModel()
+	model.tasks = GetMinimalMockTasks()
+	taskID := model.tasks[2].id
+
+	model.setDueDate(taskID, "2024-03-01")
+	task := model.findTaskByID(taskID)
+	if got := storage.FormatDueDate(task.due); got != "2024-03-01" {
+		t.Fatalf("Expected due date 2024-03-01, got %q", got)
+	}
+	if meta := ansi.Strip(model.renderMeta(*task)); !strings.Contains(meta, "due 2024-03-01") {
+		t.Errorf("Expected meta to show the due date, got %q", meta)
+	}
+
+	// Bad input shows an error and leaves the due date alone
+	undoDepth := len(model.undoStack)
+	model.setDueDate(taskID, "someday soon")
+	if !model.showError || len(model.undoStack) != undoDepth {
+		t.Error("Expected an error and no snapshot for an unreadable due date")
+	}
+	if storage.FormatDueDate(model.findTaskByID(taskID).due) != "2024-03-01" {
+		t.Error("Expected an invalid due date to leave the existing value")
+	}
+
+	// Overdue and due today only apply to unfinished tasks
+	due := *model.findTaskByID(taskID)
+	if got := taskDueState(due, time.Date(2024, 3, 2, 9, 0, 0, 0, due.due.Location())); got != overdue {
+		t.Errorf("Expected overdue the day after, got %v", got)
+	}
+	if got := taskDueState(due, time.Date(2024, 3, 1, 18, 0, 0, 0, due.due.Location())); got != dueToday {
+		t.Errorf("Expected due today on the day, got %v", got)
+	}
+	if got := taskDueState(due, time.Date(2024, 2, 28, 9, 0, 0, 0, due.due.Location())); got != notDue {
+		t.Errorf("Expected not due before the day, got %v", got)
+	}
+	due.status = Done
+	if got := taskDueState(due, time.Date(2024, 3, 2, 9, 0, 0, 0, due.due.Location())); got != notDue {
+		t.Errorf("Expected Done tasks never to be overdue, got %v", got)
+	}
+
+	// Due dates survive conversion to storage and back, and clearing takes one undo
+	restored := FromTaskDataSlice(ToTaskDataSlice(model.tasks))
+	if storage.FormatDueDate(restored[2].due) != "2024-03-01" {
+		t.Error("Expected due dates to round trip")
+	}
+	model.setDueDate(taskID, "")
+	if model.findTaskByID(taskID).due != nil {
+		t.Error("Expected an empty due date to clear it")
+	}
+	model.undo()
+	if model.findTaskByID(taskID).due == nil {
+		t.Error("Expected undo to restore the due date")
+	}
+}
+
+func TestPriorities(t *testing.T) {
+	model := NewModel()
+	model.width, model.height = 80, 24
+	model.tasks = []Task{
+		NewTask("Low", Todo),
+		NewTask("None", Todo),
+		NewTask("High", Todo, NewTask("High child", Todo, NewTask("Grandchild", Todo))),
+		NewTask("Also low", Todo),
+	}
+	press := func(code rune) {
+		updated, _ := model.Update(tea.KeyPressMsg{Code: code, Text: string(code)})
+		model = updated.(Model)
+	}
+
+	model.cursorID = model.tasks[0].id
+	press('+')
+	model.cursorID = model.tasks[3].id
+	press('+')
+	model.cursorID = model.tasks[2].id
+	for i := 0; i < 5; i++ {
+		press('+')
+	}
+	if got := model.getCurrentTask().priority; got != HighPriority {
+		t.Fatalf("Expected priority to stop at high, got %d", got)
+	}
+	if !strings.Contains(ansi.Strip(model.View()), "!!! High") {
+		t.Error("Expected a high priority marker before the title")
+	}
+
+	// Sorting orders siblings by priority, keeping ties in order and subtrees attached
+	press('!')
+	var titles []string
+	for _, task := range model.tasks {
+		titles = append(titles, task.title)
+	}
+	if got := strings.Join(titles, ","); got != "High,Low,Also low,None" {
+		t.Errorf("Expected siblings sorted by priority, got %s", got)
+	}
+	high := model.tasks[0]
+	if len(high.subtasks) != 1 || len(high.subtasks[0].subtasks) != 1 || high.subtasks[0].subtasks[0].title != "Grandchild" {
+		t.Error("Expected the sorted task to keep its subtree")
+	}
+
+	// Priorities survive conversion to storage and back, and sorting takes one undo
+	restored := FromTaskDataSlice(ToTaskDataSlice(model.tasks))
+	if restored[0].priority != HighPriority || restored[1].priority != LowPriority {
+		t.Error("Expected priorities to round trip")
+	}
+	model.undo()
+	if model.tasks[2].title != "High" || model.tasks[2].priority != HighPriority {
+		t.Error("Expected undo to restore the original order with priorities")
+	}
+
+	press('-')
+	if got := model.findTaskByID(high.id).priority; got != MediumPriority {
+		t.Errorf("Expected lowering to medium, got %d", got)
+	}
+}
+
+func TestTaskProgress(t *testing.T) {
+	model := NewModel()
+	parent := NewTask("Release", Active,
+		NewTask("Write notes", Done),
+		NewTask("Build", Active,
+			NewTask("Linux", Done),
+			NewTask("macOS", Todo),
+		),
+		NewTask("Announce", Todo),
+	)
+	model.tasks = []Task{parent, NewTask("Leaf", Todo)}
+
+	// Only leaf tasks count, at every depth
+	if done, total := taskProgress(model.tasks[0]); done != 2 || total != 4 {
+		t.Errorf("Expected 2/4 leaves done, got %d/%d", done, total)
+	}
+	if meta := ansi.Strip(model.renderMeta(model.tasks[0])); !strings.Contains(meta, "2/4") {
+		t.Errorf("Expected the parent to show 2/4, got %q", meta)
+	}
+	if meta := model.renderMeta(model.tasks[1]); meta != "" {
+		t.Errorf("Expected no progress on a task without subtasks, got %q", meta)
+	}
+
+	// The counter follows status changes, and stays on folded parents
+	model.cursorID = parent.subtasks[2].id
+	model.setStatusDirect(Done)
+	model.cursorID = parent.id
+	model.toggleFold()
+	if meta := ansi.Strip(model.renderMeta(model.tasks[0])); !strings.Contains(meta, "3/4") {
+		t.Errorf("Expected the folded parent to show 3/4, got %q", meta)
+	}
+}
+
+func TestStatusFilter(t *testing.T) {
+	model := NewModel()
+	model.tasks = GetMinimalMockTasks()
+	model.cursorID = model.tasks[0].id // Done task
+
+	// Cycle to the Todo filter: the Done cursor task is hidden so the cursor moves
+	model.cycleStatusFilter()
+	if !model.filtering || model.filterStatus != Todo {
+		t.Fatalf("Expected Todo filter, got filtering=%v status=%v", model.filtering, model.filterStatus)
+	}
+	ids := model.getAllTaskIDs()
+	wantIDs := []string{model.tasks[2].id, model.tasks[3].id, model.tasks[3].subtasks[0].id}
+	if strings.Join(ids, ",") != strings.Join(wantIDs, ",") {
+		t.Errorf("Expected only Todo tasks to be navigable, got %d tasks", len(ids))
+	}
+	if model.cursorID != ids[0] {
+		t.Error("Expected cursor to move to the first visible task")
+	}
+
+	// Active filter keeps the Todo parent visible for context around its Active subtask
+	model.cycleStatusFilter()
+	ids = model.getAllTaskIDs()
+	wantIDs = []string{model.tasks[1].id, model.tasks[3].id, model.tasks[3].subtasks[1].id}
+	if strings.Join(ids, ",") != strings.Join(wantIDs, ",") {
+		t.Errorf("Expected Active tasks and their ancestors, got %d tasks", len(ids))
+	}
+
+	// The header shows the active filter
+	model.width, model.height = 80, 24
+	if !strings.Contains(ansi.Strip(model.View()), "[only Active]") {
+		t.Error("Expected header to indicate the active filter")
+	}
+
+	// ESC clears the filter
+	updated, _ := model.Update(tea.KeyPressMsg{Code: tea.KeyEscape})
+	model = updated.(Model)
+	if model.filtering {
+		t.Error("Expected ESC to clear the filter")
+	}
+	if len(model.getAllTaskIDs()) != 6 {
+		t.Error("Expected all tasks to be visible after clearing the filter")
+	}
+}
+
+func TestTaskTags(t *testing.T) {
+	tests := []struct {
+		title string
+		want  []string
+	}{
+		{"No tags here", nil},
+		{"Prepare workspace #setup", []string{"setup"}},
+		{"#home Fix the sink #urgent, then #Home again", []string{"home", "urgent"}},
+		{"Issue#42 and C# are not tags", nil},
+		{"Mixed #follow-up #v2_plan", []string{"follow-up", "v2_plan"}},
+	}
+	for _, tt := range tests {
+		got := NewTask(tt.title, Todo).Tags()
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("Expected tags %v for %q, got %v", tt.want, tt.title, got)
+		}
+	}
+}
+
+func TestTagFilter(t *testing.T) {
+	model := NewModel()
+	model.tasks = []Task{
+		NewTask("Groceries #errand", Todo),
+		NewTask("House", Todo,
+			NewTask("Fix the sink #Errand", Todo),
+			NewTask("Paint the fence", Todo),
+		),
+		NewTask("Write report #work", Active),
+	}
+	model.cursorID = model.tasks[2].id
+	press := func(msg tea.KeyPressMsg) {
+		updated, _ := model.Update(msg)
+		model = updated.(Model)
+	}
+	typeText := func(text string) {
+		for _, r := range text {
+			press(tea.KeyPressMsg{Code: r, Text: string(r)})
+		}
+	}
+
+	press(tea.KeyPressMsg{Code: '#', Text: "#"})
+	if model.prompt == nil {
+		t.Fatal("Expected # to prompt for a tag")
+	}
+	typeText("#errand")
+	press(tea.KeyPressMsg{Code: tea.KeyEnter})
+
+	// Matches ignore case, and the untagged parent stays for context
+	ids := model.getAllTaskIDs()
+	wantIDs := []string{model.tasks[0].id, model.tasks[1].id, model.tasks[1].subtasks[0].id}
+	if strings.Join(ids, ",") != strings.Join(wantIDs, ",") {
+		t.Errorf("Expected tagged tasks and their ancestors, got %d tasks", len(ids))
+	}
+	if model.cursorID != ids[0] {
+		t.Error("Expected cursor to move to the first visible task")
+	}
+	press(tea.KeyPressMsg{Code: 'j', Text: "j"})
+	press(tea.KeyPressMsg{Code: 'j', Text: "j"})
+	press(tea.KeyPressMsg{Code: 'j', Text: "j"})
+	if model.cursorID != model.tasks[1].subtasks[0].id {
+		t.Error("Expected navigation to skip filtered-out tasks")
+	}
+
+	model.width, model.height = 80, 24
+	if !strings.Contains(ansi.Strip(model.View()), "tagged #errand") {
+		t.Error("Expected header to indicate the tag filter")
+	}
+
+	// A tag nothing has leaves the filter as it was
+	press(tea.KeyPressMsg{Code: '#', Text: "#"})
+	press(tea.KeyPressMsg{Code: 'u', Mod: tea.ModCtrl})
+	typeText("missing")
+	press(tea.KeyPressMsg{Code: tea.KeyEnter})
+	if model.filterTag != "errand" {
+		t.Errorf("Expected the errand filter to stay, got %q", model.filterTag)
+	}
+
+	// ESC clears the filter
+	press(tea.KeyPressMsg{Code: tea.KeyEscape})
+	if model.filterTag != "" || len(model.getAllTaskIDs()) != 5 {
+		t.Error("Expected ESC to clear the tag filter")
+	}
+}
+
+func TestTagsRenderedInColor(t *testing.T) {
+	model := NewModel()
+	title := "Buy milk, eggs and flour for the weekend #errand before the shops close today"
+	task := NewTask(title, Todo)
+	width := 30
+
+	// Done tasks aren't colored, so they show where the lines should break
+	plain := model.renderText(NewTask(title, Done), width, false, false, nil)
+	row := model.renderText(task, width, false, false, nil)
+	if ansi.Strip(row) != ansi.Strip(plain) {
+		t.Errorf("Expected tag coloring to keep the text, wrapping and padding, got %q", ansi.Strip(row))
+	}
+	tagged := TagStyle.Render("#errand")
+	if !strings.Contains(row, tagged) {
+		t.Errorf("Expected the tag to be rendered with TagStyle, got %q", row)
+	}
+	if strings.Contains(row, TagStyle.Render("today")) {
+		t.Error("Expected only the tag to be colored")
+	}
+}
+
+func TestSinkDoneTasks(t *testing.T) {
+	model := NewModel()
+	model.sinkDoneTasks = true
+	child := NewTask("Child A", Active, NewTask("Grandchild", Todo))
+	model.tasks = []Task{
+		NewTask("Parent", Todo, child, NewTask("Child B", Todo), NewTask("Child C", Done)),
+		NewTask("Other", Todo),
+	}
+	model.cursorID = child.id
+
+	// Marking the nested Active task Done sinks it below its siblings with its subtree
+	model.changeTaskStatusForward()
+	siblings := model.tasks[0].subtasks
+	if siblings[len(siblings)-1].id != child.id {
+		t.Fatalf("Expected completed task at the bottom of its group, got %q", siblings[len(siblings)-1].title)
+	}
+	if len(siblings[len(siblings)-1].subtasks) != 1 {
+		t.Error("Expected sunk task to keep its subtasks")
+	}
+	if model.tasks[1].title != "Other" || len(model.tasks) != 2 {
+		t.Error("Expected other groups to be unaffected")
+	}
+	if model.cursorID != child.id {
+		t.Error("Expected cursor to follow the sunk task")
+	}
+
+	// Reopening leaves the task where it is
+	model.changeTaskStatusBackward()
+	siblings = model.tasks[0].subtasks
+	if siblings[len(siblings)-1].id != child.id {
+		t.Error("Expected reopened task to stay at the bottom")
+	}
+
+	// Status change and move are undone together
+	model.undo()
+	model.undo()
+	if model.tasks[0].subtasks[0].id != child.id || model.tasks[0].subtasks[0].status != Active {
+		t.Error("Expected undo to restore both status and position in one step")
+	}
+
+	// Without the option tasks stay in place
+	model.sinkDoneTasks = false
+	model.changeTaskStatusForward()
+	if model.tasks[0].subtasks[0].id != child.id {
+		t.Error("Expected task to stay in place when sinking is disabled")
+	}
+
+	// b turns sinking on for the session
+	model.undo()
+	updated, _ := model.Update(tea.KeyPressMsg{Code: 'b', Text: "b"})
+	model = updated.(Model)
+	if !model.sinkDoneTasks {
+		t.Fatal("Expected b to turn sinking on")
+	}
+	model.changeTaskStatusForward()
+	siblings = model.tasks[0].subtasks
+	if siblings[len(siblings)-1].id != child.id || model.cursorID != child.id {
+		t.Error("Expected the task to sink with the cursor following it after toggling")
+	}
+}
+
+func TestReverseSiblings(t *testing.T) {
+	model := NewModel()
+	model.tasks = GetMinimalMockTasks()
+	originalIDs := []string{model.tasks[0].id, model.tasks[1].id, model.tasks[2].id, model.tasks[3].id}
+	subtaskIDs := []string{model.tasks[3].subtasks[0].id, model.tasks[3].subtasks[1].id}
+	model.cursorID = model.tasks[1].id
+
+	model.reverseSiblings()
+
+	for i, id := range originalIDs {
+		if model.tasks[len(originalIDs)-1-i].id != id {
+			t.Fatalf("Expected top-level order to be reversed")
+		}
+	}
+	// Subtrees move with their tasks and keep their own order
+	if len(model.tasks[0].subtasks) != 2 || model.tasks[0].subtasks[0].id != subtaskIDs[0] || model.tasks[0].subtasks[1].id != subtaskIDs[1] {
+		t.Error("Expected subtasks to move with their parent unchanged")
+	}
+	if model.cursorID != originalIDs[1] {
+		t.Error("Expected cursor to stay on the same task")
+	}
+
+	// Reversing a nested group leaves the top level alone
+	model.cursorID = subtaskIDs[0]
+	model.reverseSiblings()
+	if model.tasks[0].subtasks[0].id != subtaskIDs[1] || model.tasks[0].id != originalIDs[3] {
+		t.Error("Expected only the nested group to be reversed")
+	}
+
+	// One undo per reversal
+	model.undo()
+	model.undo()
+	if model.tasks[0].id != originalIDs[0] {
+		t.Error("Expected undo to restore the original order")
+	}
+}
+
+func TestCollapsedTaskShowsHiddenCount(t *testing.T) {
+	model := NewModel()
+	model.tasks = GetMinimalMockTasks()
+	model.tasks[3].subtasks[0].subtasks = []Task{NewTask("Nested", Todo)}
+	model.tasks[3].collapsed = true
+
+	meta := ansi.Strip(model.renderMeta(model.tasks[3]))
+	if !strings.Contains(meta, FoldCollapsedSymbol+" (2)") {
+		t.Errorf("Expected collapsed marker with 2 hidden direct children, got %q", meta)
+	}
+
+	// Hidden descendants are not navigable
+	if len(model.getAllTaskIDs()) != 4 {
+		t.Errorf("Expected only the 4 top-level tasks to be visible, got %d", len(model.getAllTaskIDs()))
+	}
+
+	// The count follows changes made under the fold
+	model.tasks[3].subtasks = append(model.tasks[3].subtasks, NewTask("Added", Todo))
+	meta = ansi.Strip(model.renderMeta(model.tasks[3]))
+	if !strings.Contains(meta, FoldCollapsedSymbol+" (3)") {
+		t.Errorf("Expected count to update to 3, got %q", meta)
+	}
+
+	// Expanded parents show the expanded marker without a count, and leaves show none
+	model.tasks[3].collapsed = false
+	if meta := ansi.Strip(model.renderMeta(model.tasks[3])); !strings.Contains(meta, FoldExpandedSymbol) || strings.Contains(meta, "(") {
+		t.Errorf("Expected the expanded marker on an expanded task, got %q", meta)
+	}
+	if meta := ansi.Strip(model.renderMeta(model.tasks[0])); strings.Contains(meta, FoldExpandedSymbol) || strings.Contains(meta, FoldCollapsedSymbol) {
+		t.Errorf("Expected no fold marker on a task without subtasks, got %q", meta)
+	}
+}
+
+func TestOpenDirectoryWithoutFile(t *testing.T) {
+	model := NewModel()
+
+	updated, _ := model.Update(tea.KeyPressMsg{Code: 'O', Text: "O"})
+	model = updated.(Model)
+
+	if model.lastError != "" {
+		t.Errorf("Expected no error in a session without a file, got %q", model.lastError)
+	}
+	if model.statusMessage == "" {
+		t.Error("Expected a status message explaining there is no file to open")
+	}
+}
+
+func TestEmptyParentPlaceholders(t *testing.T) {
+	model := NewModel()
+	model.tasks = GetMinimalMockTasks()
+	parent := &model.tasks[3]
+	parent.subtasks[0].status = Active // Both subtasks are now Active
+
+	model.filtering = true
+	model.filterStatus = Todo
+
+	// By default the parent stays visible with a note
+	if !model.isTaskVisible(parent) {
+		t.Fatal("Expected the matching parent to stay visible by default")
+	}
+	if meta := ansi.Strip(model.renderMeta(*parent)); !strings.Contains(meta, "(children hidden)") {
+		t.Errorf("Expected children hidden note, got %q", meta)
+	}
+
+	// Toggling hides the parent instead
+	model.toggleEmptyParents()
+	if model.isTaskVisible(parent) {
+		t.Error("Expected the parent to be hidden once empty parents are hidden")
+	}
+
+	// Without a filter there is nothing to note or hide
+	model.clearFilters()
+	if !model.isTaskVisible(parent) {
+		t.Error("Expected the parent to be visible without a filter")
+	}
+	if meta := ansi.Strip(model.renderMeta(*parent)); strings.Contains(meta, "(children hidden)") {
+		t.Errorf("Expected no note without a filter, got %q", meta)
+	}
+}
+
+func TestAppendAndPrependEditCursor(t *testing.T) {
+	model := NewModel()
+	model.tasks = GetMinimalMockTasks()
+	model.cursorID = model.tasks[2].id
+	title := model.tasks[2].title
+
+	updated, _ := model.Update(tea.KeyPressMsg{Code: 'a', Text: "a"})
+	model = updated.(Model)
+	if !model.editing {
+		t.Fatal("Expected append key to enter edit mode")
+	}
+	if model.textInput.Value() != title || model.textInput.Position() != len([]rune(title)) {
+		t.Errorf("Expected cursor at end of title, got position %d", model.textInput.Position())
+	}
+
+	model.editing = false
+	updated, _ = model.Update(tea.KeyPressMsg{Code: 'i', Text: "i"})
+	model = updated.(Model)
+	if !model.editing || model.textInput.Position() != 0 {
+		t.Errorf("Expected prepend key to edit with cursor at start, got position %d", model.textInput.Position())
+	}
+}
+
+func TestConfirmEdit(t *testing.T) {
+	model := NewModel()
+	model.tasks = GetMinimalMockTasks()
+	model.cursorID = model.tasks[2].id
+	count := len(model.getAllTaskIDs())
+	press := func(msg tea.KeyPressMsg) {
+		updated, _ := model.Update(msg)
+		model = updated.(Model)
+	}
+	confirmEdit := tea.KeyPressMsg{Code: tea.KeyEnter, Mod: tea.ModAlt}
+
+	// The edit is kept and no task is created
+	press(tea.KeyPressMsg{Code: 'a', Text: "a"})
+	press(tea.KeyPressMsg{Code: '!', Text: "!"})
+	press(confirmEdit)
+	if model.editing {
+		t.Error("Expected alt+enter to leave edit mode")
+	}
+	if !strings.HasSuffix(model.tasks[2].title, "!") {
+		t.Errorf("Expected the edit to be saved, got %q", model.tasks[2].title)
+	}
+	if got := len(model.getAllTaskIDs()); got != count {
+		t.Errorf("Expected no new task, got %d tasks instead of %d", got, count)
+	}
+	if model.cursorID != model.tasks[2].id {
+		t.Error("Expected the cursor to stay on the edited task")
+	}
+
+	// An empty new task is deleted as with enter
+	press(tea.KeyPressMsg{Code: tea.KeyEnter})
+	if !model.editing {
+		t.Fatal("Expected enter to create a task and edit it")
+	}
+	press(confirmEdit)
+	if model.editing || len(model.getAllTaskIDs()) != count {
+		t.Errorf("Expected the empty task to be discarded, got %d tasks", len(model.getAllTaskIDs()))
+	}
+}
+
+func TestJumpBack(t *testing.T) {
+	model := NewModel()
+	model.tasks = GetMinimalMockTasks()
+	start := model.tasks[1].id
+	model.cursorID = start
+
+	model.jumpToBottom()
+	ids := model.getAllTaskIDs()
+	if model.cursorID != ids[len(ids)-1] {
+		t.Fatalf("Expected cursor on last visible task, got %s", model.cursorID)
+	}
+	model.jumpToTop()
+	if model.cursorID != ids[0] {
+		t.Fatalf("Expected cursor on first task, got %s", model.cursorID)
+	}
+
+	model.jumpBack()
+	if model.cursorID != ids[len(ids)-1] {
+		t.Errorf("Expected jump back to the bottom task, got %s", model.cursorID)
+	}
+	model.jumpBack()
+	if model.cursorID != start {
+		t.Errorf("Expected jump back to the starting task, got %s", model.cursorID)
+	}
+
+	model.jumpBack()
+	if model.cursorID != start || model.statusMessage == "" {
+		t.Error("Expected an empty history to leave the cursor and report it")
+	}
+
+	// History stays bounded
+	for i := 0; i < maxJumpHistory*2; i++ {
+		model.jumpToTop()
+		model.jumpToBottom()
+	}
+	if len(model.jumpHistory) != maxJumpHistory {
+		t.Errorf("Expected history capped at %d, got %d", maxJumpHistory, len(model.jumpHistory))
+	}
+}
+
+func TestGoToParent(t *testing.T) {
+	model := NewModel()
+	model.tasks = []Task{
+		NewTask("Top", Todo,
+			NewTask("Child", Todo,
+				NewTask("Grandchild", Todo),
+			),
+		),
+	}
+	top, child := &model.tasks[0], &model.tasks[0].subtasks[0]
+	model.cursorID = child.subtasks[0].id
+	press := func() {
+		updated, _ := model.Update(tea.KeyPressMsg{Code: tea.KeyBackspace})
+		model = updated.(Model)
+	}
+
+	press()
+	if model.cursorID != child.id {
+		t.Errorf("Expected cursor on the parent, got %s", model.cursorID)
+	}
+	press()
+	if model.cursorID != top.id {
+		t.Errorf("Expected cursor on the grandparent, got %s", model.cursorID)
+	}
+	press()
+	if model.cursorID != top.id {
+		t.Error("Expected the cursor to stay put at the top level")
+	}
+
+	// A focused group's parent is hidden, so the cursor stays in the group
+	model.cursorID = child.id
+	model.toggleFocusGroup()
+	press()
+	if model.cursorID != child.id {
+		t.Error("Expected the cursor to stay inside the focused group")
+	}
+}
+
+func TestGoToSibling(t *testing.T) {
+	model := NewModel()
+	model.tasks = []Task{
+		NewTask("First", Todo,
+			NewTask("Nephew", Todo,
+				NewTask("Grand-nephew", Todo),
+			),
+			NewTask("Second nephew", Todo),
+		),
+		NewTask("Second", Done),
+		NewTask("Third", Todo,
+			NewTask("Only child", Todo),
+		),
+	}
+	model.cursorID = model.tasks[0].id
+	press := func(r rune) {
+		updated, _ := model.Update(tea.KeyPressMsg{Code: r, Text: string(r)})
+		model = updated.(Model)
+	}
+
+	// Expanded subtrees are skipped rather than descended into
+	press('J')
+	if model.cursorID != model.tasks[1].id {
+		t.Errorf("Expected cursor on the next sibling, got %s", model.cursorID)
+	}
+	press('J')
+	if model.cursorID != model.tasks[2].id {
+		t.Errorf("Expected cursor on the last sibling, got %s", model.cursorID)
+	}
+	press('J')
+	if model.cursorID != model.tasks[2].id {
+		t.Error("Expected the cursor to stay on the last sibling")
+	}
+	press('K')
+	press('K')
+	if model.cursorID != model.tasks[0].id {
+		t.Errorf("Expected cursor back on the first sibling, got %s", model.cursorID)
+	}
+	press('K')
+	if model.cursorID != model.tasks[0].id {
+		t.Error("Expected the cursor to stay on the first sibling")
+	}
+
+	// Nested levels move among their own siblings only
+	model.cursorID = model.tasks[0].subtasks[0].id
+	press('J')
+	if model.cursorID != model.tasks[0].subtasks[1].id {
+		t.Errorf("Expected cursor on the nested sibling, got %s", model.cursorID)
+	}
+	press('J')
+	if model.cursorID != model.tasks[0].subtasks[1].id {
+		t.Error("Expected the cursor not to leave its sibling group")
+	}
+
+	// Siblings hidden by a filter are skipped
+	model.cursorID = model.tasks[0].id
+	model.cycleStatusFilter() // Todo only
+	press('J')
+	if model.cursorID != model.tasks[2].id {
+		t.Errorf("Expected the filtered-out sibling to be skipped, got %s", model.cursorID)
+	}
+}
+
+func TestFuzzyScore(t *testing.T) {
+	if _, ok := fuzzyScore("bke", "Bake the cake"); !ok {
+		t.Error("Expected a subsequence to match")
+	}
+	if _, ok := fuzzyScore("ekb", "Bake the cake"); ok {
+		t.Error("Expected characters out of order not to match")
+	}
+
+	// Consecutive characters and word starts beat scattered ones
+	word, _ := fuzzyScore("cake", "Bake the cake")
+	scattered, _ := fuzzyScore("cake", "Check all kitchen equipment")
+	if word <= scattered {
+		t.Errorf("Expected a whole word to score higher than scattered letters, got %d <= %d", word, scattered)
+	}
+}
+
+func TestFinderJump(t *testing.T) {
+	model := NewModel()
+	model.tasks = []Task{
+		NewTask("Check all kitchen equipment", Todo),
+		NewTask("Buy groceries", Todo),
+		NewTask("Bake", Todo,
+			NewTask("Frost the cake", Todo),
+		),
+	}
+	start := model.tasks[1].id
+	model.cursorID = start
+	press := func(msg tea.KeyPressMsg) {
+		updated, _ := model.Update(msg)
+		model = updated.(Model)
+	}
+	typeText := func(text string) {
+		for _, r := range text {
+			press(tea.KeyPressMsg{Code: r, Text: string(r)})
+		}
+	}
+
+	press(tea.KeyPressMsg{Code: ':', Text: ":"})
+	if model.finder == nil {
+		t.Fatal("Expected : to open the finder")
+	}
+
+	// Typing moves the cursor to the best match as a preview, and keys go to the query
+	typeText("cake")
+	if model.cursorID != model.tasks[2].subtasks[0].id {
+		t.Errorf("Expected the cursor on the best match, got %s", model.cursorID)
+	}
+	if len(model.finder.matches) != 2 {
+		t.Errorf("Expected 2 matches, got %d", len(model.finder.matches))
+	}
+	press(tea.KeyPressMsg{Code: tea.KeyDown})
+	if model.cursorID != model.tasks[0].id {
+		t.Error("Expected down to move to the next match")
+	}
+
+	// ESC restores the cursor
+	press(tea.KeyPressMsg{Code: tea.KeyEscape})
+	if model.finder != nil || model.cursorID != start {
+		t.Error("Expected ESC to close the finder and restore the cursor")
+	}
+
+	// Enter jumps, and the jump can be undone with jump back
+	press(tea.KeyPressMsg{Code: ':', Text: ":"})
+	typeText("frost")
+	press(tea.KeyPressMsg{Code: tea.KeyEnter})
+	if model.finder != nil || model.cursorID != model.tasks[2].subtasks[0].id {
+		t.Fatal("Expected enter to jump to the match")
+	}
+	model.jumpBack()
+	if model.cursorID != start {
+		t.Error("Expected jump back to return to where the finder was opened")
+	}
+
+	// No match leaves the cursor where it was
+	press(tea.KeyPressMsg{Code: ':', Text: ":"})
+	typeText("zzz")
+	if model.cursorID != start {
+		t.Error("Expected no match to keep the cursor in place")
+	}
+	press(tea.KeyPressMsg{Code: tea.KeyEnter})
+	if model.cursorID != start || model.statusMessage == "" {
+		t.Error("Expected enter without a match to keep the cursor and report it")
+	}
+
+	// Folded tasks are found too: named while hidden, and unfolded on enter
+	model.tasks[2].collapsed = true
+	frost := model.tasks[2].subtasks[0].id
+	press(tea.KeyPressMsg{Code: ':', Text: ":"})
+	typeText("frost")
+	if model.cursorID != start || !strings.Contains(ansi.Strip(model.renderFinder()), "hidden: Frost the cake") {
+		t.Errorf("Expected a folded match named in the footer without moving the cursor, got %q", ansi.Strip(model.renderFinder()))
+	}
+	press(tea.KeyPressMsg{Code: tea.KeyEnter})
+	if model.cursorID != frost || model.tasks[2].collapsed {
+		t.Error("Expected enter to unfold the parent and jump to the folded match")
+	}
+}
+
+func TestInlineViewHeightCapped(t *testing.T) {
+	model := NewModel()
+	model.width, model.height = 80, 60
+	model.tasks = GetLargeMockTasks()
+
+	if got := lipgloss.Height(model.View()); got <= maxInlineHeight {
+		t.Fatalf("Expected the alt-screen view to use the terminal height, got %d lines", got)
+	}
+
+	model.SetInline(true)
+	if got := lipgloss.Height(model.View()); got > maxInlineHeight {
+		t.Errorf("Expected inline view capped at %d lines, got %d", maxInlineHeight, got)
+	}
+
+	// Short terminals still fit
+	model.height = 10
+	if got := lipgloss.Height(model.View()); got > 10 {
+		t.Errorf("Expected inline view within terminal height, got %d lines", got)
+	}
+}
+
+func TestASCIIMode(t *testing.T) {
+	model := NewModel()
+	model.SetASCII(true)
+	model.tasks = GetMultiLineMockTasks()
+	model.tasks[4].collapsed = true
+	model.cursorID = model.tasks[0].id
+
+	width := 50
+	var check func(tasks []Task, indentLevel int)
+	check = func(tasks []Task, indentLevel int) {
+		for _, task := range tasks {
+			isSelected := task.id == model.cursorID
+			row := ansi.Strip(model.renderRow(task, width, indentLevel, isSelected, false, nil))
+			for _, r := range row {
+				if r > unicode.MaxASCII {
+					t.Errorf("Expected only ASCII in the row for %q, got %q", task.title, row)
+					break
+				}
+			}
+			lines := strings.Split(row, "\n")
+			if !strings.Contains(lines[0], ASCIIBulletSymbols[task.status]+" "+task.title[:5]) {
+				t.Errorf("Expected %s bullet before %q, got %q", ASCIIBulletSymbols[task.status], task.title, lines[0])
+			}
+			if isSelected && !strings.HasPrefix(lines[0], ">") {
+				t.Errorf("Expected > cursor on the selected row, got %q", lines[0])
+			}
+			if indentLevel > 0 && !strings.Contains(lines[0], "`-[") {
+				t.Errorf("Expected `- connector on subtask row, got %q", lines[0])
+			}
+
+			// Continuation lines still hang under the wider bullet column
+			textStart := CursorWidth + indentLevel*IndentWidth + ASCIIBulletWidth
+			for i, line := range lines {
+				if lipgloss.Width(line) > width {
+					t.Errorf("Line %d of %q is wider than %d: %q", i, task.title, width, line)
+				}
+				if i > 0 && strings.TrimSpace(line[:textStart]) != "" {
+					t.Errorf("Continuation line %d of %q is not hang-indented: %q", i, task.title, line)
+				}
+			}
+			check(task.subtasks, indentLevel+1)
+		}
+	}
+	check(model.tasks, 0)
+
+	if meta := ansi.Strip(model.renderMeta(model.tasks[4])); !strings.Contains(meta, ASCIIFoldCollapsedSymbol+" (2)") {
+		t.Errorf("Expected ASCII fold marker, got %q", meta)
+	}
+	model.tasks[4].collapsed = false
+	if meta := ansi.Strip(model.renderMeta(model.tasks[4])); !strings.Contains(meta, ASCIIFoldExpandedSymbol) {
+		t.Errorf("Expected ASCII expanded marker, got %q", meta)
+	}
+}
+
+func TestSetStatusByNumber(t *testing.T) {
+	model := NewModel()
+	model.tasks = GetMinimalMockTasks()
+	model.cursorID = model.tasks[0].id
+	model.tasks[0].status = Todo
+
+	updated, _ := model.Update(tea.KeyPressMsg{Code: '3', Text: "3"})
+	model = updated.(Model)
+	if got := model.getCurrentTask().status; got != Done {
+		t.Fatalf("Expected Done after pressing 3, got %s", got)
+	}
+	if len(model.undoStack) != 1 {
+		t.Errorf("Expected one undo snapshot, got %d", len(model.undoStack))
+	}
+
+	// Setting the same status again is a no-op
+	updated, _ = model.Update(tea.KeyPressMsg{Code: '3', Text: "3"})
+	model = updated.(Model)
+	if len(model.undoStack) != 1 {
+		t.Errorf("Expected no snapshot for an unchanged status, got %d", len(model.undoStack))
+	}
+
+	updated, _ = model.Update(tea.KeyPressMsg{Code: '2', Text: "2"})
+	model = updated.(Model)
+	if got := model.getCurrentTask().status; got != Active {
+		t.Errorf("Expected Active after pressing 2, got %s", got)
+	}
+}
+
+func TestNormalizeTasks(t *testing.T) {
+	model := NewModel()
+	model.tasks = []Task{
+		NewTaskWithID("a", "Parent", Todo,
+			NewTaskWithID("a", "Duplicate", TaskStatus(9)),
+		),
+	}
+	model.tasks[0].collapsed = true
+	model.cursorID = "a"
+
+	model.normalizeTasks()
+
+	child := model.tasks[0].subtasks[0]
+	if child.id == "a" || child.status != Todo {
+		t.Errorf("Expected duplicate ID replaced and status reset, got %q %s", child.id, child.status)
+	}
+	if !model.tasks[0].collapsed {
+		t.Error("Expected folding to survive normalization")
+	}
+	if len(model.undoStack) != 1 {
+		t.Errorf("Expected normalization to be undoable, got %d snapshots", len(model.undoStack))
+	}
+
+	model.normalizeTasks()
+	if len(model.undoStack) != 1 || model.statusMessage != "Nothing to normalize" {
+		t.Error("Expected no changes on an already normalized list")
+	}
+}
+
+func TestScrollOffsets(t *testing.T) {
+	// Following scrolls only near the bottom edge
+	if got := followOffset(0, 4, 5, 10); got != 0 {
+		t.Errorf("Expected no scroll above the threshold, got %d", got)
+	}
+	if got := followOffset(0, 11, 12, 10); got != 4 {
+		t.Errorf("Expected offset 4 past the threshold, got %d", got)
+	}
+	// ... and scrolls back up to the first line of a row above the top
+	if got := followOffset(10, 6, 9, 10); got != 6 {
+		t.Errorf("Expected offset 6 for a row starting above the view, got %d", got)
+	}
+	if got := followOffset(10, 12, 13, 10); got != 10 {
+		t.Errorf("Expected no scroll while the row stays in view, got %d", got)
+	}
+
+	tests := []struct {
+		name         string
+		cursorBottom int
+		want         int
+	}{
+		{"NearTopStaysAtTop", 3, 0},
+		{"MiddleIsCentered", 20, 15},
+		{"NearBottomStopsAtEnd", 39, 30},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := centeredOffset(tt.cursorBottom, 10, 40); got != tt.want {
+				t.Errorf("Expected offset %d, got %d", tt.want, got)
+			}
+		})
+	}
+
+	// Content shorter than the viewport never scrolls
+	if got := centeredOffset(8, 10, 8); got != 0 {
+		t.Errorf("Expected no scroll for short content, got %d", got)
+	}
+}
+
+func TestCenteredScrollingView(t *testing.T) {
+	model := NewModel()
+	model.width, model.height = 80, 20
+	model.tasks = GetLargeMockTasks()
+	ids := model.getAllTaskIDs()
+	model.cursorID = ids[len(ids)/2]
+
+	updated, _ := model.Update(tea.KeyPressMsg{Code: 'z', Text: "z"})
+	model = updated.(Model)
+	if !model.centerCursor {
+		t.Fatal("Expected z to turn on centered scrolling")
+	}
+
+	lines := strings.Split(ansi.Strip(model.View()), "\n")
+	row := -1
+	for i, line := range lines {
+		if strings.Contains(line, "▐") {
+			row = i
+			break
+		}
+	}
+	if row < 5 || row > 15 {
+		t.Errorf("Expected the selected task near the middle of the view, found at line %d", row)
+	}
+}
+
+func TestPageMovement(t *testing.T) {
+	model := NewModel()
+	model.tasks = GetLargeMockTasks()
+	model.cursorID = model.tasks[0].id
+	updated, _ := model.Update(tea.WindowSizeMsg{Width: 80, Height: 20})
+	model = updated.(Model)
+	press := func(msg tea.KeyPressMsg) {
+		updated, _ := model.Update(msg)
+		model = updated.(Model)
+	}
+	position := func() int {
+		return slices.Index(model.getAllTaskIDs(), model.cursorID)
+	}
+
+	page := model.viewport.Height()
+	press(tea.KeyPressMsg{Code: tea.KeyPgDown})
+	if got := position(); got != page {
+		t.Errorf("Expected page down to move %d tasks, got %d", page, got)
+	}
+	if !strings.Contains(ansi.Strip(model.View()), "▐") {
+		t.Error("Expected the view to scroll to the cursor after paging down")
+	}
+
+	press(tea.KeyPressMsg{Code: 'u', Mod: tea.ModCtrl})
+	if got := position(); got != page-page/2 {
+		t.Errorf("Expected half page up to move back %d tasks, got position %d", page/2, got)
+	}
+	press(tea.KeyPressMsg{Code: 'd', Mod: tea.ModCtrl})
+	if got := position(); got != page-page/2+page/2 {
+		t.Errorf("Expected half page down to move %d tasks, got position %d", page/2, got)
+	}
+
+	// Paging stops at the ends of the list
+	for range len(model.getAllTaskIDs()) {
+		press(tea.KeyPressMsg{Code: 'f', Mod: tea.ModCtrl})
+	}
+	if got, last := position(), len(model.getAllTaskIDs())-1; got != last {
+		t.Errorf("Expected paging down to stop at the last task %d, got %d", last, got)
+	}
+	for range len(model.getAllTaskIDs()) {
+		press(tea.KeyPressMsg{Code: tea.KeyPgUp})
+	}
+	if got := position(); got != 0 {
+		t.Errorf("Expected paging up to stop at the first task, got %d", got)
+	}
+}
+
+func TestTrackScrollMatchesRender(t *testing.T) {
+	model := NewModel()
+	model.tasks = GetLargeMockTasks()
+	model.cursorID = model.tasks[0].id
+	send := func(msg tea.Msg) {
+		updated, _ := model.Update(msg)
+		model = updated.(Model)
+		if _, offset := model.render(); offset != model.yOffset {
+			t.Fatalf("Expected the tracked offset %d to match the rendered one %d after %v", model.yOffset, offset, msg)
+		}
+	}
+
+	send(tea.WindowSizeMsg{Width: 60, Height: 20})
+	for range 30 {
+		send(tea.KeyPressMsg{Code: 'j', Text: "j"})
+	}
+	send(tea.KeyPressMsg{Code: tea.KeyPgDown})
+	send(tea.WindowSizeMsg{Width: 100, Height: 15})
+	send(tea.KeyPressMsg{Code: tea.KeyTab})
+	send(tea.KeyPressMsg{Code: 'G', Text: "G"})
+	send(tea.KeyPressMsg{Code: 'z', Text: "z"})
+	for range 10 {
+		send(tea.KeyPressMsg{Code: 'k', Text: "k"})
+	}
+}
+
+func TestScrollingUpKeepsCursorVisible(t *testing.T) {
+	model := NewModel()
+	model.width, model.height = 80, 20
+	model.tasks = GetLargeMockTasks()
+	press := func(msg tea.KeyPressMsg) {
+		updated, _ := model.Update(msg)
+		model = updated.(Model)
+	}
+	cursorLine := func() int {
+		for i, line := range strings.Split(ansi.Strip(model.View()), "\n") {
+			if strings.Contains(line, "▐") {
+				return i
+			}
+		}
+		return -1
+	}
+
+	press(tea.KeyPressMsg{Code: 'G', Text: "G"})
+	if cursorLine() < 0 {
+		t.Fatal("Expected the last task to be visible after jumping to the bottom")
+	}
+
+	previous := cursorLine()
+	press(tea.KeyPressMsg{Code: 'k', Text: "k"})
+	if line := cursorLine(); line >= previous {
+		t.Errorf("Expected the view to hold still while the cursor moves up within it, cursor went from line %d to %d", previous, line)
+	}
+
+	previous = cursorLine()
+	for i := 1; i < len(model.getAllTaskIDs())-1; i++ {
+		press(tea.KeyPressMsg{Code: 'k', Text: "k"})
+		line := cursorLine()
+		if line < 0 {
+			t.Fatalf("Expected the selected task to stay visible after %d moves up", i+1)
+		}
+		// Moving up never jumps the cursor further down the screen
+		if line > previous {
+			t.Fatalf("Expected the view to scroll up smoothly, cursor moved from line %d to %d", previous, line)
+		}
+		previous = line
+	}
+}
+
+func TestDuplicateTaskShallow(t *testing.T) {
+	model := NewModel()
+	model.tasks = GetMinimalMockTasks()
+	original := model.tasks[3]
+	original.estimate = 90 * time.Minute
+	original.notes = "Check with the team first"
+	original.deferred = true
+	model.tasks[3] = original
+	model.cursorID = original.id
+	model.showDeferred = true
+
+	model.duplicateTaskShallow()
+
+	if len(model.tasks) != 5 {
+		t.Fatalf("Expected a new sibling, got %d top-level tasks", len(model.tasks))
+	}
+	duplicate := model.tasks[4]
+	if model.cursorID != duplicate.id || duplicate.id == original.id {
+		t.Error("Expected cursor on the duplicate with a fresh ID")
+	}
+	if duplicate.title != original.title || duplicate.status != original.status || duplicate.estimate != original.estimate || duplicate.notes != original.notes || !duplicate.deferred {
+		t.Errorf("Expected title, status, estimate, notes and deferral copied, got %+v", duplicate)
+	}
+	if len(duplicate.subtasks) != 0 || len(model.tasks[3].subtasks) != len(original.subtasks) {
+		t.Error("Expected the duplicate without subtasks and the original unchanged")
+	}
+	if len(model.undoStack) != 1 {
+		t.Errorf("Expected one undo snapshot, got %d", len(model.undoStack))
+	}
+}
+
+func TestDeferredTasks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deferred.dot")
+	model := NewModelWithFile(path)
+	model.tasks = GetMinimalMockTasks()
+	deferredID := model.tasks[3].id
+	model.cursorID = deferredID
+
+	model.toggleDeferred()
+
+	if !model.findTaskByID(deferredID).deferred {
+		t.Fatal("Expected the task to be deferred")
+	}
+	for _, id := range model.getAllTaskIDs() {
+		if id == deferredID || id == model.tasks[3].subtasks[0].id {
+			t.Fatal("Expected the deferred task and its subtasks to be hidden")
+		}
+	}
+	if model.cursorID == deferredID {
+		t.Error("Expected the cursor to move off the hidden task")
+	}
+
+	// Showing deferred tasks brings it back
+	model.toggleShowDeferred()
+	if len(model.getAllTaskIDs()) != 6 {
+		t.Errorf("Expected all tasks visible with deferred shown, got %d", len(model.getAllTaskIDs()))
+	}
+
+	// The flag is persisted
+	loaded, _, err := loadTasksFromFile(path)
+	if err != nil {
+		t.Fatalf("Failed to load saved tasks: %v", err)
+	}
+	if !loaded[3].deferred {
+		t.Error("Expected the deferred flag to be saved")
+	}
+
+	// And undoable
+	model.undo()
+	if model.findTaskByID(deferredID).deferred {
+		t.Error("Expected undo to clear the deferred flag")
+	}
+}
+
+func TestAdvanceChildrenStatus(t *testing.T) {
+	model := NewModel()
+	model.tasks = []Task{
+		NewTask("Parent", Todo,
+			NewTask("Todo child", Todo, NewTask("Grandchild", Todo)),
+			NewTask("Active child", Active),
+			NewTask("Done child", Done),
+		),
+	}
+	model.cursorID = model.tasks[0].id
+
+	model.advanceChildrenStatus()
+
+	children := model.tasks[0].subtasks
+	if children[0].status != Active || children[1].status != Done || children[2].status != Done {
+		t.Errorf("Expected Active, Done, Done, got %s, %s, %s", children[0].status, children[1].status, children[2].status)
+	}
+	if children[0].subtasks[0].status != Todo || model.tasks[0].status != Todo {
+		t.Error("Expected the parent and deeper descendants to be unchanged")
+	}
+	if len(model.undoStack) != 1 {
+		t.Errorf("Expected a single undo snapshot, got %d", len(model.undoStack))
+	}
+
+	// A child advanced to Done toggles back to the status it had
+	model.cursorID = children[1].id
+	model.toggleDone()
+	if status := model.tasks[0].subtasks[1].status; status != Active {
+		t.Errorf("Expected toggling Done off to return to Active, got %s", status)
+	}
+	model.undo()
+
+	model.undo()
+	if model.tasks[0].subtasks[0].status != Todo || model.tasks[0].subtasks[1].status != Active {
+		t.Error("Expected one undo to restore every child")
+	}
+}
+
+func TestUndoRedoDescribeChange(t *testing.T) {
+	model := NewModel()
+	model.tasks = []Task{NewTask("Preheat oven", Todo)}
+	model.cursorID = model.tasks[0].id
+
+	model.changeTaskStatusForward()
+	model.undo()
+	if want := "Undid: status forward on 'Preheat oven'"; model.statusMessage != want {
+		t.Errorf("Expected %q, got %q", want, model.statusMessage)
+	}
+
+	model.redo()
+	if want := "Redid: status forward on 'Preheat oven'"; model.statusMessage != want {
+		t.Errorf("Expected %q, got %q", want, model.statusMessage)
+	}
+
+	// The label survives a second round trip
+	model.undo()
+	if want := "Undid: status forward on 'Preheat oven'"; model.statusMessage != want {
+		t.Errorf("Expected %q after redo then undo, got %q", want, model.statusMessage)
+	}
+}
+
+func TestSnapshotLabels(t *testing.T) {
+	model := NewModel()
+	model.tasks = GetMinimalMockTasks()
+	model.cursorID = model.tasks[1].id
+	title := model.tasks[1].title
+
+	model.changeTaskStatusForward()
+	model.changeTaskStatusBackward()
+	model.indentTask()
+	model.unindentTask()
+	model.moveTaskDown()
+	model.setEstimate(model.cursorID, "1h")
+	model.editTaskTitle(model.cursorID, "Renamed")
+	model.deleteCurrentTask()
+
+	want := []string{
+		"status forward on '" + title + "'",
+		"status back on '" + title + "'",
+		"indent on '" + title + "'",
+		"unindent on '" + title + "'",
+		"move down on '" + title + "'",
+		"estimate change on '" + title + "'",
+		"edit on '" + title + "'",
+		"delete on 'Renamed'",
+	}
+	if len(model.undoStack) != len(want) {
+		t.Fatalf("Expected %d snapshots, got %d", len(want), len(model.undoStack))
+	}
+	for i, snapshot := range model.undoStack {
+		if snapshot.label != want[i] {
+			t.Errorf("Snapshot %d: expected label %q, got %q", i, want[i], snapshot.label)
+		}
+	}
+}
+
+func TestUndoHistoryBrowser(t *testing.T) {
+	model := NewModel()
+	model.width, model.height = 80, 30
+	model.tasks = []Task{NewTask("Preheat oven", Todo)}
+	model.cursorID = model.tasks[0].id
+
+	model.changeTaskStatusForward()
+	model.editTaskTitle(model.cursorID, "Preheat the oven")
+	model.changeTaskStatusForward()
+
+	press := func(msg tea.KeyPressMsg) {
+		updated, _ := model.Update(msg)
+		model = updated.(Model)
+	}
+
+	press(tea.KeyPressMsg{Code: 'U', Text: "U"})
+	if model.history == nil {
+		t.Fatal("Expected U to open the undo history")
+	}
+	view := ansi.Strip(model.View())
+	first := strings.Index(view, "1. status forward on 'Preheat the oven'")
+	last := strings.Index(view, "3. status forward on 'Preheat oven'")
+	if first < 0 || last < 0 || first > last {
+		t.Errorf("Expected history listed most recent first, got:\n%s", view)
+	}
+
+	// Jump to before the edit: two undos at once
+	press(tea.KeyPressMsg{Code: 'j', Text: "j"})
+	press(tea.KeyPressMsg{Code: tea.KeyEnter})
+	if model.history != nil {
+		t.Error("Expected the history to close after selecting an entry")
+	}
+	task := model.getCurrentTask()
+	if task.title != "Preheat oven" || task.status != Active {
+		t.Errorf("Expected the state before the edit, got %q (%s)", task.title, task.status)
+	}
+	if len(model.undoStack) != 1 || len(model.redoStack) != 2 {
+		t.Errorf("Expected 1 undo and 2 redo entries, got %d and %d", len(model.undoStack), len(model.redoStack))
+	}
+
+	// Escape closes without changing anything
+	press(tea.KeyPressMsg{Code: 'U', Text: "U"})
+	press(tea.KeyPressMsg{Code: tea.KeyEscape})
+	if model.history != nil || len(model.undoStack) != 1 {
+		t.Error("Expected escape to close the history without undoing")
+	}
+}
+
+func TestFocusSiblingGroup(t *testing.T) {
+	model := NewModel()
+	model.width, model.height = 80, 30
+	child := NewTask("Child", Todo, NewTask("Grandchild", Todo))
+	model.tasks = []Task{
+		NewTask("Project", Todo, child, NewTask("Sibling", Todo)),
+		NewTask("Elsewhere", Todo, NewTask("Distant", Todo)),
+	}
+	model.cursorID = child.id
+
+	press := func() {
+		updated, _ := model.Update(tea.KeyPressMsg{Code: 'f', Text: "f"})
+		model = updated.(Model)
+	}
+
+	press()
+	ids := model.getAllTaskIDs()
+	if len(ids) != 3 || ids[0] != child.id {
+		t.Fatalf("Expected only the sibling group and its subtrees, got %d tasks", len(ids))
+	}
+	view := ansi.Strip(model.View())
+	if !strings.Contains(view, "in 'Project'") {
+		t.Errorf("Expected the header to name the focused group, got:\n%s", view)
+	}
+	if strings.Contains(view, "Elsewhere") || strings.Contains(view, "Distant") {
+		t.Error("Expected distant branches to be hidden")
+	}
+
+	// Toggling again restores the full tree
+	press()
+	if len(model.getAllTaskIDs()) != 6 {
+		t.Error("Expected toggling off to show every task")
+	}
+
+	// Top-level tasks already see the whole top-level group
+	model.cursorID = model.tasks[0].id
+	press()
+	if model.focusParentID != "" {
+		t.Error("Expected focusing from the top level to change nothing")
+	}
+}
+
+func TestDoneLastDisplayOrder(t *testing.T) {
+	model := NewModel()
+	model.width, model.height = 80, 30
+	model.tasks = []Task{
+		NewTask("Shipped", Done),
+		NewTask("Project", Todo,
+			NewTask("Written", Done),
+			NewTask("Review", Active),
+		),
+		NewTask("Plan", Todo),
+	}
+	model.cursorID = model.tasks[0].id
+	stored := model.getAllTaskIDs()
+
+	updated, _ := model.Update(tea.KeyPressMsg{Code: 'o', Text: "o"})
+	model = updated.(Model)
+
+	var titles []string
+	for _, id := range model.getAllTaskIDs() {
+		titles = append(titles, model.findTaskByID(id).title)
+	}
+	if got := strings.Join(titles, ","); got != "Project,Review,Written,Plan,Shipped" {
+		t.Errorf("Expected Done tasks last in each group, got %s", got)
+	}
+	view := ansi.Strip(model.View())
+	if strings.Index(view, "Plan") > strings.Index(view, "Shipped") {
+		t.Error("Expected the view to render Done tasks last")
+	}
+	if model.tasks[0].title != "Shipped" || model.tasks[1].subtasks[0].title != "Written" {
+		t.Error("Expected the stored order to be left alone")
+	}
+
+	updated, _ = model.Update(tea.KeyPressMsg{Code: 'o', Text: "o"})
+	model = updated.(Model)
+	if got := model.getAllTaskIDs(); strings.Join(got, ",") != strings.Join(stored, ",") {
+		t.Error("Expected toggling off to return to stored order")
+	}
+}
+
+func TestConfirmDeleteParent(t *testing.T) {
+	model := NewModel()
+	model.tasks = []Task{
+		NewTask("Leaf", Todo),
+		NewTask("Parent", Todo,
+			NewTask("Child", Todo,
+				NewTask("Grandchild", Todo),
+			),
+		),
+	}
+	press := func(r rune) {
+		updated, _ := model.Update(tea.KeyPressMsg{Code: r, Text: string(r)})
+		model = updated.(Model)
+	}
+
+	// Leaf tasks are deleted straight away
+	model.cursorID = model.tasks[0].id
+	press('d')
+	if model.confirm != nil || len(model.tasks) != 1 {
+		t.Fatal("Expected a leaf task to be deleted without confirmation")
+	}
+
+	// A parent asks first, and declining keeps it
+	model.cursorID = model.tasks[0].id
+	press('d')
+	if model.confirm == nil || !strings.Contains(model.confirm.message, "2 subtasks") {
+		t.Fatalf("Expected a confirmation mentioning the subtasks, got %+v", model.confirm)
+	}
+	press('n')
+	if len(model.tasks) != 1 {
+		t.Error("Expected declining to keep the task")
+	}
+
+	press('d')
+	press('y')
+	if len(model.tasks) != 0 {
+		t.Error("Expected confirming to delete the task and its subtasks")
+	}
+
+	// With confirmation turned off parents go at once
+	model.undo()
+	model.SetConfirmDelete(false)
+	model.cursorID = model.tasks[0].id
+	press('d')
+	if model.confirm != nil || len(model.tasks) != 0 {
+		t.Error("Expected no confirmation with confirmDelete off")
+	}
+}
+
+func TestClearDoneTasks(t *testing.T) {
+	model := NewModel()
+	model.tasks = []Task{
+		NewTask("Open", Todo),
+		NewTask("Finished", Done,
+			NewTask("Finished child", Done),
+		),
+		NewTask("Done parent", Done,
+			NewTask("Done child", Done),
+			NewTask("Open child", Active),
+		),
+		NewTask("Last done", Done),
+	}
+	model.cursorID = model.tasks[1].subtasks[0].id
+	press := func() {
+		updated, _ := model.Update(tea.KeyPressMsg{Code: 'd', Mod: tea.ModAlt})
+		model = updated.(Model)
+	}
+
+	press()
+	var titles []string
+	for _, id := range model.getAllTaskIDs() {
+		titles = append(titles, model.findTaskByID(id).title)
+	}
+	if got := strings.Join(titles, ", "); got != "Open, Done parent, Open child" {
+		t.Errorf("Expected only fully Done subtrees removed, got %s", got)
+	}
+	if model.cursorID != model.tasks[1].id {
+		t.Errorf("Expected the cursor on the next remaining task, got %q", model.findTaskByID(model.cursorID).title)
+	}
+
+	// One undo brings everything back
+	model.undo()
+	if len(model.getAllTaskIDs()) != 7 {
+		t.Errorf("Expected undo to restore all 7 tasks, got %d", len(model.getAllTaskIDs()))
+	}
+
+	// Nothing to clear leaves the history alone
+	model.tasks = []Task{NewTask("Open", Todo)}
+	model.cursorID = model.tasks[0].id
+	undoSteps := len(model.undoStack)
+	press()
+	if len(model.undoStack) != undoSteps || model.statusMessage == "" {
+		t.Error("Expected no change and a status message when there is nothing to clear")
+	}
+}
+
+func TestPinnedTasks(t *testing.T) {
+	model := NewModel()
+	model.tasks = []Task{
+		NewTask("First", Todo),
+		NewTask("Project", Todo,
+			NewTask("Key milestone", Active),
+		),
+		NewTask("Last", Todo),
+	}
+	model.tasks[1].collapsed = true
+	milestone := model.tasks[1].subtasks[0].id
+	model.width, model.height = 80, 30
+	press := func(msg tea.KeyPressMsg) {
+		updated, _ := model.Update(msg)
+		model = updated.(Model)
+	}
+	char := func(r rune) tea.KeyPressMsg { return tea.KeyPressMsg{Code: r, Text: string(r)} }
+
+	// Pinning is undoable and persists through TaskData
+	model.cursorID = model.tasks[2].id
+	press(char('*'))
+	if !model.tasks[2].Pinned() {
+		t.Fatal("Expected * to pin the task")
+	}
+	if meta := ansi.Strip(model.renderMeta(model.tasks[2])); !strings.Contains(meta, PinnedSymbol) {
+		t.Errorf("Expected a pinned marker, got %q", meta)
+	}
+	model.undo()
+	if model.tasks[2].Pinned() {
+		t.Error("Expected undo to unpin the task")
+	}
+	model.tasks[1].subtasks[0].pinned = true
+	if !FromTaskData(ToTaskData(model.tasks[1])).subtasks[0].Pinned() {
+		t.Error("Expected pinned to round-trip through TaskData")
+	}
+
+	// The section lists pinned tasks above the list, even folded away ones
+	if strings.Contains(ansi.Strip(model.View()), "Key milestone") {
+		t.Fatal("Expected the pinned section to be hidden by default")
+	}
+	press(char('&'))
+	view := ansi.Strip(model.View())
+	if !strings.Contains(view, "Key milestone") || strings.Index(view, "Key milestone") > strings.Index(view, "First") {
+		t.Errorf("Expected the pinned task above the list, got:\n%s", view)
+	}
+
+	// Moving up from the first task enters the section, which is read-only
+	model.cursorID = model.tasks[0].id
+	press(tea.KeyPressMsg{Code: tea.KeyUp})
+	if model.pinnedCursor != 1 {
+		t.Fatalf("Expected the cursor in the pinned section, got %d", model.pinnedCursor)
+	}
+	press(char('d'))
+	if len(model.tasks) != 3 || model.statusMessage == "" {
+		t.Error("Expected pinned entries to be read-only")
+	}
+
+	// Enter jumps to the real task, unfolding its parent
+	press(tea.KeyPressMsg{Code: tea.KeyEnter})
+	if model.pinnedCursor != 0 || model.cursorID != milestone {
+		t.Errorf("Expected a jump to the pinned task, got cursor %q", model.cursorID)
+	}
+	if model.tasks[1].collapsed {
+		t.Error("Expected the pinned task's parent to be unfolded")
+	}
+	if len(model.undoStack) != 0 {
+		t.Errorf("Expected unfolding to take no snapshot, got %d", len(model.undoStack))
+	}
+
+	// Down from the last entry returns to the list
+	model.cursorID = model.tasks[0].id
+	press(tea.KeyPressMsg{Code: tea.KeyUp})
+	press(tea.KeyPressMsg{Code: tea.KeyDown})
+	if model.pinnedCursor != 0 || model.cursorID != model.tasks[0].id {
+		t.Error("Expected down from the section to return to the first task")
+	}
+
+	// A long section shows a few entries, scrolling with the cursor
+	for i := range maxPinnedRows + 3 {
+		task := NewTask(fmt.Sprintf("Extra %d", i), Todo)
+		task.pinned = true
+		model.tasks = append(model.tasks, task)
+	}
+	section := ansi.Strip(model.renderPinnedSection(80))
+	if lines := strings.Count(section, "\n"); lines != maxPinnedRows+1 {
+		t.Errorf("Expected the heading and %d entries, got %d lines:\n%s", maxPinnedRows, lines, section)
+	}
+	if !strings.Contains(section, fmt.Sprintf("(1-%d of %d)", maxPinnedRows, maxPinnedRows+4)) {
+		t.Errorf("Expected the heading to count the entries, got:\n%s", section)
+	}
+	press(tea.KeyPressMsg{Code: tea.KeyUp})
+	if section := ansi.Strip(model.renderPinnedSection(80)); !strings.Contains(section, fmt.Sprintf("Extra %d", maxPinnedRows+2)) || strings.Contains(section, "Key milestone") {
+		t.Errorf("Expected the section scrolled to the last entry, got:\n%s", section)
+	}
+}
+
+func TestRevealTaskSavesFolds(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reveal.dot")
+	if err := storage.SaveTasks(path, []storage.TaskData{
+		{ID: "outer", Title: "Outer", Collapsed: true, Subtasks: []storage.TaskData{
+			{ID: "inner", Title: "Inner", Collapsed: true, Subtasks: []storage.TaskData{{ID: "deep", Title: "Deep"}}},
+		}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Read-only lists unfold in the view but not in the file
+	model := NewModelWithFile(path)
+	model.SetReadOnly(true)
+	model.revealTask("deep")
+	if model.tasks[0].collapsed || model.tasks[0].subtasks[0].collapsed {
+		t.Error("Expected every ancestor unfolded")
+	}
+	if saved, _ := storage.LoadTasks(path); !saved[0].Collapsed || model.unsaved {
+		t.Error("Expected a read-only list to be left as saved")
+	}
+
+	model = NewModelWithFile(path)
+	model.revealTask("deep")
+	if saved, _ := storage.LoadTasks(path); saved[0].Collapsed || saved[0].Subtasks[0].Collapsed {
+		t.Error("Expected the unfolded ancestors to be saved")
+	}
+}
+
+func TestTrashRestore(t *testing.T) {
+	model := NewModel()
+	model.width, model.height = 80, 30
+	groceries := NewTask("Groceries", Todo, NewTask("Milk", Todo), NewTask("Eggs", Done))
+	model.tasks = []Task{NewTask("Keep", Todo), groceries, NewTask("Blank", Todo)}
+	model.cursorID = groceries.id
+
+	press := func(msg tea.KeyPressMsg) {
+		updated, _ := model.Update(msg)
+		model = updated.(Model)
+	}
+
+	press(tea.KeyPressMsg{Code: 'T', Text: "T"})
+	if model.trashView != nil {
+		t.Fatal("Expected an empty trash not to open")
+	}
+
+	// Deleting keeps a copy of the subtree, and blank new tasks are skipped
+	model.deleteCurrentTask()
+	model.cursorID = model.tasks[1].id
+	model.editTaskTitle(model.cursorID, "")
+	model.deleteCurrentTask()
+	if len(model.trash) != 1 || len(model.trash[0].subtasks) != 2 {
+		t.Fatalf("Expected the deleted subtree in the trash, got %d entries", len(model.trash))
+	}
+
+	// The trash survives other edits that push the delete out of undo's reach
+	model.undoStack = nil
+	press(tea.KeyPressMsg{Code: 'T', Text: "T"})
+	if model.trashView == nil {
+		t.Fatal("Expected T to open the trash")
+	}
+	if view := ansi.Strip(model.View()); !strings.Contains(view, "1. Groceries (+2 subtasks)") {
+		t.Errorf("Expected the trash to list the deleted task, got:\n%s", view)
+	}
+	press(tea.KeyPressMsg{Code: tea.KeyEnter})
+	if model.trashView != nil || len(model.trash) != 0 {
+		t.Error("Expected restoring to close the trash and take the entry out")
+	}
+	restored := model.tasks[len(model.tasks)-1]
+	if restored.id != groceries.id || len(restored.subtasks) != 2 || model.cursorID != groceries.id {
+		t.Error("Expected the subtree restored at the end of the top level with its ID, under the cursor")
+	}
+
+	// Restoring a task whose delete was undone gives it fresh IDs
+	model.deleteCurrentTask()
+	model.undo()
+	model.restoreFromTrash(0)
+	copied := model.tasks[len(model.tasks)-1]
+	if copied.id == groceries.id || copied.subtasks[0].id == groceries.subtasks[0].id {
+		t.Error("Expected colliding IDs to be replaced on restore")
+	}
+	if copied.title != "Groceries" || model.findTaskByID(groceries.id) == nil {
+		t.Error("Expected both the undone original and the restored copy")
+	}
+}
+
+func TestTrashRestoreUndoRedo(t *testing.T) {
+	model := NewModel()
+	groceries := NewTask("Groceries", Todo, NewTask("Milk", Todo), NewTask("Eggs", Done))
+	model.tasks = []Task{NewTask("Keep", Todo), groceries}
+	model.cursorID = groceries.id
+
+	model.deleteCurrentTask()
+	model.restoreFromTrash(0)
+	if len(model.trash) != 0 || model.findTaskByID(groceries.id) == nil {
+		t.Fatal("Expected the restore to move the subtree out of the trash")
+	}
+
+	// Undoing the restore puts the subtree back in the trash
+	model.undo()
+	if model.findTaskByID(groceries.id) != nil {
+		t.Error("Expected undo to take the restored subtree out of the list")
+	}
+	if len(model.trash) != 1 || model.trash[0].id != groceries.id || len(model.trash[0].subtasks) != 2 {
+		t.Fatalf("Expected undo to put the subtree back in the trash, got %d entries", len(model.trash))
+	}
+
+	model.redo()
+	if len(model.trash) != 0 {
+		t.Error("Expected redo to take the subtree out of the trash again")
+	}
+	restored := model.findTaskByID(groceries.id)
+	if restored == nil || len(restored.subtasks) != 2 {
+		t.Error("Expected redo to restore the subtree")
+	}
+
+	// Edits to the restored copy don't reach the entry kept for undo
+	restored.subtasks[0].title = "Oat milk"
+	model.undo()
+	if model.trash[0].subtasks[0].title != "Milk" {
+		t.Errorf("Expected the trash entry to be unchanged, got %q", model.trash[0].subtasks[0].title)
+	}
+}
+
+func TestAutoCompleteParents(t *testing.T) {
+	leaf := NewTask("Last leaf", Active)
+	tree := func() []Task {
+		return []Task{
+			NewTask("Root", Active,
+				NewTask("Middle", Active,
+					NewTask("First leaf", Done),
+					leaf,
+				),
+				NewTask("Other", Done),
+			),
+		}
+	}
+
+	// Off by default: parents are left alone
+	model := NewModel()
+	model.tasks = tree()
+	model.cursorID = leaf.id
+	model.changeTaskStatusForward()
+	if model.tasks[0].status != Active || model.tasks[0].subtasks[0].status != Active {
+		t.Error("Expected parents unchanged without auto-complete")
+	}
+
+	model = NewModel()
+	model.tasks = tree()
+	model.cursorID = leaf.id
+	updated, _ := model.Update(tea.KeyPressMsg{Code: 'C', Text: "C"})
+	model = updated.(Model)
+	if !model.autoRollup {
+		t.Fatal("Expected C to turn on auto-complete parents")
+	}
+
+	// Completing the last leaf cascades to the root in one undo step
+	undoDepth := len(model.undoStack)
+	model.changeTaskStatusForward()
+	if model.tasks[0].subtasks[0].status != Done || model.tasks[0].status != Done {
+		t.Errorf("Expected Middle and Root Done, got %s and %s", model.tasks[0].subtasks[0].status, model.tasks[0].status)
+	}
+	if len(model.undoStack) != undoDepth+1 {
+		t.Errorf("Expected one snapshot for the cascade, got %d", len(model.undoStack)-undoDepth)
+	}
+
+	// Reopening the leaf reopens its ancestors
+	model.changeTaskStatusBackward()
+	if model.tasks[0].subtasks[0].status != Active || model.tasks[0].status != Active {
+		t.Errorf("Expected Middle and Root back to Active, got %s and %s", model.tasks[0].subtasks[0].status, model.tasks[0].status)
+	}
+
+	model.setStatusDirect(Done)
+	model.undo()
+	if model.tasks[0].status != Active || model.findTaskByID(leaf.id).status != Active {
+		t.Error("Expected undo to restore the leaf and its ancestors together")
+	}
+}
+
+func TestStatusFollowUpsSaved(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rollup.dot")
+	model := NewModelWithFile(path)
+	model.sinkDoneTasks, model.autoRollup = true, true
+	leaf := NewTask("Leaf", Active)
+	model.tasks = []Task{NewTask("Parent", Active, leaf), NewTask("Next", Todo)}
+	model.cursorID = leaf.id
+
+	// The sunk and completed parent is saved along with the leaf
+	model.changeTaskStatusForward()
+	saved, _, err := loadTasksFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved[1].title != "Parent" || saved[1].status != Done || saved[1].subtasks[0].status != Done {
+		t.Errorf("Expected the parent completed and sunk in the saved file, got %q %s", saved[1].title, saved[1].status)
+	}
+}
+
+func TestAutoAdvanceOnDone(t *testing.T) {
+	model := NewModel()
+	model.tasks = []Task{
+		NewTask("First", Active),
+		NewTask("Already done", Done),
+		NewTask("Next", Todo),
+	}
+	model.cursorID = model.tasks[0].id
+
+	// Off by default: the cursor stays put
+	model.changeTaskStatusForward()
+	if model.cursorID != model.tasks[0].id {
+		t.Fatal("Expected the cursor to stay without auto-advance")
+	}
+
+	model.undo()
+	model.autoAdvance = true
+	model.changeTaskStatusForward()
+	if model.cursorID != model.tasks[2].id {
+		t.Errorf("Expected the cursor to skip Done tasks to 'Next', got %q", model.getCurrentTask().title)
+	}
+
+	// Moving Todo to Active is not a completion
+	model.changeTaskStatusForward()
+	if model.cursorID != model.tasks[2].id {
+		t.Error("Expected the cursor to stay when a task becomes Active")
+	}
+
+	// With nothing incomplete after it, the cursor stays on the completed task
+	model.changeTaskStatusForward()
+	if model.cursorID != model.tasks[2].id {
+		t.Error("Expected the cursor to stay on the last task")
+	}
+}
+
+func TestCustomBulletSymbols(t *testing.T) {
+	cfg := config.Default()
+	cfg.BulletSymbols = map[string]string{"active": "▶", "todo": "too long"}
+	model := NewModelWithConfig("", cfg)
+
+	if got := ansi.Strip(model.renderBullet(Active, false, false)); got != "▶ " {
+		t.Errorf("Expected overridden Active bullet, got %q", got)
+	}
+	if got := ansi.Strip(model.renderBullet(Todo, false, false)); got != BulletSymbols[Todo]+" " {
+		t.Errorf("Expected the invalid Todo override to fall back, got %q", got)
+	}
+	if BulletSymbols[Active] == "▶" {
+		t.Error("Expected the default bullet set to be left unchanged")
+	}
+
+	// Overrides apply over the ASCII bullets too, and survive switching back
+	model.SetASCII(true)
+	if got := model.bulletSymbols[Active]; got != "▶" {
+		t.Errorf("Expected the override to apply in ASCII mode, got %q", got)
+	}
+	if got := model.bulletSymbols[Todo]; got != ASCIIBulletSymbols[Todo] {
+		t.Errorf("Expected the ASCII Todo bullet, got %q", got)
+	}
+	model.SetASCII(false)
+	if model.bulletSymbols[Active] != "▶" || model.bulletSymbols[Todo] != BulletSymbols[Todo] {
+		t.Errorf("Expected the Unicode bullets with the override back, got %v", model.bulletSymbols)
+	}
+}
+
+func TestWrapInNewParent(t *testing.T) {
+	model := NewModel()
+	model.tasks = GetMinimalMockTasks()
+	wrapped := model.tasks[1]
+	model.cursorID = wrapped.id
+
+	press := func(msg tea.KeyPressMsg) {
+		updated, _ := model.Update(msg)
+		model = updated.(Model)
+	}
+
+	press(tea.KeyPressMsg{Code: 'W', Text: "W"})
+	newParent := model.tasks[1]
+	if !model.editing || model.cursorID != newParent.id {
+		t.Fatal("Expected to be editing the new parent")
+	}
+	if len(newParent.subtasks) != 1 || newParent.subtasks[0].id != wrapped.id {
+		t.Fatalf("Expected the task wrapped as the only child, got %+v", newParent.subtasks)
+	}
+	if len(model.tasks) != 4 {
+		t.Errorf("Expected the parent to take the task's place, got %d top-level tasks", len(model.tasks))
+	}
+
+	// Cancelling with an empty title unwraps rather than deleting the child
+	press(tea.KeyPressMsg{Code: tea.KeyEscape})
+	if model.tasks[1].id != wrapped.id || len(model.tasks) != 4 {
+		t.Errorf("Expected the wrapped task restored in place, got %q", model.tasks[1].title)
+	}
+	if model.cursorID != wrapped.id {
+		t.Error("Expected the cursor back on the wrapped task")
+	}
+	if len(model.undoStack) != 1 {
+		t.Errorf("Expected wrapping and cancelling to leave one undo entry, got %d", len(model.undoStack))
+	}
+
+	// Other parents left without a title are deleted as before
+	model.tasks = []Task{NewTask("", Todo, NewTask("Child", Todo)), NewTask("Next", Todo)}
+	model.cursorID = model.tasks[0].id
+	model.discardEmptyTask()
+	if len(model.tasks) != 1 || model.tasks[0].title != "Next" {
+		t.Errorf("Expected the untitled parent and its subtask deleted, got %d tasks", len(model.tasks))
+	}
+
+	// A wrap that got a title isn't unwrapped by a later empty edit
+	model.cursorID = model.tasks[0].id
+	press(tea.KeyPressMsg{Code: 'W', Text: "W"})
+	press(tea.KeyPressMsg{Code: 'P', Text: "P"})
+	press(tea.KeyPressMsg{Code: tea.KeyEnter, Mod: tea.ModAlt})
+	if model.tasks[0].title != "P" || model.wrapParentID != "" {
+		t.Fatalf("Expected the new parent titled, got %q", model.tasks[0].title)
+	}
+	model.editTaskTitle(model.cursorID, "")
+	model.discardEmptyTask()
+	if len(model.tasks) != 0 {
+		t.Errorf("Expected the emptied parent deleted, got %d tasks", len(model.tasks))
+	}
+}
+
+func TestToggleFold(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fold.dot")
+	model := NewModelWithFile(path)
+	model.tasks = GetMinimalMockTasks()
+	parent := model.tasks[3]
+	model.cursorID = parent.id
+
+	updated, _ := model.Update(tea.KeyPressMsg{Code: tea.KeyTab})
+	model = updated.(Model)
+	if !model.tasks[3].collapsed {
+		t.Fatal("Expected tab to fold the parent")
+	}
+	for _, id := range model.getAllTaskIDs() {
+		if id == parent.subtasks[0].id {
+			t.Error("Expected folded subtasks to be skipped by navigation")
+		}
+	}
+
+	// Folding is saved with the list
+	loaded, _, err := loadTasksFromFile(path)
+	if err != nil || !loaded[3].collapsed {
+		t.Errorf("Expected the fold to be saved, got err %v", err)
+	}
+
+	// Adding a subtask unfolds the parent so the new task is visible
+	model.createNewSubtask()
+	if model.tasks[3].collapsed {
+		t.Error("Expected a new subtask to unfold its parent")
+	}
+
+	// Tasks without subtasks can't be folded
+	model.cursorID = model.tasks[0].id
+	model.toggleFold()
+	if model.tasks[0].collapsed {
+		t.Error("Expected a task without subtasks to stay unfolded")
+	}
+}
+
+func TestHideHelpFooter(t *testing.T) {
+	model := NewModel()
+	model.width, model.height = 80, 30
+
+	if len(model.buildFooterParts(76)) != 2 {
+		t.Fatal("Expected the position line and help footer by default")
+	}
+
+	updated, _ := model.Update(tea.KeyPressMsg{Code: '~', Text: "~"})
+	model = updated.(Model)
+	if parts := model.buildFooterParts(76); len(parts) != 0 {
+		t.Errorf("Expected no footer with help hidden, got %v", parts)
+	}
+
+	// ? brings the footer back instead of toggling hidden full help
+	updated, _ = model.Update(tea.KeyPressMsg{Code: '?', Text: "?"})
+	model = updated.(Model)
+	if model.hideHelp || model.showFullHelp {
+		t.Error("Expected ? to show the short help again")
+	}
+}
+
+func TestFooterPosition(t *testing.T) {
+	model := NewModel()
+	model.tasks = GetMinimalMockTasks()
+	model.cursorID = model.tasks[2].id
+
+	if got := ansi.Strip(model.renderPosition()); got != "3/6 · 6 tasks, 1 done" {
+		t.Errorf("Expected the position among visible tasks and the counts, got %q", got)
+	}
+
+	// Folded tasks still count, but can't be reached
+	model.tasks[3].collapsed = true
+	if got := ansi.Strip(model.renderPosition()); got != "3/4 · 6 tasks, 1 done" {
+		t.Errorf("Expected folded subtasks left out of the position, got %q", got)
+	}
+
+	// Messages take the position's place in the footer
+	model.setStatus("Task pinned")
+	for _, part := range model.buildFooterParts(76) {
+		if strings.Contains(ansi.Strip(part), "3/4") {
+			t.Error("Expected the position to be hidden while a status message shows")
+		}
+	}
+
+	model.editing = true
+	if got := model.renderPosition(); got != "" {
+		t.Errorf("Expected no position while editing, got %q", got)
+	}
+}
+
+func TestFooterPositionAfterEditing(t *testing.T) {
+	model := NewModel()
+	model.tasks = GetMinimalMockTasks()
+	model.cursorID = model.tasks[0].id
+	press := func(msg tea.KeyPressMsg) {
+		updated, _ := model.Update(msg)
+		model = updated.(Model)
+	}
+	footer := func() string {
+		return ansi.Strip(strings.Join(model.buildFooterParts(76), "\n"))
+	}
+
+	press(tea.KeyPressMsg{Code: 'e', Text: "e"})
+	press(tea.KeyPressMsg{Code: 'x', Text: "x"})
+	if strings.Contains(footer(), "Status: x") {
+		t.Error("Expected keystrokes while editing not to show as a status")
+	}
+	press(tea.KeyPressMsg{Code: tea.KeyEscape})
+	press(tea.KeyPressMsg{Code: 'j', Text: "j"})
+	press(tea.KeyPressMsg{Code: 'j', Text: "j"})
+	if !strings.Contains(footer(), "3/6 · 6 tasks, 1 done") {
+		t.Errorf("Expected the position line after editing and moving, got %q", footer())
+	}
+
+	// A status message lasts until the next key
+	model.setStatus("Task pinned")
+	press(tea.KeyPressMsg{Code: 'k', Text: "k"})
+	if f := footer(); strings.Contains(f, "Task pinned") || !strings.Contains(f, "2/6") {
+		t.Errorf("Expected moving to replace the status with the position, got %q", f)
+	}
+}
+
+func TestConfirmRedoDiscard(t *testing.T) {
+	cfg := config.Default()
+	cfg.ConfirmRedoDiscard = true
+	model := NewModelWithConfig("", cfg)
+	model.tasks = []Task{NewTask("Preheat oven", Todo)}
+	model.cursorID = model.tasks[0].id
+
+	press := func(msg tea.KeyPressMsg) {
+		updated, _ := model.Update(msg)
+		model = updated.(Model)
+	}
+
+	model.changeTaskStatusForward()
+	model.undo()
+
+	// Declining keeps the redo history and makes no change
+	press(tea.KeyPressMsg{Code: 'd', Text: "d"})
+	if model.confirm == nil {
+		t.Fatal("Expected a confirmation before discarding redo history")
+	}
+	press(tea.KeyPressMsg{Code: 'n', Text: "n"})
+	if len(model.tasks) != 1 || len(model.redoStack) != 1 {
+		t.Error("Expected declining to keep the task and the redo history")
+	}
+
+	// Navigation doesn't ask
+	press(tea.KeyPressMsg{Code: 'j', Text: "j"})
+	if model.confirm != nil {
+		t.Error("Expected no confirmation for navigation")
+	}
+
+	// Accepting applies the change
+	press(tea.KeyPressMsg{Code: 'd', Text: "d"})
+	press(tea.KeyPressMsg{Code: 'y', Text: "y"})
+	if len(model.tasks) != 0 || len(model.redoStack) != 0 {
+		t.Errorf("Expected the delete applied and redo cleared, got %d tasks and %d redo steps", len(model.tasks), len(model.redoStack))
+	}
+}
+
+func TestGoToBottomScrollsIntoView(t *testing.T) {
+	model := NewModel()
+	model.width, model.height = 80, 20
+	model.tasks = GetLargeMockTasks()
+	model.cursorID = model.tasks[0].id
+
+	press := func(msg tea.KeyPressMsg) {
+		updated, _ := model.Update(msg)
+		model = updated.(Model)
+	}
+
+	press(tea.KeyPressMsg{Code: 'G', Text: "G"})
+	ids := model.getAllTaskIDs()
+	if model.cursorID != ids[len(ids)-1] {
+		t.Fatal("Expected G to move to the last task")
+	}
+	if !strings.Contains(ansi.Strip(model.View()), "▐") {
+		t.Error("Expected the cursor to be scrolled into view at the bottom")
+	}
+
+	press(tea.KeyPressMsg{Code: 'g', Text: "g"})
+	if model.cursorID != model.tasks[0].id {
+		t.Fatal("Expected g to move to the first task")
+	}
+	if !strings.Contains(ansi.Strip(model.View()), "▐") {
+		t.Error("Expected the cursor to be scrolled into view at the top")
+	}
+}
+
+func TestRowHeightMatchesRenderedRow(t *testing.T) {
+	model := NewModel()
+	model.width, model.height = 60, 30
+	model.tasks = append(GetMultiLineMockTasks(), largeRenderTasks(30)...)
+	model.tasks[0].estimate = 90 * time.Minute
+	width := model.width - TotalPadding
+
+	check := func(name string) {
+		model.walkVisibleTasks(func(task *Task, depth int) {
+			isSelected := task.id == model.cursorID
+			want := lipgloss.Height(model.renderRow(*task, width, depth, isSelected, model.editing, nil))
+			if got := model.rowHeight(*task, width, depth, isSelected); got != want {
+				t.Errorf("%s: expected height %d for %q, measured %d", name, want, task.title, got)
+			}
+		})
+	}
+
+	check("wrapped")
+	model.cursorID = model.tasks[1].id
+	model.editing = true
+	model.textInput.SetValue(model.tasks[1].title)
+	check("editing")
+	model.editing = false
+	model.truncateTitles = true
+	check("truncated")
+}
+
+func TestViewRendersOnlyVisibleRows(t *testing.T) {
+	model := NewModel()
+	model.width, model.height = 80, 20
+	model.tasks = largeRenderTasks(500)
+	model.cursorID = model.tasks[250].id
+
+	view := ansi.Strip(model.View())
+	if !strings.Contains(view, "Task 250") || !strings.Contains(view, "▐") {
+		t.Errorf("Expected the cursor task in view, got:\n%s", view)
+	}
+
+	// Only the rows around the viewport are rendered into its content
+	var layout []rowLayout
+	model.walkVisibleTasks(func(task *Task, depth int) {
+		layout = append(layout, rowLayout{task: task, depth: depth})
+	})
+	model.viewport.SetHeight(15)
+	windowTop := model.setViewportTasks(layout, model.width-TotalPadding)
+	if windowTop == 0 {
+		t.Error("Expected the rendered rows to start below the top of the list")
+	}
+	if lines := model.viewport.TotalLineCount(); lines > 15+2 { // Wrapped rows may be cut at either edge
+		t.Errorf("Expected only a screenful of rows rendered, got %d lines", lines)
+	}
+	if !strings.Contains(ansi.Strip(model.viewport.View()), "Task 250") {
+		t.Error("Expected the viewport to show the cursor task")
+	}
+}
+
+// largeRenderTasks returns n top-level tasks with a mix of short and wrapping
+// titles, every tenth one with subtasks
+func largeRenderTasks(n int) []Task {
+	tasks := make([]Task, 0, n)
+	for i := 0; i < n; i++ {
+		title := fmt.Sprintf("Task %d", i)
+		if i%3 == 0 {
+			title += " with a longer title that wraps onto a second line in an eighty column terminal"
+		}
+		task := NewTask(title, TaskStatus(i%3))
+		if i%10 == 0 {
+			task.subtasks = []Task{NewTask("Subtask A", Todo), NewTask("Subtask B", Done)}
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks
+}
+
+// BenchmarkViewLargeList measures one frame of a 5000-task list scrolled to the middle
+func BenchmarkViewLargeList(b *testing.B) {
+	model := NewModel()
+	model.width, model.height = 80, 40
+	model.tasks = largeRenderTasks(5000)
+	model.cursorID = model.tasks[2500].id
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		model.View()
+	}
+}
+
+// BenchmarkUpdateLargeList measures handling a cursor move, which tracks the
+// scroll position by measuring rows rather than rendering them
+func BenchmarkUpdateLargeList(b *testing.B) {
+	model := NewModel()
+	model.width, model.height = 80, 40
+	model.tasks = largeRenderTasks(5000)
+	model.cursorID = model.tasks[2500].id
+	keys := []tea.KeyPressMsg{{Code: 'j', Text: "j"}, {Code: 'k', Text: "k"}}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		updated, _ := model.Update(keys[i%2])
+		model = updated.(Model)
+	}
+}
+
+func TestCutTask(t *testing.T) {
+	model := NewModel()
+	groceries := NewTask("Groceries", Todo, NewTask("Milk", Todo), NewTask("Eggs", Done))
+	keep := NewTask("Keep", Todo)
+	model.tasks = []Task{groceries, keep}
+	model.cursorID = groceries.id
+
+	updated, _ := model.Update(tea.KeyPressMsg{Code: 'c', Text: "c"})
+	model = updated.(Model)
+
+	if len(model.tasks) != 1 || model.tasks[0].id != keep.id || model.cursorID != keep.id {
+		t.Fatal("Expected c to remove the task and move the cursor like a delete")
+	}
+	if model.clipboardTask == nil || model.clipboardTask.id != groceries.id || len(model.clipboardTask.subtasks) != 2 {
+		t.Fatal("Expected the whole subtree in the internal clipboard")
+	}
+	if len(model.trash) != 0 {
+		t.Error("Expected a cut task not to go to the trash")
+	}
+
+	// The clipboard keeps its own copy of the subtree
+	model.undo()
+	model.tasks[0].subtasks[0].title = "Oat milk"
+	if model.clipboardTask.subtasks[0].title != "Milk" {
+		t.Error("Expected the clipboard subtree not to share subtasks with the list")
+	}
+}
+
+func TestPasteCutSubtree(t *testing.T) {
+	model := NewModel()
+	groceries := NewTask("Groceries", Todo, NewTask("Milk", Todo, NewTask("Oat", Todo)), NewTask("Eggs", Done))
+	first := NewTask("First", Todo)
+	last := NewTask("Last", Todo)
+	model.tasks = []Task{first, groceries, last}
+	model.cursorID = groceries.id
+	model.cutCurrentTask()
+
+	// Pasting twice inserts two copies below the cursor, each with new IDs
+	model.cursorID = first.id
+	model.pasteTaskFromClipboard()
+	model.pasteTaskFromClipboard()
+	if len(model.tasks) != 4 || model.tasks[0].id != first.id || model.tasks[3].id != last.id {
+		t.Fatalf("Expected both copies between First and Last, got %d tasks", len(model.tasks))
+	}
+	seen := map[string]bool{}
+	for _, id := range model.getAllTaskIDs() {
+		if seen[id] {
+			t.Errorf("Expected unique IDs after pasting twice, %s repeats", id)
+		}
+		seen[id] = true
+	}
+	pasted := model.tasks[2]
+	if pasted.title != "Groceries" || len(pasted.subtasks) != 2 || pasted.subtasks[0].subtasks[0].title != "Oat" || pasted.subtasks[1].status != Done {
+		t.Error("Expected the pasted copy to keep the whole hierarchy")
+	}
+	if model.cursorID != pasted.id {
+		t.Error("Expected the cursor on the last pasted subtree")
+	}
+
+	// Pasting as a subtask appends the copy to the current task's subtasks
+	model.cursorID = last.id
+	model.pasteTaskAsSubtask()
+	if subtasks := model.tasks[3].subtasks; len(subtasks) != 1 || subtasks[0].title != "Groceries" || subtasks[0].id == groceries.id {
+		t.Error("Expected a fresh copy of the subtree under Last")
+	}
+
+	model.undo()
+	if len(model.tasks[3].subtasks) != 0 {
+		t.Error("Expected undo to remove the pasted subtree")
+	}
+}
+
+func TestPasteOutline(t *testing.T) {
+	model := NewModel()
+	first := NewTask("First", Todo)
+	last := NewTask("Last", Todo)
+	model.tasks = []Task{first, last}
+	model.cursorID = first.id
+
+	// Several clipboard lines become sibling tasks below the cursor, nested by indentation
+	model.pasteOutline(storage.ParseOutline("Groceries\n  Milk\n\nLaundry\n"), false)
+	titles := func(tasks []Task) []string {
+		var got []string
+		for _, task := range tasks {
+			got = append(got, task.title)
+		}
+		return got
+	}
+	if got := titles(model.tasks); !slices.Equal(got, []string{"First", "Groceries", "Laundry", "Last"}) {
+		t.Fatalf("Expected the pasted tasks between First and Last, got %v", got)
+	}
+	if got := titles(model.tasks[1].subtasks); !slices.Equal(got, []string{"Milk"}) {
+		t.Errorf("Expected the indented line as a subtask, got %v", got)
+	}
+	if model.cursorID != model.tasks[1].id || !strings.Contains(model.statusMessage, "3 tasks") {
+		t.Errorf("Expected the cursor on the first pasted task and a count, got %q", model.statusMessage)
+	}
+
+	// One undo removes the whole batch
+	model.undo()
+	if got := titles(model.tasks); !slices.Equal(got, []string{"First", "Last"}) {
+		t.Errorf("Expected one undo to remove every pasted task, got %v", got)
+	}
+
+	model.cursorID = last.id
+	model.pasteOutline(storage.ParseOutline("- [x] Done step\n- [ ] Next step"), true)
+	if subtasks := model.tasks[1].subtasks; len(subtasks) != 2 || subtasks[0].status != Done {
+		t.Errorf("Expected two subtasks under Last with their checkbox status, got %v", titles(subtasks))
+	}
+}
+
+func TestSubtreeMarkdown(t *testing.T) {
+	groceries := NewTask("Groceries", Active, NewTask("Milk", Done, NewTask("Oat", Todo)), NewTask("Eggs", Todo))
+
+	want := "- [~] Groceries\n" +
+		"  - [x] Milk\n" +
+		"    - [ ] Oat\n" +
+		"  - [ ] Eggs\n"
+	got := subtreeMarkdown(groceries)
+	if got != want {
+		t.Errorf("Unexpected Markdown:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Pasting it back rebuilds the same outline
+	if again := storage.ExportMarkdown(storage.ParseOutline(got)); again != want {
+		t.Errorf("Expected the copied Markdown to paste back as the same tree, got:\n%s", again)
+	}
+}
+
+func TestToggleDone(t *testing.T) {
+	model := NewModel()
+	active := NewTask("Active", Active)
+	todo := NewTask("Todo", Todo)
+	model.tasks = []Task{active, todo}
+	model.cursorID = active.id
+
+	space := tea.KeyPressMsg{Code: tea.KeySpace, Text: " "}
+	press := func() {
+		updated, _ := model.Update(space)
+		model = updated.(Model)
+	}
+
+	press()
+	if model.tasks[0].status != Done {
+		t.Fatalf("Expected space to mark the task Done, got %v", model.tasks[0].status)
+	}
+	press()
+	if model.tasks[0].status != Active {
+		t.Errorf("Expected toggling back to return to Active, got %v", model.tasks[0].status)
+	}
+	if len(model.undoStack) != 2 {
+		t.Errorf("Expected one undo snapshot per toggle, got %d", len(model.undoStack))
+	}
+
+	// A task marked Done another way toggles back to Todo
+	model.cursorID = todo.id
+	model.setStatusDirect(Done)
+	press()
+	if model.tasks[1].status != Todo {
+		t.Errorf("Expected Todo, got %v", model.tasks[1].status)
+	}
+}
+
+func TestTaskTimestamps(t *testing.T) {
+	model := NewModel()
+	edited := NewTask("Edited", Todo)
+	other := NewTask("Other", Todo)
+	if edited.CreatedAt().IsZero() || !edited.UpdatedAt().IsZero() {
+		t.Fatal("Expected a new task to have a creation time and no update time")
+	}
+	model.tasks = []Task{edited, other}
+	model.cursorID = edited.id
+
+	model.editTaskTitle(edited.id, "Edited")
+	if !model.tasks[0].UpdatedAt().IsZero() {
+		t.Error("Expected an unchanged title not to count as an update")
+	}
+
+	model.editTaskTitle(edited.id, "Renamed")
+	if model.tasks[0].UpdatedAt().IsZero() {
+		t.Error("Expected editing the title to set UpdatedAt")
+	}
+	if !model.tasks[1].UpdatedAt().IsZero() {
+		t.Error("Expected other tasks' UpdatedAt to be left alone")
+	}
+
+	before := model.tasks[0].UpdatedAt()
+	model.changeTaskStatusForward()
+	if !model.tasks[0].UpdatedAt().After(before) {
+		t.Error("Expected a status change to bump UpdatedAt")
+	}
+
+	// Timestamps survive saving and undo
+	restored := FromTaskData(ToTaskData(model.tasks[0]))
+	if !restored.CreatedAt().Equal(edited.CreatedAt()) || !restored.UpdatedAt().Equal(model.tasks[0].UpdatedAt()) {
+		t.Error("Expected timestamps to round-trip through TaskData")
+	}
+	model.undo()
+	if !model.tasks[0].UpdatedAt().Equal(before) {
+		t.Error("Expected undo to restore the previous UpdatedAt")
+	}
+}
+
+func TestTaskNotes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.dot")
+	model := NewModelWithFile(path)
+	model.tasks = []Task{NewTask("Bake", Todo), NewTask("Clean", Todo)}
+	model.cursorID = model.tasks[0].id
+	model.width, model.height = 80, 30
+	press := func(msg tea.KeyPressMsg) {
+		updated, _ := model.Update(msg)
+		model = updated.(Model)
+	}
+	typeText := func(text string) {
+		for _, r := range text {
+			press(tea.KeyPressMsg{Code: r, Text: string(r)})
+		}
+	}
+	save := tea.KeyPressMsg{Code: tea.KeyEnter, Mod: tea.ModAlt}
+
+	press(tea.KeyPressMsg{Code: 'E', Text: "E"})
+	if model.notesEditor == nil {
+		t.Fatal("Expected E to open the notes editor")
+	}
+	typeText("Preheat to 180C")
+	press(tea.KeyPressMsg{Code: tea.KeyEnter})
+	typeText("Grease the tin")
+	if !strings.Contains(ansi.Strip(model.View()), "Grease the tin") {
+		t.Error("Expected the editor to show the notes being typed")
+	}
+	press(save)
+	if model.notesEditor != nil {
+		t.Fatal("Expected alt+enter to close the notes editor")
+	}
+	if got := model.tasks[0].Notes(); got != "Preheat to 180C\nGrease the tin" {
+		t.Errorf("Expected multi-line notes to be saved, got %q", got)
+	}
+
+	// The row shows an indicator and the footer shows the notes of the selection
+	if meta := ansi.Strip(model.renderMeta(model.tasks[0])); !strings.Contains(meta, NotesSymbol) {
+		t.Errorf("Expected a notes indicator, got %q", meta)
+	}
+	if meta := ansi.Strip(model.renderMeta(model.tasks[1])); strings.Contains(meta, NotesSymbol) {
+		t.Error("Expected no notes indicator on a task without notes")
+	}
+	if !strings.Contains(ansi.Strip(model.View()), "Grease the tin") {
+		t.Error("Expected the footer to show the selected task's notes")
+	}
+
+	// Notes are saved to the file
+	loaded, _, err := loadTasksFromFile(path)
+	if err != nil || loaded[0].Notes() != model.tasks[0].Notes() {
+		t.Errorf("Expected notes to be saved to the file, got %q (%v)", loaded[0].Notes(), err)
+	}
+
+	// ESC discards changes
+	press(tea.KeyPressMsg{Code: 'E', Text: "E"})
+	typeText(" and more")
+	press(tea.KeyPressMsg{Code: tea.KeyEscape})
+	if model.notesEditor != nil || model.tasks[0].Notes() != "Preheat to 180C\nGrease the tin" {
+		t.Error("Expected ESC to close the editor without saving")
+	}
+
+	// Clearing notes is undoable
+	model.setNotes(model.tasks[0].id, "  ")
+	if model.tasks[0].Notes() != "" {
+		t.Error("Expected blank notes to remove them")
+	}
+	model.undo()
+	if model.tasks[0].Notes() != "Preheat to 180C\nGrease the tin" {
+		t.Error("Expected undo to restore the notes")
+	}
+}
+
+func TestReloadOnExternalChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.dot")
+	if err := storage.SaveTasks(path, []storage.TaskData{{ID: "a", Title: "A"}, {ID: "b", Title: "B"}}); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	model := NewModelWithFile(path)
+	model.cursorID = "b"
+
+	// Another program rewrites the file
+	changeFile := func(tasks []storage.TaskData, at time.Time) fileCheckMsg {
+		if err := storage.SaveTasks(path, tasks); err != nil {
+			t.Fatalf("Failed to change file: %v", err)
+		}
+		if err := os.Chtimes(path, at, at); err != nil {
+			t.Fatalf("Failed to set modification time: %v", err)
+		}
+		return fileCheckMsg{path: path, modTime: modTimeOf(path)}
+	}
+	check := func(msg fileCheckMsg) {
+		updated, cmd := model.Update(msg)
+		model = updated.(Model)
+		if cmd == nil {
+			t.Error("Expected the file to keep being watched")
+		}
+	}
+
+	// Checking an unchanged file does nothing
+	check(fileCheckMsg{path: path, modTime: modTimeOf(path)})
+	if len(model.undoStack) != 0 {
+		t.Error("Expected no reload without a change")
+	}
+
+	check(changeFile([]storage.TaskData{{ID: "b", Title: "B edited"}, {ID: "c", Title: "C"}}, time.Now().Add(time.Minute)))
+	if len(model.tasks) != 2 || model.tasks[0].title != "B edited" || model.cursorID != "b" {
+		t.Fatalf("Expected the file reloaded with the cursor kept on B, got %d tasks", len(model.tasks))
+	}
+
+	// With changes that couldn't be saved, reloading needs confirmation
+	model.unsaved = true
+	model.tasks[1].title = "C local"
+	check(changeFile([]storage.TaskData{{ID: "c", Title: "C remote"}}, time.Now().Add(2*time.Minute)))
+	if model.confirm == nil || model.tasks[1].title != "C local" {
+		t.Fatal("Expected a confirmation before dropping local changes")
+	}
+	updated, _ := model.Update(tea.KeyPressMsg{Code: 'y', Text: "y"})
+	model = updated.(Model)
+	if len(model.tasks) != 1 || model.tasks[0].title != "C remote" || model.cursorID != "c" {
+		t.Error("Expected confirming to reload the file and move the cursor to a remaining task")
+	}
+}
+
+func TestSaveRefusesToOverwriteOutsideChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.dot")
+	if err := storage.SaveTasks(path, []storage.TaskData{{ID: "a", Title: "A"}}); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	model := NewModelWithFile(path)
+	model.cursorID = "a"
+
+	// Another instance saves before this one does
+	if err := storage.SaveTasks(path, []storage.TaskData{{ID: "a", Title: "A"}, {ID: "b", Title: "From elsewhere"}}); err != nil {
+		t.Fatalf("Failed to change file: %v", err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatalf("Failed to set modification time: %v", err)
+	}
+
+	model.editTaskTitle("a", "Mine")
+	loaded, _ := storage.LoadTasks(path)
+	if len(loaded) != 2 || loaded[0].Title != "A" {
+		t.Fatal("Expected the outside change not to be overwritten")
+	}
+	if !model.showError || !model.unsaved {
+		t.Error("Expected an error and the change marked as unsaved")
+	}
+
+	// The message names the force-save key, whatever it is bound to
+	rebound := model
+	if err := rebound.OverrideKeys(map[string][]string{"force_save": {"ctrl+w"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := rebound.saveTasksToFile(); !errors.Is(err, errFileChanged) || !strings.Contains(err.Error(), "press ctrl+w") {
+		t.Errorf("Expected the error to name the rebound force-save key, got %v", err)
+	}
+
+	updated, _ := model.Update(tea.KeyPressMsg{Code: 's', Mod: tea.ModCtrl})
+	model = updated.(Model)
+	loaded, _ = storage.LoadTasks(path)
+	if len(loaded) != 1 || loaded[0].Title != "Mine" || model.unsaved {
+		t.Error("Expected ctrl+s to save over the outside change")
+	}
+
+	// Later saves go through again
+	model.editTaskTitle("a", "Mine again")
+	if loaded, _ = storage.LoadTasks(path); loaded[0].Title != "Mine again" {
+		t.Error("Expected auto-save to work after force saving")
+	}
+}
+
+func TestSaveKeepsLoadedCreationTime(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.dot")
+	if err := storage.SaveTasks(path, []storage.TaskData{{ID: "a", Title: "A"}}); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	info, _ := storage.GetFileInfo(path)
+	model := NewModelWithFile(path)
+	if !model.fileCreated.Equal(info.Created) {
+		t.Fatalf("Expected the creation time %v loaded with the list, got %v", info.Created, model.fileCreated)
+	}
+
+	// Saving uses the loaded time rather than reading the file again
+	other := storage.SaveOptions{CreatedAt: info.Created.Add(-time.Hour)}
+	if err := storage.SaveTasksWithOptions(path, []storage.TaskData{{ID: "a", Title: "A"}}, other); err != nil {
+		t.Fatal(err)
+	}
+	if err := model.writeTasksToFile(); err != nil {
+		t.Fatal(err)
+	}
+	if saved, _ := storage.GetFileInfo(path); !saved.Created.Equal(info.Created) {
+		t.Errorf("Expected created_at %v kept from loading, got %v", info.Created, saved.Created)
+	}
+}
+
+func TestLockedOutKeepsChangesInMemory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.dot")
+	if err := storage.SaveTasks(path, []storage.TaskData{{ID: "a", Title: "A"}}); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	model := NewModelWithFile(path)
+	model.cursorID = "a"
+	model.SetLockedOut(storage.ErrLocked)
+	if !model.showError || !strings.Contains(model.lastError, "autosave is off") {
+		t.Errorf("Expected a warning that autosave is off, got %q", model.lastError)
+	}
+
+	model.editTaskTitle("a", "Mine")
+	if loaded, _ := storage.LoadTasks(path); loaded[0].Title != "A" {
+		t.Error("Expected the change not to be saved while another dotdot has the file")
+	}
+	if !model.unsaved {
+		t.Error("Expected the change marked as unsaved, so a reload asks first")
+	}
+
+	updated, _ := model.Update(tea.KeyPressMsg{Code: 's', Mod: tea.ModCtrl})
+	model = updated.(Model)
+	if loaded, _ := storage.LoadTasks(path); loaded[0].Title != "Mine" || model.unsaved {
+		t.Error("Expected ctrl+s to save anyway")
+	}
+}
+
+func TestRestoreCursor(t *testing.T) {
+	model := NewModel()
+	model.tasks = GetMinimalMockTasks()
+	model.cursorID = model.tasks[0].id
+
+	model.RestoreCursor(model.tasks[2].id)
+	if model.CursorID() != model.tasks[2].id {
+		t.Errorf("Expected the cursor on the remembered task, got %q", model.CursorID())
+	}
+
+	// Deleted and folded-away tasks leave the cursor where it is
+	model.RestoreCursor("no-such-task")
+	if model.CursorID() != model.tasks[2].id {
+		t.Errorf("Expected a missing task to be ignored, got %q", model.CursorID())
+	}
+	model.tasks[3].collapsed = true
+	model.RestoreCursor(model.tasks[3].subtasks[0].id)
+	if model.CursorID() != model.tasks[2].id {
+		t.Errorf("Expected a folded subtask to be ignored, got %q", model.CursorID())
+	}
+}
+
+func TestReadOnlyMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shared.dot")
+	if err := storage.SaveTasks(path, []storage.TaskData{
+		{ID: "a", Title: "A", Subtasks: []storage.TaskData{{ID: "a1", Title: "A1"}}},
+		{ID: "b", Title: "B"},
+	}); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	before, _ := os.ReadFile(path)
+
+	model := NewModelWithFile(path)
+	model.SetReadOnly(true)
+	model.cursorID = "a"
+	press := func(msg tea.KeyPressMsg) {
+		updated, _ := model.Update(msg)
+		model = updated.(Model)
+	}
+	char := func(r rune) tea.KeyPressMsg { return tea.KeyPressMsg{Code: r, Text: string(r)} }
+
+	for _, msg := range []tea.KeyPressMsg{char(' '), char('d'), {Code: tea.KeyEnter}, char('i'), char('u'), {Code: 's', Mod: tea.ModCtrl}} {
+		press(msg)
+		if !strings.Contains(model.statusMessage, "Read-only") {
+			t.Errorf("Expected %s to be refused with a message, got %q", msg, model.statusMessage)
+		}
+	}
+	if model.editing || len(model.tasks) != 2 || model.tasks[0].status != Todo {
+		t.Error("Expected the task list to be unchanged")
+	}
+
+	// Browsing still works, including folding, without touching the file
+	press(char('j'))
+	if model.cursorID != "a1" {
+		t.Errorf("Expected to move down to A1, got %q", model.cursorID)
+	}
+	model.cursorID = "a"
+	press(tea.KeyPressMsg{Code: tea.KeyTab})
+	if after, _ := os.ReadFile(path); string(after) != string(before) || model.unsaved {
+		t.Error("Expected the file to be left alone in read-only mode")
+	}
+	if name := model.getTaskListDisplayName(); !strings.HasSuffix(name, "(read-only)") {
+		t.Errorf("Expected the header to say read-only, got %q", name)
+	}
+}
+
+func TestMoveTaskToAnotherList(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	inbox, _ := storage.GlobalTaskPath("inbox")
+	projects, _ := storage.GlobalTaskPath("work/projects")
+	if err := storage.SaveTasks(inbox, []storage.TaskData{
+		{ID: "keep", Title: "Keep"},
+		{ID: "move", Title: "Triage", Subtasks: []storage.TaskData{{ID: "step", Title: "Step"}}},
+	}); err != nil {
+		t.Fatalf("Failed to create inbox: %v", err)
+	}
+	if err := storage.SaveTasks(projects, []storage.TaskData{{ID: "p", Title: "Project"}}); err != nil {
+		t.Fatalf("Failed to create projects: %v", err)
+	}
+
+	model := NewModelWithFile(inbox)
+	model.width, model.height = 80, 30
+	model.cursorID = "move"
+	press := func(msg tea.KeyPressMsg) {
+		updated, _ := model.Update(msg)
+		model = updated.(Model)
+	}
+
+	press(tea.KeyPressMsg{Code: 'm', Text: "m"})
+	if model.listPicker == nil || len(model.listPicker.choices) != 1 || model.listPicker.choices[0].label != "work/projects" {
+		t.Fatal("Expected m to offer the other lists, leaving out the open one")
+	}
+	if view := ansi.Strip(model.View()); !strings.Contains(view, "Move 'Triage' to") {
+		t.Errorf("Expected the picker to name the task being moved, got:\n%s", view)
+	}
+
+	press(tea.KeyPressMsg{Code: tea.KeyEnter})
+	if model.listPicker != nil || len(model.tasks) != 1 || model.cursorID != "keep" {
+		t.Fatal("Expected the task removed from the open list")
+	}
+	moved, _ := storage.LoadTasks(projects)
+	if len(moved) != 2 || moved[1].Title != "Triage" || len(moved[1].Subtasks) != 1 || moved[1].ID == "move" {
+		t.Errorf("Expected the subtree appended to the other list with fresh IDs, got %+v", moved)
+	}
+	if saved, _ := storage.LoadTasks(inbox); len(saved) != 1 {
+		t.Error("Expected the open list saved without the task")
+	}
+
+	model.undo()
+	if model.findTaskByID("move") == nil {
+		t.Error("Expected undo to bring the task back")
+	}
+
+	// Nothing is written to the other list when this one couldn't be saved without the task
+	model.cursorID = "move"
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(inbox, future, future); err != nil {
+		t.Fatal(err)
+	}
+	model.moveToList("move", listChoice{label: "work/projects", path: projects})
+	if !strings.Contains(model.lastError, errFileChanged.Error()) || model.findTaskByID("move") == nil {
+		t.Errorf("Expected the move refused over outside changes, got %q", model.lastError)
+	}
+	model.recordFileModTime()
+	model.autoSave = false
+	model.moveToList("move", listChoice{label: "work/projects", path: projects})
+	if !strings.Contains(model.lastError, "autosave is off") || model.findTaskByID("move") == nil {
+		t.Errorf("Expected the move refused with autosave off, got %q", model.lastError)
+	}
+	if moved, _ := storage.LoadTasks(projects); len(moved) != 2 {
+		t.Errorf("Expected no further copies in the other list, got %d tasks", len(moved))
+	}
+}
+
+func TestKeyOverrides(t *testing.T) {
+	model := NewModel()
+	model.tasks = []Task{NewTask("First", Todo), NewTask("Second", Todo)}
+	model.cursorID = model.tasks[0].id
+
+	if err := model.OverrideKeys(map[string][]string{"delete_task": {"x"}}); err == nil || !strings.Contains(err.Error(), `"x"`) {
+		t.Errorf("Expected x to conflict with exporting, got %v", err)
+	}
+	if err := DefaultKeyMap().checkConflicts(); err != nil {
+		t.Errorf("Expected no conflicts in the default keys, got %v", err)
+	}
+	if err := model.OverrideKeys(map[string][]string{"clear_done": {"ctrl+d"}}); err == nil || !strings.Contains(err.Error(), "half_page_down") {
+		t.Errorf("Expected ctrl+d to conflict with half page down, got %v", err)
+	}
+	if err := model.OverrideKeys(map[string][]string{"remove_task": {"x"}}); err == nil {
+		t.Error("Expected an unknown binding name to be rejected")
+	}
+
+	model = NewModel()
+	model.tasks = []Task{NewTask("First", Todo), NewTask("Second", Todo)}
+	model.cursorID = model.tasks[0].id
+	err := model.OverrideKeys(map[string][]string{
+		"delete_task":    {"x"},
+		"export_subtree": {},
+		"set_status":     {"v", "V", "Z"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	press := func(code rune) {
+		updated, _ := model.Update(tea.KeyPressMsg{Code: code, Text: string(code)})
+		model = updated.(Model)
+	}
+	press('Z')
+	if model.tasks[0].status != Done {
+		t.Error("Expected the third rebound status key to mark the task Done")
+	}
+	press('x')
+	if len(model.tasks) != 1 || model.tasks[0].title != "Second" {
+		t.Error("Expected x to delete the task")
+	}
+	press('d')
+	if len(model.tasks) != 1 {
+		t.Error("Expected the default delete key to be unbound")
+	}
+
+	// Help shows the new keys and leaves out disabled bindings
+	model.showFullHelp = true
+	model.width, model.height = 200, 60
+	view := strings.Join(strings.Fields(ansi.Strip(model.View())), " ")
+	if !strings.Contains(view, "x delete task") || strings.Contains(view, "export task") {
+		t.Errorf("Expected help to reflect the overrides, got:\n%s", view)
+	}
+}
+
+func TestApplyTheme(t *testing.T) {
+	t.Cleanup(func() { ApplyTheme(config.DefaultTheme()) })
+
+	theme := config.DefaultTheme()
+	theme.Cursor = "#ff8800"
+	ApplyTheme(theme)
+	if CursorSelectedStyle.GetForeground() != lipgloss.Color("#ff8800") {
+		t.Error("Expected the cursor to use the theme's color")
+	}
+
+	ApplyTheme(config.Themes["mono"])
+	if _, ok := TaskActiveStyle.GetForeground().(lipgloss.NoColor); !ok {
+		t.Error("Expected the mono theme to leave Active tasks uncolored")
+	}
+	if !TaskDoneStyle.GetStrikethrough() {
+		t.Error("Expected Done tasks to stay struck through without colors")
+	}
+}
+
+func TestNoColorRendersWithoutColors(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	theme, err := config.SelectTheme("default")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ApplyTheme(theme)
+	t.Cleanup(func() { ApplyTheme(config.DefaultTheme()) })
+
+	model := NewModel()
+	model.width, model.height = 80, 30
+	overdue := time.Now().AddDate(0, 0, -1)
+	child := NewTask("Child", Active)
+	parent := NewTask("Parent", Todo, child, NewTask("Finished", Done))
+	late := NewTask("Late", Todo)
+	late.due = &overdue
+	late.priority = HighPriority
+	model.tasks = []Task{parent, late}
+	model.cursorID = child.id
+	model.showFullHelp = true
+	model.setError("Something failed")
+	model.setStatus("Something happened")
+
+	view := model.View()
+	underlined := false
+	for _, match := range regexp.MustCompile(`\x1b\[([0-9;:]*)m`).FindAllStringSubmatch(view, -1) {
+		for _, param := range strings.FieldsFunc(match[1], func(r rune) bool { return r == ';' || r == ':' }) {
+			n, _ := strconv.Atoi(param)
+			if (n >= 30 && n <= 49) || (n >= 90 && n <= 107) {
+				t.Fatalf("Expected no color codes with NO_COLOR set, found %q", match[0])
+			}
+			if n == 4 {
+				underlined = true
+			}
+		}
+	}
+	if !underlined {
+		t.Error("Expected the selected task and its parent to stay underlined")
+	}
+	if !strings.Contains(ansi.Strip(view), "▐") {
+		t.Error("Expected the cursor symbol to mark the selection")
+	}
 }
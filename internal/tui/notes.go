@@ -0,0 +1,121 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/v2/key"
+	"github.com/charmbracelet/bubbles/v2/textarea"
+	tea "github.com/charmbracelet/bubbletea/v2"
+)
+
+// Task notes: an optional longer description kept alongside the one-line title
+
+// maxNotesEditorHeight bounds the height of the notes editor in lines
+const maxNotesEditorHeight = 12
+
+// maxFooterNotesLines bounds how many lines of the selected task's notes the footer shows
+const maxFooterNotesLines = 5
+
+// notesEditor is the open editor for the notes of a task
+type notesEditor struct {
+	taskID string
+	area   *textarea.Model
+}
+
+// openNotesEditor edits the current task's notes in place of the task list
+func (m *Model) openNotesEditor() tea.Cmd {
+	task := m.getCurrentTask()
+	if task == nil {
+		return nil
+	}
+
+	area := textarea.New()
+	area.ShowLineNumbers = false
+	area.Prompt = ""
+	area.SetValue(task.notes)
+	m.notesEditor = &notesEditor{taskID: task.id, area: area}
+	m.resizeNotesEditor()
+	return area.Focus()
+}
+
+// resizeNotesEditor fits the open notes editor to the terminal
+func (m *Model) resizeNotesEditor() {
+	if m.notesEditor == nil {
+		return
+	}
+	m.notesEditor.area.SetWidth(max(m.width-TotalPadding, 1))
+	m.notesEditor.area.SetHeight(max(min(maxNotesEditorHeight, m.viewHeight()-10), 3))
+}
+
+// setNotes replaces a task's notes, ignoring surrounding blank lines
+func (m *Model) setNotes(taskID, notes string) {
+	task := m.findTaskByID(taskID)
+	notes = strings.TrimSpace(notes)
+	if task == nil || task.notes == notes {
+		return
+	}
+
+	m.takeSnapshot(m.taskLabel("edit notes of", taskID))
+	m.modifyTaskByID(taskID, func(task *Task) {
+		task.notes = notes
+		task.touch()
+	})
+	if notes == "" {
+		m.setStatus("Notes removed")
+	} else {
+		m.setStatus("Notes saved")
+	}
+}
+
+func (m Model) handleNotesMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keyMap.ConfirmEdit):
+		editor := m.notesEditor
+		m.notesEditor = nil
+		m.setNotes(editor.taskID, editor.area.Value())
+		return m, nil
+	case key.Matches(msg, m.keyMap.Cancel):
+		m.notesEditor = nil
+		m.setStatus("Cancelled")
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.notesEditor.area, cmd = m.notesEditor.area.Update(msg)
+	return m, cmd
+}
+
+// renderNotesEditor renders the notes editor in place of the task list. The
+// editor scrolls itself, so the returned lines to keep in view are just the top.
+func (m Model) renderNotesEditor() ([]string, int, int) {
+	title := "task"
+	if task := m.findTaskByID(m.notesEditor.taskID); task != nil {
+		title = fmt.Sprintf("'%s'", task.title)
+	}
+	save := m.keyMap.ConfirmEdit.Help().Key
+	rows := []string{HelpStyle.Render(fmt.Sprintf("Notes for %s: %s to save, esc to cancel", title, save)), ""}
+	rows = append(rows, m.notesEditor.area.View())
+	return rows, 0, 0
+}
+
+// notesSymbol returns the indicator shown for tasks with notes
+func (m Model) notesSymbol() string {
+	if m.ascii {
+		return ASCIINotesSymbol
+	}
+	return NotesSymbol
+}
+
+// renderFooterNotes renders the first lines of the selected task's notes, or "" if it has none
+func (m Model) renderFooterNotes(width int) string {
+	task := m.getCurrentTask()
+	if task == nil || task.notes == "" {
+		return ""
+	}
+	lines := strings.Split(task.notes, "\n")
+	if len(lines) > maxFooterNotesLines {
+		lines = append(lines[:maxFooterNotesLines-1], fmt.Sprintf("(%d more lines)", len(lines)-maxFooterNotesLines+1))
+	}
+	return NotesStyle.Width(width).Render(strings.Join(lines, "\n"))
+}
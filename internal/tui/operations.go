@@ -1,9 +1,15 @@
 package tui
 
 import (
+	"fmt"
+	"slices"
 	"strings"
+	"time"
+
+	"dotdot/internal/storage"
 
 	"github.com/atotto/clipboard"
+	"github.com/google/uuid"
 )
 
 // Task manipulation and tree operations
@@ -45,12 +51,11 @@ func (m Model) getCurrentTask() *Task {
 	return m.findTaskByID(m.cursorID)
 }
 
-// getAllTaskIDs returns all task IDs in traversal order
+// getAllTaskIDs returns the IDs of all visible tasks in display order
 func (m Model) getAllTaskIDs() []string {
 	var ids []string
-	m.traverseTasks(func(task *Task) bool {
+	m.walkVisibleTasks(func(task *Task, depth int) {
 		ids = append(ids, task.id)
-		return false
 	})
 	return ids
 }
@@ -65,10 +70,14 @@ func (m Model) getAdjacentTaskID(direction int) string {
 			if newIndex >= 0 && newIndex < len(ids) {
 				return ids[newIndex]
 			}
-			break
+			return m.cursorID // Stay put at the boundary
 		}
 	}
-	return m.cursorID // Return current if at boundary
+	// Cursor is on a hidden task; jump to the first visible one
+	if len(ids) > 0 {
+		return ids[0]
+	}
+	return m.cursorID
 }
 
 // getPreviousTaskID returns the ID of the previous task in traversal order
@@ -81,6 +90,98 @@ func (m Model) getNextTaskID() string {
 	return m.getAdjacentTaskID(1)
 }
 
+// maxJumpHistory bounds how many earlier cursor positions jump back remembers
+const maxJumpHistory = 20
+
+// jumpTo moves the cursor to taskID, remembering the current position for jumpBack
+func (m *Model) jumpTo(taskID string) {
+	if taskID == "" || taskID == m.cursorID {
+		return
+	}
+	m.jumpHistory = append(m.jumpHistory, m.cursorID)
+	if len(m.jumpHistory) > maxJumpHistory {
+		m.jumpHistory = m.jumpHistory[len(m.jumpHistory)-maxJumpHistory:]
+	}
+	m.cursorID = taskID
+}
+
+// jumpToTop moves the cursor to the first visible task
+func (m *Model) jumpToTop() {
+	if ids := m.getAllTaskIDs(); len(ids) > 0 {
+		m.jumpTo(ids[0])
+	}
+}
+
+// jumpToBottom moves the cursor to the last visible task
+func (m *Model) jumpToBottom() {
+	if ids := m.getAllTaskIDs(); len(ids) > 0 {
+		m.jumpTo(ids[len(ids)-1])
+	}
+}
+
+// moveCursorBy moves the cursor n visible tasks down, or up for negative n,
+// stopping at the first or last task. The view scrolls to follow the cursor
+// when it is next rendered.
+func (m *Model) moveCursorBy(n int) {
+	ids := m.getAllTaskIDs()
+	if len(ids) == 0 {
+		return
+	}
+	index := slices.Index(ids, m.cursorID)
+	if index < 0 {
+		index = 0
+	}
+	m.cursorID = ids[max(0, min(index+n, len(ids)-1))]
+}
+
+// jumpBack returns the cursor to where it was before the most recent large move.
+// Entries for tasks that have since been deleted are skipped.
+func (m *Model) jumpBack() {
+	for len(m.jumpHistory) > 0 {
+		last := len(m.jumpHistory) - 1
+		id := m.jumpHistory[last]
+		m.jumpHistory = m.jumpHistory[:last]
+		if id != m.cursorID && m.findTaskByID(id) != nil {
+			m.cursorID = id
+			m.ensureCursorVisible()
+			return
+		}
+	}
+	m.setStatus("No earlier position to jump back to")
+}
+
+// goToParent moves the cursor to the current task's parent, staying put at the
+// top level or at the top of a focused group
+func (m *Model) goToParent() {
+	parent, _ := m.findParentTask(m.cursorID)
+	if parent == nil || parent.id == m.focusParentID {
+		return
+	}
+	m.cursorID = parent.id
+}
+
+// goToSibling moves the cursor to the next (offset 1) or previous (offset -1)
+// visible task at the same level, skipping over subtrees, and stays put at either end
+func (m *Model) goToSibling(offset int) {
+	parent, _ := m.findParentTask(m.cursorID)
+	siblings := m.tasks
+	if parent != nil {
+		siblings = parent.subtasks
+	}
+
+	var ids []string
+	for _, i := range m.displayOrder(siblings) {
+		if m.isTaskVisible(&siblings[i]) {
+			ids = append(ids, siblings[i].id)
+		}
+	}
+	i := slices.Index(ids, m.cursorID)
+	if i < 0 || i+offset < 0 || i+offset >= len(ids) {
+		return
+	}
+	m.cursorID = ids[i+offset]
+}
+
 // findParentTask finds the parent task for a given task ID and returns the parent and index
 // For top-level tasks, returns nil parent and the index in the top-level tasks slice
 func (m *Model) findParentTask(taskID string) (*Task, int) {
@@ -126,7 +227,7 @@ func (m *Model) getTaskContainer(parent *Task) *[]Task {
 func (m *Model) getParentChainIDs(taskID string) []string {
 	var parentIDs []string
 	currentTaskID := taskID
-	
+
 	for {
 		parent, _ := m.findParentTask(currentTaskID)
 		if parent == nil {
@@ -135,7 +236,7 @@ func (m *Model) getParentChainIDs(taskID string) []string {
 		parentIDs = append(parentIDs, parent.id)
 		currentTaskID = parent.id
 	}
-	
+
 	return parentIDs
 }
 
@@ -172,13 +273,20 @@ func (m *Model) modifyTaskByID(taskID string, fn func(*Task)) {
 }
 
 func (m *Model) editTaskTitle(taskID string, newTitle string) {
+	if taskID == m.wrapParentID {
+		m.wrapParentID = "" // The new parent is kept, titled or not
+	}
+
 	// Only take snapshot if title actually changed
 	currentTask := m.findTaskByID(taskID)
 	if currentTask != nil && currentTask.title != newTitle {
-		m.takeSnapshot()
+		m.takeSnapshot(m.taskLabel("edit", taskID))
 	}
 	m.modifyTaskByID(taskID, func(task *Task) {
-		task.title = newTitle
+		if task.title != newTitle {
+			task.title = newTitle
+			task.touch()
+		}
 	})
 }
 
@@ -198,33 +306,228 @@ func (m *Model) changeTaskStatus(direction int) {
 		willChange = (currentTask.status == Done) || (currentTask.status == Active)
 	}
 
-	if willChange {
-		m.takeSnapshot()
+	// Remember where to go next before the task is marked Done and possibly sunk
+	nextID := ""
+	if m.autoAdvance && direction > 0 && currentTask.status == Active {
+		nextID = m.nextIncompleteTaskID()
 	}
 
-	m.modifyCurrentTask(func(task *Task) {
-		if direction > 0 {
-			// Forward: Todo -> Active -> Done
-			switch task.status {
-			case Todo:
-				task.status = Active
-			case Active:
-				task.status = Done
-			case Done:
-				// Already at max status, no change
-			}
-		} else {
-			// Backward: Done -> Active -> Todo
-			switch task.status {
-			case Done:
-				task.status = Active
-			case Active:
-				task.status = Todo
-			case Todo:
-				// Already at min status, no change
-			}
+	if !willChange {
+		return
+	}
+
+	label := "status forward"
+	if direction < 0 {
+		label = "status back"
+	}
+	m.takeSnapshot(m.taskLabel(label, m.cursorID))
+
+	if direction > 0 {
+		// Forward: Todo -> Active -> Done
+		switch currentTask.status {
+		case Todo:
+			currentTask.status = Active
+		case Active:
+			currentTask.status = Done
+		}
+	} else {
+		// Backward: Done -> Active -> Todo
+		switch currentTask.status {
+		case Done:
+			currentTask.status = Active
+		case Active:
+			currentTask.status = Todo
+		}
+	}
+	currentTask.touch()
+
+	m.afterStatusChange(m.cursorID)
+	if nextID != "" {
+		m.cursorID = nextID
+	}
+	m.autoSaveIfEnabled()
+}
+
+// nextIncompleteTaskID returns the first visible task after the cursor that isn't Done,
+// or "" if there is none
+func (m Model) nextIncompleteTaskID() string {
+	passedCursor := false
+	nextID := ""
+	m.walkVisibleTasks(func(task *Task, depth int) {
+		switch {
+		case nextID != "":
+		case task.id == m.cursorID:
+			passedCursor = true
+		case passedCursor && task.status != Done:
+			nextID = task.id
 		}
 	})
+	return nextID
+}
+
+// setStatusDirect sets the current task to the given status, taking a snapshot only if it changes
+func (m *Model) setStatusDirect(status TaskStatus) {
+	currentTask := m.getCurrentTask()
+	if currentTask == nil || currentTask.status == status {
+		return
+	}
+
+	nextID := ""
+	if m.autoAdvance && status == Done {
+		nextID = m.nextIncompleteTaskID()
+	}
+
+	m.takeSnapshot(m.taskLabel("status change", m.cursorID))
+	currentTask.status = status
+	currentTask.touch()
+
+	m.afterStatusChange(m.cursorID)
+	if nextID != "" {
+		m.cursorID = nextID
+	}
+	m.autoSaveIfEnabled()
+}
+
+// toggleDone marks the current task Done, or returns a Done task to the status it
+// had before it was toggled or its parent advanced it (Todo if it was marked Done some other way)
+func (m *Model) toggleDone() {
+	currentTask := m.getCurrentTask()
+	if currentTask == nil {
+		return
+	}
+
+	nextID := ""
+	if m.autoAdvance && currentTask.status != Done {
+		nextID = m.nextIncompleteTaskID()
+	}
+
+	m.takeSnapshot(m.taskLabel("toggle done", m.cursorID))
+	if currentTask.status == Done {
+		currentTask.status, currentTask.undone = currentTask.undone, Todo
+	} else {
+		currentTask.status, currentTask.undone = Done, currentTask.status
+	}
+	currentTask.touch()
+
+	m.afterStatusChange(m.cursorID)
+	if nextID != "" {
+		m.cursorID = nextID
+	}
+	m.autoSaveIfEnabled()
+}
+
+// advanceChildrenStatus moves each direct subtask of the current task one status
+// forward in a single undo step. Subtasks already Done are left alone.
+func (m *Model) advanceChildrenStatus() {
+	task := m.getCurrentTask()
+	if task == nil {
+		return
+	}
+
+	if !slices.ContainsFunc(task.subtasks, func(child Task) bool { return child.status != Done }) {
+		m.setStatus("No subtasks to advance")
+		return
+	}
+
+	m.takeSnapshot(m.taskLabel("subtask status changes", m.cursorID))
+	var advanced []string
+	for i := range task.subtasks {
+		child := &task.subtasks[i]
+		if child.status != Done {
+			setTaskStatus(child, child.status+1)
+			advanced = append(advanced, child.id)
+		}
+	}
+	// Sinking reorders the subtasks, so follow-ups wait until every child has changed
+	for _, id := range advanced {
+		m.afterStatusChange(id)
+	}
+	m.setStatus(fmt.Sprintf("Advanced %d subtask(s)", len(advanced)))
+	m.autoSaveIfEnabled()
+}
+
+// setTaskStatus changes a task's status in place, remembering the status it had
+// when it is marked Done so that toggling Done off returns to it
+func setTaskStatus(task *Task, status TaskStatus) {
+	if task.status == status {
+		return
+	}
+	if status == Done {
+		task.undone = task.status
+	}
+	task.status = status
+	task.touch()
+}
+
+// afterStatusChange applies follow-up behavior once a task's status has changed.
+// It runs within the same undo snapshot as the status change itself, and leaves
+// saving to the caller so the whole change is saved once.
+func (m *Model) afterStatusChange(taskID string) {
+	task := m.findTaskByID(taskID)
+	if task == nil {
+		return
+	}
+
+	if m.sinkDoneTasks && task.status == Done {
+		m.moveTaskToBottom(taskID)
+	}
+	if m.autoRollup {
+		m.recomputeParentStatuses(taskID)
+	}
+}
+
+// recomputeParentStatuses walks up from a task through its ancestors, marking
+// each Done once all of its descendants are Done, and back to Active when one
+// of them isn't any more. Parents changed to Done sink like any other task.
+func (m *Model) recomputeParentStatuses(taskID string) {
+	for _, parentID := range m.getParentChainIDs(taskID) {
+		parent := m.findTaskByID(parentID)
+		done := allDescendantsDone(*parent)
+
+		var status TaskStatus
+		switch {
+		case done && parent.status != Done:
+			status = Done
+		case !done && parent.status == Done:
+			status = Active
+		default:
+			continue
+		}
+		parent.status = status
+		parent.touch()
+		if m.sinkDoneTasks && status == Done {
+			m.moveTaskToBottom(parentID)
+		}
+	}
+}
+
+// allDescendantsDone reports whether a task has subtasks and every one of them, at all depths, is Done
+func allDescendantsDone(task Task) bool {
+	if len(task.subtasks) == 0 {
+		return false
+	}
+	for _, subtask := range task.subtasks {
+		if subtask.status != Done || (len(subtask.subtasks) > 0 && !allDescendantsDone(subtask)) {
+			return false
+		}
+	}
+	return true
+}
+
+// moveTaskToBottom moves a task to the end of its sibling group without taking a snapshot
+func (m *Model) moveTaskToBottom(taskID string) {
+	parent, index := m.findParentTask(taskID)
+	if index < 0 {
+		return
+	}
+
+	container := m.getTaskContainer(parent)
+	if index == len(*container)-1 {
+		return // Already last
+	}
+
+	task := removeTaskFromSlice(container, index)
+	*container = append(*container, task)
 }
 
 // changeTaskStatusForward advances task status: Todo -> Active -> Done
@@ -237,13 +540,18 @@ func (m *Model) changeTaskStatusBackward() {
 	m.changeTaskStatus(-1)
 }
 
-// createTask creates a new task at the specified location
+// createTask creates a new Todo task at the specified location
 // asSubtask: true to create as subtask, false to create as sibling
 func (m *Model) createTask(asSubtask bool) string {
+	return m.createTaskWithStatus(asSubtask, Todo)
+}
+
+// createTaskWithStatus creates a new task with the given status at the specified location
+func (m *Model) createTaskWithStatus(asSubtask bool, status TaskStatus) string {
 	// Take snapshot before creating task
-	m.takeSnapshot()
+	m.takeSnapshot("new task")
 
-	newTask := NewTask("", Todo)
+	newTask := NewTask("", status)
 
 	// Special case: if no tasks exist, add as first top-level task
 	if len(m.tasks) == 0 || m.cursorID == "" {
@@ -260,8 +568,9 @@ func (m *Model) createTask(asSubtask bool) string {
 			return newTask.id
 		}
 
-		// Add to the end of the current task's subtasks
+		// Add to the end of the current task's subtasks, unfolding it so the new task is visible
 		currentTask.subtasks = append(currentTask.subtasks, newTask)
+		currentTask.collapsed = false
 		return newTask.id
 	}
 
@@ -281,11 +590,32 @@ func (m *Model) createTask(asSubtask bool) string {
 	return newTask.id
 }
 
-// createNewTaskBelow creates a new task below the currently selected task
+// createNewTaskBelow creates a new task below the currently selected task.
+// With smart new task enabled, a task created below an Active parent that
+// already has subtasks becomes that parent's first subtask instead of a sibling.
 func (m *Model) createNewTaskBelow() string {
+	if m.smartNewTask {
+		if currentTask := m.getCurrentTask(); currentTask != nil && currentTask.status == Active && len(currentTask.subtasks) > 0 {
+			m.takeSnapshot("new task")
+			newTask := NewTask("", Todo)
+			insertTaskInSlice(&currentTask.subtasks, 0, newTask)
+			currentTask.collapsed = false // Unfold so the new task is visible
+			return newTask.id
+		}
+	}
 	return m.createTask(false)
 }
 
+// createNewTaskBelowWithSameStatus creates a sibling below the current task
+// that starts with the current task's status instead of Todo
+func (m *Model) createNewTaskBelowWithSameStatus() string {
+	status := Todo
+	if currentTask := m.getCurrentTask(); currentTask != nil {
+		status = currentTask.status
+	}
+	return m.createTaskWithStatus(false, status)
+}
+
 // createNewSubtask creates a new subtask at the end of the currently selected task's subtasks
 func (m *Model) createNewSubtask() string {
 	return m.createTask(true)
@@ -294,7 +624,7 @@ func (m *Model) createNewSubtask() string {
 // createNewTaskInParent creates a new task in the parent of the currently selected task
 func (m *Model) createNewTaskInParent() string {
 	// Take snapshot before creating task
-	m.takeSnapshot()
+	m.takeSnapshot("new task")
 
 	newTask := NewTask("", Todo)
 
@@ -338,12 +668,12 @@ func (m *Model) deleteCurrentTask() {
 	}
 
 	// Take snapshot before deletion
-	m.takeSnapshot()
+	m.takeSnapshot(m.taskLabel("delete", m.cursorID))
 
 	container := m.getTaskContainer(parent)
 
-	// Remove the task from its container
-	removeTaskFromSlice(container, index)
+	// Remove the task from its container, keeping a copy in the trash
+	m.moveToTrash(removeTaskFromSlice(container, index))
 
 	// Update cursor to a valid task
 	m.updateCursorAfterDeletion()
@@ -351,6 +681,74 @@ func (m *Model) deleteCurrentTask() {
 	m.autoSaveIfEnabled()
 }
 
+// deleteCurrentTaskConfirmed deletes the current task, first asking for
+// confirmation if it has subtasks and confirmDelete is on
+func (m *Model) deleteCurrentTaskConfirmed() {
+	task := m.getCurrentTask()
+	if task == nil {
+		return
+	}
+	if !m.confirmDelete || len(task.subtasks) == 0 {
+		m.deleteCurrentTask()
+		return
+	}
+
+	count := task.DescendantCount()
+	subtasks := "subtasks"
+	if count == 1 {
+		subtasks = "subtask"
+	}
+	m.askConfirm(fmt.Sprintf("Delete '%s' and %d %s?", task.title, count, subtasks), func(m *Model) {
+		m.deleteCurrentTask()
+	})
+}
+
+// discardEmptyTask removes the current task after its title was left empty.
+// A new parent from wrapInNewParent is replaced by the task it wraps instead,
+// which the wrap's own undo entry already covers.
+func (m *Model) discardEmptyTask() {
+	wrapParentID := m.wrapParentID
+	m.wrapParentID = ""
+	task := m.getCurrentTask()
+	if task == nil || task.id != wrapParentID || len(task.subtasks) == 0 {
+		m.deleteCurrentTask()
+		return
+	}
+
+	parent, index := m.findParentTask(m.cursorID)
+	container := m.getTaskContainer(parent)
+	removed := removeTaskFromSlice(container, index)
+	for i, child := range removed.subtasks {
+		insertTaskInSlice(container, index+i, child)
+	}
+	m.cursorID = removed.subtasks[0].id
+
+	m.autoSaveIfEnabled()
+}
+
+// wrapInNewParent replaces the current task with a new, empty parent task that
+// holds it as its only subtask, and moves the cursor to the new parent.
+// It returns false if there is no current task.
+func (m *Model) wrapInNewParent() bool {
+	parent, index := m.findParentTask(m.cursorID)
+	if index < 0 {
+		return false
+	}
+
+	m.takeSnapshot(m.taskLabel("wrap in parent", m.cursorID))
+
+	container := m.getTaskContainer(parent)
+	task := removeTaskFromSlice(container, index)
+	newParent := NewTask("", Todo, task)
+	insertTaskInSlice(container, index, newParent)
+
+	m.previousID = m.cursorID
+	m.cursorID = newParent.id
+	m.wrapParentID = newParent.id
+	m.autoSaveIfEnabled()
+	return true
+}
+
 // updateCursorAfterDeletion moves cursor to a valid task after deletion
 func (m *Model) updateCursorAfterDeletion() {
 	// First try to go back to the previously selected task
@@ -378,7 +776,7 @@ func (m *Model) moveTaskUp() {
 	}
 
 	// Take snapshot before moving
-	m.takeSnapshot()
+	m.takeSnapshot(m.taskLabel("move up", m.cursorID))
 
 	container := m.getTaskContainer(parent)
 	// Swap with the previous task
@@ -400,7 +798,7 @@ func (m *Model) moveTaskDown() {
 	}
 
 	// Take snapshot before moving
-	m.takeSnapshot()
+	m.takeSnapshot(m.taskLabel("move down", m.cursorID))
 
 	// Swap with the next task
 	(*container)[index], (*container)[index+1] = (*container)[index+1], (*container)[index]
@@ -408,6 +806,52 @@ func (m *Model) moveTaskDown() {
 	m.autoSaveIfEnabled()
 }
 
+// moveTaskToEdge moves the current task, with its subtasks, to the first or
+// last position in its parent container
+func (m *Model) moveTaskToEdge(top bool) {
+	parent, index := m.findParentTask(m.cursorID)
+	if index < 0 {
+		return // Task not found
+	}
+
+	container := m.getTaskContainer(parent)
+	target, label := len(*container)-1, "move to bottom"
+	if top {
+		target, label = 0, "move to top"
+	}
+	if index == target {
+		return // Already there
+	}
+
+	m.takeSnapshot(m.taskLabel(label, m.cursorID))
+	task := removeTaskFromSlice(container, index)
+	insertTaskInSlice(container, target, task)
+
+	m.autoSaveIfEnabled()
+}
+
+// reverseSiblings reverses the order of the current task's sibling group
+func (m *Model) reverseSiblings() {
+	parent, index := m.findParentTask(m.cursorID)
+	if index < 0 {
+		return // Task not found
+	}
+
+	container := m.getTaskContainer(parent)
+	if len(*container) < 2 {
+		return // Nothing to reverse
+	}
+
+	// Take snapshot before reversing
+	m.takeSnapshot(m.taskLabel("reverse siblings", m.cursorID))
+
+	for i, j := 0, len(*container)-1; i < j; i, j = i+1, j-1 {
+		(*container)[i], (*container)[j] = (*container)[j], (*container)[i]
+	}
+
+	m.autoSaveIfEnabled()
+}
+
 // unindentTask moves a task out of its parent (decrease indentation)
 func (m *Model) unindentTask() {
 	parent, index := m.findParentTask(m.cursorID)
@@ -416,7 +860,7 @@ func (m *Model) unindentTask() {
 	}
 
 	// Take snapshot before unindenting
-	m.takeSnapshot()
+	m.takeSnapshot(m.taskLabel("unindent", m.cursorID))
 
 	// Remove task from current location (parent's subtasks)
 	task := removeTaskFromSlice(&parent.subtasks, index)
@@ -431,6 +875,159 @@ func (m *Model) unindentTask() {
 	m.autoSaveIfEnabled()
 }
 
+// promoteTaskToTopLevel moves a task and its subtree out to the top level,
+// inserting it directly after its outermost ancestor
+func (m *Model) promoteTaskToTopLevel() {
+	parentChainIDs := m.getParentChainIDs(m.cursorID)
+	if len(parentChainIDs) == 0 {
+		return // Already a top-level task (or not found)
+	}
+	rootID := parentChainIDs[len(parentChainIDs)-1]
+
+	// Take snapshot before promoting
+	m.takeSnapshot(m.taskLabel("promote", m.cursorID))
+
+	// Remove task from its current parent
+	parent, index := m.findParentTask(m.cursorID)
+	task := removeTaskFromSlice(&parent.subtasks, index)
+
+	// Insert task after its outermost ancestor
+	_, rootIndex := m.findParentTask(rootID)
+	insertTaskInSlice(&m.tasks, rootIndex+1, task)
+
+	m.autoSaveIfEnabled()
+}
+
+// unindentToDepth moves a task and its subtree out to become a sibling of its
+// ancestor at the given depth (0 being the top level), inserting it directly after
+// that ancestor. Depths at or below the task's parent move it out one level.
+func (m *Model) unindentToDepth(depth int) {
+	parentChainIDs := m.getParentChainIDs(m.cursorID)
+	if len(parentChainIDs) == 0 {
+		return // Already a top-level task (or not found)
+	}
+	if depth < 0 {
+		depth = 0
+	}
+	if depth > len(parentChainIDs)-1 {
+		depth = len(parentChainIDs) - 1
+	}
+	// The chain runs from the immediate parent up to the root at depth 0
+	ancestorID := parentChainIDs[len(parentChainIDs)-1-depth]
+
+	// Take snapshot before unindenting
+	m.takeSnapshot(m.taskLabel(fmt.Sprintf("unindent to depth %d", depth), m.cursorID))
+
+	// Remove task from its current parent
+	parent, index := m.findParentTask(m.cursorID)
+	task := removeTaskFromSlice(&parent.subtasks, index)
+
+	// Insert task after the chosen ancestor
+	ancestorParent, ancestorIndex := m.findParentTask(ancestorID)
+	insertTaskInSlice(m.getTaskContainer(ancestorParent), ancestorIndex+1, task)
+
+	m.autoSaveIfEnabled()
+}
+
+// startUnindentToDepth waits for a number key giving the depth to unindent the current task to
+func (m *Model) startUnindentToDepth() {
+	depth := len(m.getParentChainIDs(m.cursorID))
+	if depth == 0 {
+		m.setStatus("Already a top-level task")
+		return
+	}
+	m.unindentPending = true
+	m.setStatus(fmt.Sprintf("Unindent to depth: 0 (top level) to %d", depth-1))
+}
+
+// toggleFold collapses or expands the subtasks of the current task
+func (m *Model) toggleFold() {
+	task := m.getCurrentTask()
+	if task == nil || len(task.subtasks) == 0 {
+		m.setStatus("No subtasks to fold")
+		return
+	}
+
+	m.modifyCurrentTask(func(task *Task) {
+		task.collapsed = !task.collapsed
+	})
+}
+
+// normalizeTasks repairs structural problems left by imports or hand edits,
+// such as duplicate IDs, unknown statuses and over-deep nesting
+func (m *Model) normalizeTasks() {
+	normalized, fixes := storage.NormalizeTasks(ToTaskDataSlice(m.tasks))
+	if len(fixes) == 0 {
+		m.setStatus("Nothing to normalize")
+		return
+	}
+
+	m.takeSnapshot("normalize list")
+	m.tasks = FromTaskDataSlice(normalized)
+	if m.getCurrentTask() == nil && len(m.tasks) > 0 {
+		m.cursorID = m.tasks[0].id
+	}
+	m.ensureCursorVisible()
+
+	m.setStatus(fmt.Sprintf("Normalized list: %d fix(es)", len(fixes)))
+	m.autoSaveIfEnabled()
+}
+
+// clearDoneTasks removes the Done tasks whose subtasks are all Done too, as one undoable change
+func (m *Model) clearDoneTasks() {
+	cleared, removed := storage.ClearDone(ToTaskDataSlice(m.tasks))
+	if removed == 0 {
+		m.setStatus("No Done tasks to clear")
+		return
+	}
+
+	// If the cursor's task goes, move to the next task that stays, or else the previous one
+	ids := m.getAllTaskIDs()
+	cursor := slices.Index(ids, m.cursorID)
+
+	m.takeSnapshot("clear done tasks")
+	m.tasks = FromTaskDataSlice(cleared)
+	if m.getCurrentTask() == nil && cursor >= 0 {
+		candidates := slices.Concat(ids[cursor:], ids[:cursor])
+		slices.Reverse(candidates[len(ids)-cursor:])
+		for _, id := range candidates {
+			if m.findTaskByID(id) != nil {
+				m.cursorID = id
+				break
+			}
+		}
+	}
+	m.ensureCursorVisible()
+
+	m.setStatus(fmt.Sprintf("Cleared %d Done task(s)", removed))
+	m.autoSaveIfEnabled()
+}
+
+// duplicateTaskShallow inserts a copy of the current task after it, without its
+// subtasks, and moves the cursor to the copy
+func (m *Model) duplicateTaskShallow() {
+	parent, index := m.findParentTask(m.cursorID)
+	if index < 0 {
+		return
+	}
+
+	m.takeSnapshot(m.taskLabel("duplicate", m.cursorID))
+
+	container := m.getTaskContainer(parent)
+	original := (*container)[index]
+	duplicate := NewTask(original.title, original.status)
+	duplicate.notes = original.notes
+	duplicate.estimate = original.estimate
+	duplicate.due = original.due
+	duplicate.priority = original.priority
+	duplicate.deferred = original.deferred
+	insertTaskInSlice(container, index+1, duplicate)
+
+	m.previousID = m.cursorID
+	m.cursorID = duplicate.id
+	m.autoSaveIfEnabled()
+}
+
 // indentTask moves a task into the previous sibling (increase indentation)
 func (m *Model) indentTask() {
 	parent, index := m.findParentTask(m.cursorID)
@@ -439,7 +1036,7 @@ func (m *Model) indentTask() {
 	}
 
 	// Take snapshot before indenting
-	m.takeSnapshot()
+	m.takeSnapshot(m.taskLabel("indent", m.cursorID))
 
 	container := m.getTaskContainer(parent)
 	// Get the previous sibling (which will become the parent)
@@ -454,8 +1051,17 @@ func (m *Model) indentTask() {
 	m.autoSaveIfEnabled()
 }
 
-// takeSnapshot creates a snapshot of the current model state
-func (m *Model) takeSnapshot() {
+// taskLabel describes an operation on a task for undo/redo messages, e.g. "edit on 'Buy milk'"
+func (m Model) taskLabel(action, taskID string) string {
+	if task := m.findTaskByID(taskID); task != nil && task.title != "" {
+		return fmt.Sprintf("%s on '%s'", action, task.title)
+	}
+	return action
+}
+
+// takeSnapshot creates a snapshot of the current model state, labelled with
+// the change about to be made
+func (m *Model) takeSnapshot(label string) {
 	// Create a deep copy of tasks
 	tasksCopy := make([]Task, len(m.tasks))
 	copy(tasksCopy, m.tasks)
@@ -465,30 +1071,33 @@ func (m *Model) takeSnapshot() {
 		tasks:      tasksCopy,
 		cursorID:   m.cursorID,
 		previousID: m.previousID,
+		label:      label,
 	}
 
 	// Add to undo stack
 	m.undoStack = append(m.undoStack, snapshot)
 
 	// Limit history size
-	if len(m.undoStack) > m.maxHistorySize {
-		m.undoStack = m.undoStack[1:]
-	}
+	m.undoStack = m.trimHistory(m.undoStack)
 
 	// Clear redo stack when new operation is performed
 	m.redoStack = m.redoStack[:0]
 }
 
+// trimHistory drops the oldest entries of a history stack beyond maxHistorySize
+func (m *Model) trimHistory(stack []ModelSnapshot) []ModelSnapshot {
+	if m.maxHistorySize > 0 && len(stack) > m.maxHistorySize {
+		return stack[len(stack)-m.maxHistorySize:]
+	}
+	return stack
+}
+
 // deepCopyTasks creates a deep copy of a task slice
 func (m *Model) deepCopyTasks(tasks []Task) []Task {
 	result := make([]Task, len(tasks))
 	for i, task := range tasks {
-		result[i] = Task{
-			id:       task.id,
-			title:    task.title,
-			status:   task.status,
-			subtasks: m.deepCopyTasks(task.subtasks),
-		}
+		result[i] = task
+		result[i].subtasks = m.deepCopyTasks(task.subtasks)
 	}
 	return result
 }
@@ -499,26 +1108,31 @@ func (m *Model) undo() {
 		return
 	}
 
-	// Save current state to redo stack
+	snapshot := m.undoStack[len(m.undoStack)-1]
+	m.undoStack = m.undoStack[:len(m.undoStack)-1]
+
+	// Save current state to redo stack, carrying the label so redo can describe it
 	currentSnapshot := ModelSnapshot{
 		tasks:      m.deepCopyTasks(m.tasks),
 		cursorID:   m.cursorID,
 		previousID: m.previousID,
+		label:      snapshot.label,
+		trashed:    snapshot.trashed,
 	}
 	m.redoStack = append(m.redoStack, currentSnapshot)
 
 	// Limit redo stack size
-	if len(m.redoStack) > m.maxHistorySize {
-		m.redoStack = m.redoStack[1:]
-	}
+	m.redoStack = m.trimHistory(m.redoStack)
 
 	// Restore from undo stack
-	snapshot := m.undoStack[len(m.undoStack)-1]
-	m.undoStack = m.undoStack[:len(m.undoStack)-1]
-
 	m.tasks = snapshot.tasks
 	m.cursorID = snapshot.cursorID
 	m.previousID = snapshot.previousID
+	if snapshot.trashed != nil {
+		// An undone restore puts the subtree back in the trash
+		m.trash = append(m.trash, *snapshot.trashed)
+	}
+	m.setStatus(historyMessage("Undid", snapshot.label))
 
 	m.autoSaveIfEnabled()
 }
@@ -529,30 +1143,42 @@ func (m *Model) redo() {
 		return
 	}
 
-	// Save current state to undo stack
+	snapshot := m.redoStack[len(m.redoStack)-1]
+	m.redoStack = m.redoStack[:len(m.redoStack)-1]
+
+	// Save current state to undo stack, keeping the label for a later undo
 	currentSnapshot := ModelSnapshot{
 		tasks:      m.deepCopyTasks(m.tasks),
 		cursorID:   m.cursorID,
 		previousID: m.previousID,
+		label:      snapshot.label,
+		trashed:    snapshot.trashed,
 	}
 	m.undoStack = append(m.undoStack, currentSnapshot)
 
 	// Limit undo stack size
-	if len(m.undoStack) > m.maxHistorySize {
-		m.undoStack = m.undoStack[1:]
-	}
+	m.undoStack = m.trimHistory(m.undoStack)
 
 	// Restore from redo stack
-	snapshot := m.redoStack[len(m.redoStack)-1]
-	m.redoStack = m.redoStack[:len(m.redoStack)-1]
-
 	m.tasks = snapshot.tasks
 	m.cursorID = snapshot.cursorID
 	m.previousID = snapshot.previousID
+	if snapshot.trashed != nil {
+		m.takeOutOfTrash(snapshot.trashed.id)
+	}
+	m.setStatus(historyMessage("Redid", snapshot.label))
 
 	m.autoSaveIfEnabled()
 }
 
+// historyMessage builds the status shown after undo or redo, e.g. "Undid: edit on 'Buy milk'"
+func historyMessage(verb, label string) string {
+	if label == "" {
+		return verb + " last change"
+	}
+	return verb + ": " + label
+}
+
 // copyCurrentTaskToClipboard copies the current task's title to the system clipboard
 func (m *Model) copyCurrentTaskToClipboard() {
 	task := m.getCurrentTask()
@@ -565,13 +1191,89 @@ func (m *Model) copyCurrentTaskToClipboard() {
 		m.setError("Failed to copy to clipboard: " + err.Error())
 		return
 	}
+	// The most recent copy or cut is what gets pasted
+	m.clipboardTask = nil
 
 	m.setStatus("Task copied to clipboard")
 	m.clearError()
 }
 
-// pasteTaskFromClipboard creates a new task below current position using clipboard contents
+// cutCurrentTask removes the current task and its subtasks like deleteCurrentTask,
+// keeping the whole subtree in the internal clipboard rather than the trash
+func (m *Model) cutCurrentTask() {
+	parent, index := m.findParentTask(m.cursorID)
+	if index < 0 {
+		m.setStatus("No task selected to cut")
+		return
+	}
+
+	m.takeSnapshot(m.taskLabel("cut", m.cursorID))
+
+	task := removeTaskFromSlice(m.getTaskContainer(parent), index)
+	task.subtasks = m.deepCopyTasks(task.subtasks)
+	m.clipboardTask = &task
+
+	m.updateCursorAfterDeletion()
+	m.setStatus("Task cut")
+	m.clearError()
+
+	m.autoSaveIfEnabled()
+}
+
+// copyListAsMarkdown copies the whole task tree to the system clipboard as a Markdown checklist
+func (m *Model) copyListAsMarkdown() {
+	if len(m.tasks) == 0 {
+		m.setStatus("No tasks to copy")
+		return
+	}
+
+	markdown := storage.ExportMarkdown(ToTaskDataSlice(m.tasks))
+	if err := clipboard.WriteAll(markdown); err != nil {
+		m.setError("Failed to copy to clipboard: " + err.Error())
+		return
+	}
+
+	m.setStatus("Task list copied to clipboard as Markdown")
+	m.clearError()
+}
+
+// copySubtreeAsMarkdown copies the current task and its subtasks to the system
+// clipboard as a Markdown checklist, in the same format as the export command
+func (m *Model) copySubtreeAsMarkdown() {
+	task := m.getCurrentTask()
+	if task == nil {
+		m.setStatus("No task selected to copy")
+		return
+	}
+
+	if err := clipboard.WriteAll(subtreeMarkdown(*task)); err != nil {
+		m.setError("Failed to copy to clipboard: " + err.Error())
+		return
+	}
+	// The most recent copy or cut is what gets pasted
+	m.clipboardTask = nil
+
+	if count := task.DescendantCount(); count > 0 {
+		m.setStatus(fmt.Sprintf("Task and %d subtask(s) copied to clipboard as Markdown", count))
+	} else {
+		m.setStatus("Task copied to clipboard as Markdown")
+	}
+	m.clearError()
+}
+
+// subtreeMarkdown renders a task and its subtasks as a Markdown checklist
+func subtreeMarkdown(task Task) string {
+	return storage.ExportMarkdown([]storage.TaskData{ToTaskData(task)})
+}
+
+// pasteTaskFromClipboard creates a new task below current position using clipboard contents,
+// or inserts a copy of the cut subtree when the internal clipboard holds one
 func (m *Model) pasteTaskFromClipboard() {
+	if m.clipboardTask != nil {
+		m.pasteSubtree(false)
+		return
+	}
+
 	clipContent, err := clipboard.ReadAll()
 	if err != nil {
 		m.setError("Failed to read from clipboard: " + err.Error())
@@ -582,6 +1284,10 @@ func (m *Model) pasteTaskFromClipboard() {
 		m.setStatus("Clipboard is empty")
 		return
 	}
+	if outline := storage.ParseOutline(clipContent); len(storage.FlattenTasks(outline)) > 1 {
+		m.pasteOutline(outline, false)
+		return
+	}
 
 	// Reuse existing task creation infrastructure
 	m.previousID = m.cursorID
@@ -595,8 +1301,14 @@ func (m *Model) pasteTaskFromClipboard() {
 	}
 }
 
-// pasteTaskAsSubtask creates a new subtask using clipboard contents
+// pasteTaskAsSubtask creates a new subtask using clipboard contents,
+// or adds a copy of the cut subtree when the internal clipboard holds one
 func (m *Model) pasteTaskAsSubtask() {
+	if m.clipboardTask != nil {
+		m.pasteSubtree(true)
+		return
+	}
+
 	clipContent, err := clipboard.ReadAll()
 	if err != nil {
 		m.setError("Failed to read from clipboard: " + err.Error())
@@ -607,6 +1319,10 @@ func (m *Model) pasteTaskAsSubtask() {
 		m.setStatus("Clipboard is empty")
 		return
 	}
+	if outline := storage.ParseOutline(clipContent); len(storage.FlattenTasks(outline)) > 1 {
+		m.pasteOutline(outline, true)
+		return
+	}
 
 	// Reuse existing subtask creation infrastructure
 	m.previousID = m.cursorID
@@ -619,3 +1335,243 @@ func (m *Model) pasteTaskAsSubtask() {
 		m.clearError()
 	}
 }
+
+// pasteSubtree inserts a copy of the internal clipboard's subtree below the current task,
+// or at the end of its subtasks. The copy gets fresh IDs so pasting twice doesn't
+// leave two tasks with the same ID.
+func (m *Model) pasteSubtree(asSubtask bool) {
+	task := withFreshIDs(*m.clipboardTask)
+
+	m.takeSnapshot(fmt.Sprintf("paste '%s'", task.title))
+	m.insertPastedTasks([]Task{task}, asSubtask)
+	if asSubtask {
+		m.setStatus("Subtree pasted as subtask")
+	} else {
+		m.setStatus("Subtree pasted")
+	}
+	m.clearError()
+
+	m.autoSaveIfEnabled()
+}
+
+// pasteOutline inserts the tasks parsed from several lines of clipboard text,
+// nested by indentation, as one change that a single undo removes
+func (m *Model) pasteOutline(outline []storage.TaskData, asSubtask bool) {
+	count := len(storage.FlattenTasks(outline))
+	m.takeSnapshot(fmt.Sprintf("paste %d tasks", count))
+	m.insertPastedTasks(FromTaskDataSlice(outline), asSubtask)
+	if asSubtask {
+		m.setStatus(fmt.Sprintf("%d tasks pasted from clipboard as subtasks", count))
+	} else {
+		m.setStatus(fmt.Sprintf("%d tasks pasted from clipboard", count))
+	}
+	m.clearError()
+
+	m.autoSaveIfEnabled()
+}
+
+// insertPastedTasks inserts tasks below the current task, or at the end of its
+// subtasks, or at the end of the list when no task is selected, and moves the
+// cursor to the first of them
+func (m *Model) insertPastedTasks(tasks []Task, asSubtask bool) {
+	parent, index := m.findParentTask(m.cursorID)
+	switch {
+	case index < 0:
+		m.tasks = append(m.tasks, tasks...)
+	case asSubtask:
+		current := &(*m.getTaskContainer(parent))[index]
+		current.subtasks = append(current.subtasks, tasks...)
+		current.collapsed = false
+	default:
+		container := m.getTaskContainer(parent)
+		*container = slices.Insert(*container, index+1, tasks...)
+	}
+
+	m.previousID = m.cursorID
+	m.cursorID = tasks[0].id
+}
+
+// withFreshIDs returns a deep copy of a task and its subtasks with new IDs
+func withFreshIDs(task Task) Task {
+	task.id = uuid.New().String()
+	subtasks := make([]Task, len(task.subtasks))
+	for i, subtask := range task.subtasks {
+		subtasks[i] = withFreshIDs(subtask)
+	}
+	task.subtasks = subtasks
+	return task
+}
+
+// promptEstimate asks for a time estimate for the current task
+func (m *Model) promptEstimate() {
+	task := m.getCurrentTask()
+	if task == nil {
+		m.setStatus("No task selected")
+		return
+	}
+
+	taskID := task.id
+	m.openPrompt("Estimate (e.g. 2h, 30m; empty clears)", storage.FormatEstimate(task.estimate), func(m *Model, value string) {
+		m.setEstimate(taskID, value)
+	})
+}
+
+// setEstimate parses and sets a task's time estimate, rejecting invalid input
+func (m *Model) setEstimate(taskID string, value string) {
+	estimate, err := storage.ParseEstimate(value)
+	if err != nil {
+		m.setStatus(err.Error())
+		return
+	}
+
+	task := m.findTaskByID(taskID)
+	if task == nil || task.estimate == estimate {
+		return
+	}
+
+	m.takeSnapshot(m.taskLabel("estimate change", taskID))
+	m.modifyTaskByID(taskID, func(task *Task) {
+		task.estimate = estimate
+	})
+
+	if estimate == 0 {
+		m.setStatus("Estimate cleared")
+	} else {
+		m.setStatus("Estimate set to " + storage.FormatEstimate(estimate))
+	}
+}
+
+// changePriority raises (delta > 0) or lowers (delta < 0) the current task's priority,
+// staying between no priority and high
+func (m *Model) changePriority(delta int) {
+	task := m.getCurrentTask()
+	if task == nil {
+		return
+	}
+	priority := task.priority + delta
+	if priority < NoPriority || priority > HighPriority {
+		return
+	}
+
+	m.takeSnapshot(m.taskLabel("priority change", m.cursorID))
+	m.modifyCurrentTask(func(task *Task) {
+		task.priority = priority
+	})
+	m.setStatus("Priority: " + priorityNames[priority])
+}
+
+// priorityNames are the display names of the priority levels
+var priorityNames = [...]string{"none", "low", "medium", "high"}
+
+// sortByPriority reorders the current task's siblings from highest to lowest
+// priority, keeping the existing order within each level and subtasks attached
+func (m *Model) sortByPriority() {
+	parent, index := m.findParentTask(m.cursorID)
+	if index < 0 {
+		return
+	}
+	container := m.getTaskContainer(parent)
+	if slices.IsSortedFunc(*container, comparePriority) {
+		m.setStatus("Already sorted by priority")
+		return
+	}
+
+	m.takeSnapshot(m.taskLabel("sort by priority", m.cursorID))
+	slices.SortStableFunc(*container, comparePriority)
+	m.autoSaveIfEnabled()
+}
+
+// comparePriority orders tasks from highest to lowest priority
+func comparePriority(a, b Task) int {
+	return b.priority - a.priority
+}
+
+// promptDueDate asks for a due date for the current task
+func (m *Model) promptDueDate() {
+	task := m.getCurrentTask()
+	if task == nil {
+		m.setStatus("No task selected")
+		return
+	}
+
+	taskID := task.id
+	m.openPrompt("Due date (e.g. 2024-03-01, Mar 1, tomorrow; empty clears)", storage.FormatDueDate(task.due), func(m *Model, value string) {
+		m.setDueDate(taskID, value)
+	})
+}
+
+// setDueDate parses and sets a task's due date, showing an error for input it can't read
+func (m *Model) setDueDate(taskID string, value string) {
+	due, err := storage.ParseDueDate(value, time.Now())
+	if err != nil {
+		m.setError(err.Error())
+		return
+	}
+
+	task := m.findTaskByID(taskID)
+	if task == nil || storage.FormatDueDate(task.due) == storage.FormatDueDate(due) {
+		return
+	}
+
+	m.takeSnapshot(m.taskLabel("due date change", taskID))
+	m.modifyTaskByID(taskID, func(task *Task) {
+		task.due = due
+	})
+
+	if due == nil {
+		m.setStatus("Due date cleared")
+	} else {
+		m.setStatus("Due " + storage.FormatDueDate(due))
+	}
+}
+
+// dueState describes how a task's due date compares with today
+type dueState int
+
+const (
+	notDue dueState = iota
+	dueToday
+	overdue
+)
+
+// taskDueState reports whether an unfinished task is due today or overdue as of now
+func taskDueState(task Task, now time.Time) dueState {
+	if task.due == nil || task.status == Done {
+		return notDue
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, task.due.Location())
+	switch {
+	case task.due.Before(today):
+		return overdue
+	case task.due.Equal(today):
+		return dueToday
+	}
+	return notDue
+}
+
+// taskProgress returns how many of a task's leaf descendants are Done, out of
+// all its leaf descendants. A task without subtasks has no progress to show.
+func taskProgress(task Task) (done, total int) {
+	for _, subtask := range task.subtasks {
+		if len(subtask.subtasks) == 0 {
+			total++
+			if subtask.status == Done {
+				done++
+			}
+			continue
+		}
+		subDone, subTotal := taskProgress(subtask)
+		done += subDone
+		total += subTotal
+	}
+	return done, total
+}
+
+// totalEstimate returns a task's own estimate plus the estimates of all its descendants
+func totalEstimate(task Task) time.Duration {
+	total := task.estimate
+	for _, subtask := range task.subtasks {
+		total += totalEstimate(subtask)
+	}
+	return total
+}
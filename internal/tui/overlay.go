@@ -0,0 +1,66 @@
+package tui
+
+import (
+	"github.com/charmbracelet/bubbles/v2/key"
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/lipgloss/v2"
+)
+
+// List overlays: pick one entry of a list shown in place of the task list,
+// shared by the undo history, the trash and the list picker
+
+// overlayAction is what a key does in a list overlay
+type overlayAction int
+
+const (
+	overlayNone    overlayAction = iota // Key handled, or ignored, with the overlay still open
+	overlayConfirm                      // Act on the selected entry
+	overlayClose                        // Close without acting
+)
+
+// handleListOverlay moves the selection of a list overlay with count entries
+// for Up and Down, and reports whether the key confirms or closes the overlay.
+// Cancel closes it, as does toggle, the key that opened it.
+func (m Model) handleListOverlay(msg tea.KeyMsg, selected *int, count int, toggle key.Binding) overlayAction {
+	switch {
+	case key.Matches(msg, m.keyMap.Up):
+		if *selected > 0 {
+			*selected--
+		}
+	case key.Matches(msg, m.keyMap.Down):
+		if *selected < count-1 {
+			*selected++
+		}
+	case key.Matches(msg, m.keyMap.Confirm):
+		return overlayConfirm
+	case key.Matches(msg, m.keyMap.Cancel), key.Matches(msg, toggle):
+		return overlayClose
+	}
+	return overlayNone
+}
+
+// renderListOverlay renders a heading above one row per entry, underlining the selected one.
+// It returns the rows and the lines on which the selected entry starts and ends.
+func (m Model) renderListOverlay(width int, heading string, entries []string, selected int) ([]string, int, int) {
+	rows := []string{HelpStyle.Render(heading), ""}
+	textWidth := width - CursorWidth
+	if textWidth < 0 {
+		textWidth = 0
+	}
+	selectedStart, selectedEnd := 0, 0
+	for i, entry := range entries {
+		isSelected := i == selected
+		style := TaskTodoStyle
+		if isSelected {
+			style = style.Underline(true)
+		}
+		text := style.Width(textWidth).Render(entry)
+		row := lipgloss.JoinHorizontal(lipgloss.Top, m.renderCursor(isSelected, false), text)
+		rows = append(rows, row)
+		if isSelected {
+			selectedEnd = lipgloss.Height(lipgloss.JoinVertical(lipgloss.Left, rows...))
+			selectedStart = selectedEnd - lipgloss.Height(row)
+		}
+	}
+	return rows, selectedStart, selectedEnd
+}
@@ -0,0 +1,191 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/charmbracelet/bubbles/v2/key"
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Pinned tasks: a few key tasks listed in a read-only section above the task
+// list, from which the cursor can jump to them
+
+// togglePin pins the current task to the pinned section, or unpins it
+func (m *Model) togglePin() {
+	task := m.getCurrentTask()
+	if task == nil {
+		return
+	}
+
+	pinned := !task.pinned
+	label := "unpin"
+	if pinned {
+		label = "pin"
+	}
+	m.takeSnapshot(m.taskLabel(label, m.cursorID))
+	m.modifyCurrentTask(func(task *Task) {
+		task.pinned = pinned
+	})
+
+	switch {
+	case !pinned:
+		m.setStatus("Task unpinned")
+	case m.showPinned:
+		m.setStatus("Task pinned")
+	default:
+		m.setStatus(fmt.Sprintf("Task pinned; press %s to show pinned tasks", m.keyMap.ShowPinned.Help().Key))
+	}
+}
+
+// toggleShowPinned shows or hides the pinned section
+func (m *Model) toggleShowPinned() {
+	m.showPinned = !m.showPinned
+	switch {
+	case !m.showPinned:
+		m.setStatus("Hiding pinned tasks")
+	case len(m.pinnedTaskIDs()) == 0:
+		m.setStatus(fmt.Sprintf("No pinned tasks yet; press %s to pin one", m.keyMap.TogglePin.Help().Key))
+	default:
+		m.setStatus("Showing pinned tasks")
+	}
+}
+
+// pinnedTaskIDs returns the IDs of all pinned tasks in tree order, including
+// those folded away or hidden by filters
+func (m Model) pinnedTaskIDs() []string {
+	var ids []string
+	var walk func(tasks []Task)
+	walk = func(tasks []Task) {
+		for i := range tasks {
+			if tasks[i].pinned {
+				ids = append(ids, tasks[i].id)
+			}
+			walk(tasks[i].subtasks)
+		}
+	}
+	walk(m.tasks)
+	return ids
+}
+
+// enterPinnedSection moves the cursor up from the first task into the pinned
+// section when it is shown, reporting whether it did
+func (m *Model) enterPinnedSection() bool {
+	if !m.showPinned {
+		return false
+	}
+	ids := m.getAllTaskIDs()
+	pinned := m.pinnedTaskIDs()
+	if len(pinned) == 0 || (len(ids) > 0 && ids[0] != m.cursorID) {
+		return false
+	}
+	m.pinnedCursor = len(pinned)
+	return true
+}
+
+// handlePinnedMode handles keys while the cursor is in the pinned section.
+// The entries are read-only: enter jumps to the task in the list.
+func (m Model) handlePinnedMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	pinned := m.pinnedTaskIDs()
+	if m.pinnedCursor > len(pinned) {
+		m.pinnedCursor = len(pinned)
+	}
+	if m.pinnedCursor == 0 {
+		return m.handleNormalMode(msg)
+	}
+
+	switch {
+	case key.Matches(msg, m.keyMap.Quit):
+		return m, tea.Quit
+	case key.Matches(msg, m.keyMap.Up):
+		if m.pinnedCursor > 1 {
+			m.pinnedCursor--
+		}
+	case key.Matches(msg, m.keyMap.Down):
+		if m.pinnedCursor++; m.pinnedCursor > len(pinned) {
+			m.pinnedCursor = 0 // Back to the task list
+		}
+	case key.Matches(msg, m.keyMap.Confirm):
+		id := pinned[m.pinnedCursor-1]
+		m.pinnedCursor = 0
+		m.revealTask(id)
+		m.jumpTo(id)
+	case key.Matches(msg, m.keyMap.Cancel):
+		m.pinnedCursor = 0
+	case key.Matches(msg, m.keyMap.ShowPinned):
+		m.pinnedCursor = 0
+		m.toggleShowPinned()
+	default:
+		m.setStatus("Pinned tasks are read-only here; press enter to jump to one")
+	}
+	return m, nil
+}
+
+// revealTask makes sure a task can be selected in the list: it unfolds its
+// ancestors, saving the folds once, and if it is still hidden clears the
+// filters and focus and shows deferred tasks
+func (m *Model) revealTask(taskID string) {
+	unfolded := false
+	for _, id := range m.getParentChainIDs(taskID) {
+		if ancestor := m.findTaskByID(id); ancestor.collapsed {
+			ancestor.collapsed = false
+			unfolded = true
+		}
+	}
+	if unfolded && !m.readOnly {
+		m.autoSaveIfEnabled()
+	}
+	if !slices.Contains(m.getAllTaskIDs(), taskID) {
+		m.clearFilters()
+		m.focusParentID = ""
+		m.showDeferred = true
+	}
+}
+
+// maxPinnedRows is how many pinned tasks the section shows at once
+const maxPinnedRows = 5
+
+// renderPinnedSection renders the pinned tasks, one line each, for the top of
+// the view, or "" while the section is hidden or empty
+func (m Model) renderPinnedSection(width int) string {
+	if !m.showPinned {
+		return ""
+	}
+	pinned := m.pinnedTaskIDs()
+	if len(pinned) == 0 {
+		return ""
+	}
+
+	// Only a few entries are shown, scrolling to keep the selected one in view
+	start := max(m.pinnedCursor-maxPinnedRows, 0)
+	end := min(start+maxPinnedRows, len(pinned))
+	heading := m.pinnedSymbol() + " Pinned"
+	if len(pinned) > maxPinnedRows {
+		heading += fmt.Sprintf(" (%d-%d of %d)", start+1, end, len(pinned))
+	}
+
+	rows := []string{PinnedStyle.Render(heading)}
+	textWidth := max(width-CursorWidth, 0)
+	for i := start; i < end; i++ {
+		task := m.findTaskByID(pinned[i])
+		isSelected := i+1 == m.pinnedCursor
+		style := GetTaskStyle(task.status)
+		if isSelected {
+			style = style.Underline(true)
+		}
+		title := ansi.Truncate(task.title, textWidth, "…")
+		row := lipgloss.JoinHorizontal(lipgloss.Top, m.renderCursor(isSelected, false), style.Render(title))
+		rows = append(rows, row)
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, rows...) + "\n"
+}
+
+// pinnedSymbol returns the marker shown for pinned tasks
+func (m Model) pinnedSymbol() string {
+	if m.ascii {
+		return ASCIIPinnedSymbol
+	}
+	return PinnedSymbol
+}
@@ -0,0 +1,89 @@
+package tui
+
+import (
+	"github.com/charmbracelet/bubbles/v2/key"
+	"github.com/charmbracelet/bubbles/v2/textinput"
+	tea "github.com/charmbracelet/bubbletea/v2"
+)
+
+// Prompts for input that isn't a task title (file names, confirmations)
+
+// inputPrompt asks the user for a single line of text
+type inputPrompt struct {
+	label    string
+	input    textinput.Model
+	onSubmit func(m *Model, value string)
+}
+
+// confirmPrompt asks the user a yes/no question answered with a single key
+type confirmPrompt struct {
+	message   string
+	onConfirm func(m *Model)
+}
+
+// openPrompt shows a text prompt in the footer and calls onSubmit with the entered value
+func (m *Model) openPrompt(label, initial string, onSubmit func(m *Model, value string)) {
+	ti := textinput.New()
+	ti.Prompt = ""
+	ti.SetStyles(GetTextInputStyles())
+	ti.SetValue(initial)
+	ti.CursorEnd()
+	ti.Focus()
+
+	m.prompt = &inputPrompt{
+		label:    label,
+		input:    ti,
+		onSubmit: onSubmit,
+	}
+}
+
+// askConfirm shows a yes/no question in the footer and calls onConfirm if accepted
+func (m *Model) askConfirm(message string, onConfirm func(m *Model)) {
+	m.confirm = &confirmPrompt{
+		message:   message,
+		onConfirm: onConfirm,
+	}
+}
+
+func (m Model) handlePromptMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keyMap.Confirm):
+		prompt := m.prompt
+		m.prompt = nil
+		prompt.onSubmit(&m, prompt.input.Value())
+		return m, nil
+	case key.Matches(msg, m.keyMap.Cancel):
+		m.prompt = nil
+		m.setStatus("Cancelled")
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.prompt.input, cmd = m.prompt.input.Update(msg)
+	return m, cmd
+}
+
+func (m Model) handleConfirmMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	confirm := m.confirm
+	m.confirm = nil
+
+	if key.Matches(msg, m.keyMap.Yes) {
+		confirm.onConfirm(&m)
+	} else {
+		m.setStatus("Cancelled")
+	}
+	return m, nil
+}
+
+// renderPrompt renders the active prompt or confirmation line for the footer
+func (m Model) renderPrompt() string {
+	switch {
+	case m.confirm != nil:
+		return PromptStyle.Render(m.confirm.message + " (y/n)")
+	case m.prompt != nil:
+		return PromptStyle.Render(m.prompt.label+": ") + m.prompt.input.View()
+	case m.finder != nil:
+		return m.renderFinder()
+	}
+	return ""
+}
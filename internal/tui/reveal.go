@@ -0,0 +1,43 @@
+package tui
+
+import (
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+// revealCommand returns the platform command that opens dir in the system file manager
+func revealCommand(dir string) *exec.Cmd {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", dir)
+	case "windows":
+		return exec.Command("explorer", dir)
+	default:
+		return exec.Command("xdg-open", dir)
+	}
+}
+
+// openContainingDirectory opens the directory holding the task file in the system file manager
+func (m *Model) openContainingDirectory() {
+	if m.filePath == "" {
+		m.setStatus("No file to open (not saving to a file)")
+		return
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(m.filePath))
+	if err != nil {
+		m.setError("Failed to resolve directory: " + err.Error())
+		return
+	}
+
+	// Start without waiting so the file manager doesn't block the TUI
+	cmd := revealCommand(dir)
+	if err := cmd.Start(); err != nil {
+		m.setError("Failed to open directory: " + err.Error())
+		return
+	}
+	go cmd.Wait() // Reap the process once it exits
+
+	m.setStatus("Opened " + dir)
+}
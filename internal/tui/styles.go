@@ -1,21 +1,16 @@
 package tui
 
 import (
+	"image/color"
+
+	"dotdot/internal/config"
+
 	"github.com/charmbracelet/bubbles/v2/help"
 	"github.com/charmbracelet/bubbles/v2/textinput"
 	tea "github.com/charmbracelet/bubbletea/v2"
 	"github.com/charmbracelet/lipgloss/v2"
 )
 
-// Color constants by semantic use
-const (
-	CursorColor     = "1" // Red - cursor and selection indicator
-	ActiveTaskColor = "2" // Green - active tasks
-	DimmedColor     = "8" // Gray - dimmed/disabled elements
-	ErrorBgColor    = "0" // Black - error message background
-	ErrorTextColor  = "1" // Red - error text
-)
-
 // UI spacing constants
 const (
 	CursorWidth  = 2
@@ -26,59 +21,146 @@ const (
 	TotalPadding = PaddingLeft + PaddingRight
 )
 
-// Pre-defined styles for consistent UI elements
+// Pre-defined styles for consistent UI elements, colored by ApplyTheme
 var (
-	// Error message styling
-	ErrorStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color(ErrorTextColor)).
-			Background(lipgloss.Color(ErrorBgColor)).
-			Padding(0, 1).
-			Margin(1, 0)
-
-	// Help text styling
-	HelpStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color(DimmedColor)).
-			Italic(true)
+	ErrorStyle           lipgloss.Style // Error message
+	HelpStyle            lipgloss.Style // Help text
+	PromptStyle          lipgloss.Style // Prompt line
+	FilterIndicatorStyle lipgloss.Style // Active filter indicator in the header
+	FullTitleStyle       lipgloss.Style // Full title of a truncated selection
+	NotesStyle           lipgloss.Style // Notes of the selection in the footer
+	StatusStyle          lipgloss.Style // Status message in the footer
 
 	// Help component styles
-	HelpKeyStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color(ActiveTaskColor))
+	HelpKeyStyle       lipgloss.Style
+	HelpDescStyle      lipgloss.Style
+	HelpSeparatorStyle lipgloss.Style
 
-	HelpDescStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color(DimmedColor))
+	// Task status styles
+	TaskDoneStyle   lipgloss.Style
+	TaskActiveStyle lipgloss.Style
+	TaskTodoStyle   lipgloss.Style
+	TagStyle        lipgloss.Style // #tags within titles
+
+	// Task metadata column (estimates), deferred tasks and tasks by due state
+	MetaStyle     lipgloss.Style
+	DimmedStyle   lipgloss.Style
+	OverdueStyle  lipgloss.Style
+	DueTodayStyle lipgloss.Style
+
+	// Priority markers, by urgency
+	PriorityHighStyle   lipgloss.Style
+	PriorityMediumStyle lipgloss.Style
+	PriorityLowStyle    lipgloss.Style
+
+	// Fold marker for collapsed tasks and marker for pinned ones
+	FoldMarkerStyle lipgloss.Style
+	PinnedStyle     lipgloss.Style
 
-	HelpSeparatorStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color(DimmedColor))
+	// Bullet styling
+	BulletStyle       lipgloss.Style
+	BulletDimmedStyle lipgloss.Style
 
-	// Task status styles
-	TaskDoneStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color(DimmedColor)).
-			Strikethrough(true)
+	// Cursor styling
+	CursorStyle         lipgloss.Style
+	CursorSelectedStyle lipgloss.Style
+	CursorDimmedStyle   lipgloss.Style
+)
+
+func init() {
+	ApplyTheme(config.DefaultTheme())
+}
+
+// themeColor converts a theme color to a lipgloss color, with "" meaning none
+func themeColor(c string) color.Color {
+	if c == "" {
+		return lipgloss.NoColor{}
+	}
+	return lipgloss.Color(c)
+}
 
-	TaskActiveStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color(ActiveTaskColor))
+// ApplyTheme colors all the styles with the given theme. Call it before creating
+// a Model, since the help and text input styles are copied when it is created.
+func ApplyTheme(theme config.Theme) {
+	cursor := themeColor(theme.Cursor)
+	active := themeColor(theme.Active)
+	done := themeColor(theme.Done)
+	dimmed := themeColor(theme.Dimmed)
+	errorText := themeColor(theme.Error)
+	warning := themeColor(theme.Warning)
 
+	ErrorStyle = lipgloss.NewStyle().
+		Foreground(errorText).
+		Background(themeColor(theme.ErrorBg)).
+		Padding(0, 1).
+		Margin(1, 0)
+	HelpStyle = lipgloss.NewStyle().Foreground(dimmed).Italic(true)
+	PromptStyle = lipgloss.NewStyle().Foreground(active).Bold(true)
+	FilterIndicatorStyle = lipgloss.NewStyle().Foreground(active)
+	FullTitleStyle = lipgloss.NewStyle().Foreground(dimmed)
+	NotesStyle = lipgloss.NewStyle().Foreground(dimmed).Italic(true)
+	StatusStyle = lipgloss.NewStyle().Foreground(themeColor(theme.Status))
+
+	HelpKeyStyle = lipgloss.NewStyle().Foreground(active)
+	HelpDescStyle = lipgloss.NewStyle().Foreground(dimmed)
+	HelpSeparatorStyle = lipgloss.NewStyle().Foreground(dimmed)
+
+	TaskDoneStyle = lipgloss.NewStyle().Foreground(done).Strikethrough(true)
+	TaskActiveStyle = lipgloss.NewStyle().Foreground(active)
 	TaskTodoStyle = lipgloss.NewStyle()
+	TagStyle = lipgloss.NewStyle().Foreground(themeColor(theme.Tag))
 
-	// Bullet styling
-	BulletStyle = lipgloss.NewStyle().Width(BulletWidth)
+	MetaStyle = lipgloss.NewStyle().Foreground(dimmed)
+	DimmedStyle = lipgloss.NewStyle().Foreground(dimmed)
+	OverdueStyle = lipgloss.NewStyle().Foreground(errorText)
+	DueTodayStyle = lipgloss.NewStyle().Foreground(warning)
 
-	BulletDimmedStyle = lipgloss.NewStyle().
-				Width(BulletWidth).
-				Foreground(lipgloss.Color(DimmedColor))
+	PriorityHighStyle = lipgloss.NewStyle().Foreground(errorText)
+	PriorityMediumStyle = lipgloss.NewStyle().Foreground(warning)
+	PriorityLowStyle = lipgloss.NewStyle().Foreground(dimmed)
+
+	FoldMarkerStyle = lipgloss.NewStyle().Foreground(active)
+	PinnedStyle = lipgloss.NewStyle().Foreground(warning)
+
+	BulletStyle = lipgloss.NewStyle().Width(BulletWidth)
+	BulletDimmedStyle = lipgloss.NewStyle().Width(BulletWidth).Foreground(dimmed)
 
-	// Cursor styling
 	CursorStyle = lipgloss.NewStyle().Width(CursorWidth)
+	CursorSelectedStyle = lipgloss.NewStyle().Width(CursorWidth).Foreground(cursor)
+	CursorDimmedStyle = lipgloss.NewStyle().Width(CursorWidth).Foreground(dimmed)
+}
+
+// Fold indicators for tasks with hidden and shown subtasks
+const (
+	FoldCollapsedSymbol = "▸"
+	FoldExpandedSymbol  = "▾"
+)
 
-	CursorSelectedStyle = lipgloss.NewStyle().
-				Width(CursorWidth).
-				Foreground(lipgloss.Color(CursorColor))
+// Indicators for tasks with notes and pinned tasks
+const (
+	NotesSymbol  = "¶"
+	PinnedSymbol = "★"
+)
 
-	CursorDimmedStyle = lipgloss.NewStyle().
-				Width(CursorWidth).
-				Foreground(lipgloss.Color(DimmedColor))
+// Plain ASCII stand-ins for the symbols above, for terminals or fonts without them
+const (
+	ASCIIFoldCollapsedSymbol = "+"
+	ASCIIFoldExpandedSymbol  = "-"
+	ASCIINotesSymbol         = "*"
+	ASCIIPinnedSymbol        = "^"
+	ASCIIBulletWidth         = 4
 )
 
+// Task status bullet symbols drawn in ASCII mode
+var ASCIIBulletSymbols = map[TaskStatus]string{
+	Done:   "[x]",
+	Active: "[~]",
+	Todo:   "[ ]",
+}
+
+// Priority markers shown before the title, indexed by priority (none has no marker)
+var PrioritySymbols = [...]string{"", "!", "!!", "!!!"}
+
 // Task status bullet symbols
 var BulletSymbols = map[TaskStatus]string{
 	Done:   "◉",
@@ -86,6 +168,30 @@ var BulletSymbols = map[TaskStatus]string{
 	Todo:   "○",
 }
 
+// bulletOverridesFor returns the valid bullet symbol overrides from cfg by status
+func bulletOverridesFor(cfg config.Config) map[TaskStatus]string {
+	symbols := make(map[TaskStatus]string)
+	overrides, _ := cfg.BulletOverrides() // Invalid entries are reported by the caller loading the config
+	for i, name := range config.StatusNames {
+		if symbol, ok := overrides[name]; ok {
+			symbols[TaskStatus(i)] = symbol
+		}
+	}
+	return symbols
+}
+
+// withBulletOverrides returns a copy of a bullet symbol set with the overrides applied
+func withBulletOverrides(base, overrides map[TaskStatus]string) map[TaskStatus]string {
+	symbols := make(map[TaskStatus]string, len(base))
+	for status, symbol := range base {
+		symbols[status] = symbol
+	}
+	for status, symbol := range overrides {
+		symbols[status] = symbol
+	}
+	return symbols
+}
+
 // GetTaskStyle returns the appropriate style for a task based on its status
 func GetTaskStyle(status TaskStatus) lipgloss.Style {
 	switch status {
@@ -0,0 +1,113 @@
+package tui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/google/uuid"
+)
+
+// Trash: deleted subtrees kept for the session so they can be restored after
+// the undo history has moved on
+
+// maxTrashSize bounds how many deleted subtrees the trash keeps
+const maxTrashSize = 20
+
+// trashBrowser is the open trash overlay
+type trashBrowser struct {
+	selected int // Index into the trash, 0 being the most recent deletion
+}
+
+// moveToTrash keeps a copy of a deleted subtree, dropping the oldest beyond maxTrashSize.
+// Blank tasks without subtasks, such as abandoned new tasks, aren't worth keeping.
+func (m *Model) moveToTrash(task Task) {
+	if task.title == "" && len(task.subtasks) == 0 {
+		return
+	}
+	task.subtasks = m.deepCopyTasks(task.subtasks)
+	m.trash = append(m.trash, task)
+	if len(m.trash) > maxTrashSize {
+		m.trash = m.trash[len(m.trash)-maxTrashSize:]
+	}
+}
+
+// openTrash shows the trash overlay
+func (m *Model) openTrash() {
+	if len(m.trash) == 0 {
+		m.setStatus("Trash is empty")
+		return
+	}
+	m.trashView = &trashBrowser{}
+}
+
+// restoreFromTrash moves a deleted subtree from the trash back to the end of
+// the top level, keeping its IDs unless they are already in use
+func (m *Model) restoreFromTrash(index int) {
+	i := len(m.trash) - 1 - index
+	entry := m.trash[i]
+	task := entry
+	task.subtasks = m.deepCopyTasks(entry.subtasks)
+
+	m.takeSnapshot(fmt.Sprintf("restore '%s'", task.title))
+	m.undoStack[len(m.undoStack)-1].trashed = &entry
+	m.trash = append(m.trash[:i], m.trash[i+1:]...)
+
+	// An undone delete puts the original back, so its IDs may be taken
+	var reassign func(task *Task)
+	reassign = func(task *Task) {
+		if m.findTaskByID(task.id) != nil {
+			task.id = uuid.New().String()
+		}
+		for j := range task.subtasks {
+			reassign(&task.subtasks[j])
+		}
+	}
+	reassign(&task)
+
+	m.tasks = append(m.tasks, task)
+	m.jumpTo(task.id)
+	m.ensureCursorVisible()
+	m.setStatus(fmt.Sprintf("Restored '%s'", task.title))
+
+	m.autoSaveIfEnabled()
+}
+
+// takeOutOfTrash removes the most recent trash entry with the given ID, for redoing a restore
+func (m *Model) takeOutOfTrash(taskID string) {
+	for i := len(m.trash) - 1; i >= 0; i-- {
+		if m.trash[i].id == taskID {
+			m.trash = append(m.trash[:i], m.trash[i+1:]...)
+			return
+		}
+	}
+}
+
+func (m Model) handleTrashMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch m.handleListOverlay(msg, &m.trashView.selected, len(m.trash), m.keyMap.Trash) {
+	case overlayConfirm:
+		index := m.trashView.selected
+		m.trashView = nil
+		m.restoreFromTrash(index)
+	case overlayClose:
+		m.trashView = nil
+	}
+	return m, nil
+}
+
+// renderTrash renders the trash, most recent deletion first, in place of the task list.
+// It returns the rows and the lines on which the selected entry starts and ends.
+func (m Model) renderTrash(width int) ([]string, int, int) {
+	entries := make([]string, len(m.trash))
+	for i := range m.trash {
+		task := m.trash[len(m.trash)-1-i]
+		label := task.title
+		if label == "" {
+			label = "untitled task"
+		}
+		if count := task.DescendantCount(); count > 0 {
+			label += fmt.Sprintf(" (+%d subtasks)", count)
+		}
+		entries[i] = fmt.Sprintf("%d. %s", i+1, label)
+	}
+	return m.renderListOverlay(width, "Trash: ↵ to restore to the top level, esc to close", entries, m.trashView.selected)
+}
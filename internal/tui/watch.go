@@ -0,0 +1,94 @@
+package tui
+
+import (
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+)
+
+// File watching: notice when the task file is changed by something else, such as
+// another editor or a sync tool, and reload it instead of overwriting the change
+
+// fileCheckInterval is how often the task file's modification time is polled
+const fileCheckInterval = time.Second
+
+// fileCheckMsg reports the task file's modification time, zero if it couldn't be read
+type fileCheckMsg struct {
+	path    string
+	modTime time.Time
+}
+
+// watchFile checks the task file's modification time after fileCheckInterval
+func (m Model) watchFile() tea.Cmd {
+	if m.filePath == "" {
+		return nil
+	}
+	path := m.filePath
+	return tea.Tick(fileCheckInterval, func(time.Time) tea.Msg {
+		return fileCheckMsg{path: path, modTime: modTimeOf(path)}
+	})
+}
+
+// modTimeOf returns a file's modification time, or zero if it can't be read
+func modTimeOf(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// recordFileModTime remembers the task file's modification time after loading or saving it
+func (m *Model) recordFileModTime() {
+	m.fileModTime = modTimeOf(m.filePath)
+}
+
+// handleFileCheck reloads the task file if it changed since it was last loaded or saved.
+// Local changes that couldn't be saved are only thrown away if the user agrees.
+func (m *Model) handleFileCheck(msg fileCheckMsg) {
+	if msg.path != m.filePath || msg.modTime.IsZero() || msg.modTime.Equal(m.fileModTime) {
+		return
+	}
+	// Wait until the user has finished typing; the next check notices the change again
+	if m.editing || m.notesEditor != nil || m.prompt != nil || m.finder != nil || m.confirm != nil || m.history != nil || m.trashView != nil || m.listPicker != nil {
+		return
+	}
+
+	if !m.unsaved {
+		m.reloadFile(msg.modTime)
+		return
+	}
+
+	// Only ask once per outside change
+	m.fileModTime = msg.modTime
+	m.setError("The task file was changed outside dotdot, but there are unsaved changes here")
+	m.askConfirm("Reload the file and lose the unsaved changes?", func(m *Model) {
+		m.reloadFile(modTimeOf(m.filePath))
+	})
+}
+
+// reloadFile replaces the task list with the file's contents, keeping the cursor on
+// the same task where it still exists. The previous list can be brought back with undo.
+func (m *Model) reloadFile(modTime time.Time) {
+	tasks, created, err := loadTasksFromFile(m.filePath)
+	if err != nil {
+		m.setError("Failed to reload tasks: " + err.Error())
+		return
+	}
+
+	m.takeSnapshot("reload from disk")
+	m.tasks = tasks
+	m.fileModTime = modTime
+	m.fileCreated = created
+	m.unsaved = false
+	if m.findTaskByID(m.cursorID) == nil {
+		m.cursorID = ""
+		if len(m.tasks) > 0 {
+			m.cursorID = m.tasks[0].id
+		}
+	}
+	m.ensureCursorVisible()
+	m.clearError()
+	m.setStatus("Reloaded: the task file was changed outside dotdot")
+}